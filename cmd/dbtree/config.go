@@ -0,0 +1,195 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/token"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/viveknathani/dbtree/config"
+	"github.com/viveknathani/dbtree/render"
+	"github.com/viveknathani/dbtree/store"
+	"github.com/viveknathani/dbtree/tui"
+)
+
+// setFlags returns the names of the flags given on the command line.
+func setFlags(fs *flag.FlagSet) map[string]bool {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	return set
+}
+
+// applyConfig fills in the settings of c that were not given as flags from
+// the configuration file.
+func applyConfig(c *Configuration, cfg *config.Config, set map[string]bool) {
+	if cfg.Conn != "" && !set["conn"] {
+		c.DatabaseUrl = cfg.Conn
+	}
+	if cfg.PasswordFile != "" && !set["password-file"] {
+		c.PasswordFile = cfg.PasswordFile
+	}
+	if cfg.Format != "" && !set["format"] {
+		c.Format = cfg.Format
+	}
+	if cfg.Shape != "" && !set["shape"] {
+		c.Shape = cfg.Shape
+	}
+	if cfg.Sort != "" && !set["sort"] {
+		c.SortBy = cfg.Sort
+	}
+	if cfg.Stats && !set["stats"] {
+		c.Stats = true
+	}
+	if len(cfg.Include) > 0 && !set["include"] {
+		c.Include = cfg.Include
+	}
+	if len(cfg.Exclude) > 0 && !set["exclude"] {
+		c.Exclude = cfg.Exclude
+	}
+	if cfg.Depth > 0 && !set["depth"] {
+		c.Depth = cfg.Depth
+	}
+	if cfg.Dialect != "" && !set["dialect"] {
+		c.Dialect = cfg.Dialect
+	}
+	if cfg.GoPackage != "" && !set["go-package"] {
+		c.GoPackage = cfg.GoPackage
+	}
+	if cfg.GoNaming != "" && !set["go-naming"] {
+		c.GoNaming = cfg.GoNaming
+	}
+	if cfg.GoNullable != "" && !set["go-nullable"] {
+		c.GoNullable = cfg.GoNullable
+	}
+	if cfg.Charset != "" && !set["charset"] {
+		c.Charset = cfg.Charset
+	}
+	if cfg.Width > 0 && !set["width"] {
+		c.Width = cfg.Width
+	}
+	// Outputs given on the command line replace the configured ones, and
+	// --format asks for stdout
+	if len(cfg.Output) > 0 && !set["output"] && !set["o"] && !set["format"] {
+		c.Outputs = cfg.Output
+	}
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// runOpen implements the open command, which launches the TUI with the
+// settings of the configuration file.
+func runOpen(args []string) error {
+	fs := flag.NewFlagSet("open", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "dbtree open - Launch interactive TUI mode\n")
+		fmt.Fprintf(os.Stderr, "Usage: %s open [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		printFlagDefaults(fs)
+	}
+
+	configPath := fs.String("config", "", "Read settings from this file instead of the "+config.FileName+" found in the working directory or its parents")
+	passwordFile := fs.String("password-file", "", "Read the master password of saved connections from this file")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := config.Discover(*configPath)
+	if err != nil {
+		return err
+	}
+
+	opts := tui.Options{
+		Format:   render.Format(cfg.Format),
+		Shape:    render.Shape(cfg.Shape),
+		Filter:   cfg.Filter(),
+		MaxDepth: cfg.Depth,
+		Stats:    cfg.Stats,
+		SortBy:   render.SortOrder(cfg.Sort),
+		Dialect:  render.Dialect(cfg.Dialect),
+		Go: render.GoOptions{
+			Package:  cfg.GoPackage,
+			Naming:   render.NamingStyle(cfg.GoNaming),
+			Nullable: render.NullStyle(cfg.GoNullable),
+		},
+		Charset: render.Charset(cfg.Charset),
+		Outputs: cfg.Output,
+	}
+
+	if opts.Format != "" && !slices.Contains(render.Formats, opts.Format) {
+		return fmt.Errorf("invalid format in %s", cfg.Path)
+	}
+	// The TUI shows text or json, so formats only the CLI writes, such as
+	// markdown or sql, start it with text
+	if opts.Format != render.FormatJSON {
+		opts.Format = render.FormatText
+	}
+	if opts.Shape != "" && opts.Shape != render.ShapeTree && opts.Shape != render.ShapeFlat && opts.Shape != render.ShapeChart {
+		return fmt.Errorf("invalid shape in %s (use tree, flat, or chart)", cfg.Path)
+	}
+	if opts.Dialect != "" && !slices.Contains(render.Dialects, opts.Dialect) {
+		return fmt.Errorf("invalid dialect in %s (use postgres, mysql, or sqlite)", cfg.Path)
+	}
+	if opts.Go.Package != "" && !token.IsIdentifier(opts.Go.Package) {
+		return fmt.Errorf("invalid go package name in %s", cfg.Path)
+	}
+	if opts.Go.Naming != "" && !slices.Contains(render.NamingStyles, opts.Go.Naming) {
+		return fmt.Errorf("invalid go naming style in %s (use snake, camel, or pascal)", cfg.Path)
+	}
+	if opts.Go.Nullable != "" && !slices.Contains(render.NullStyles, opts.Go.Nullable) {
+		return fmt.Errorf("invalid go nullable style in %s (use pointer or sql)", cfg.Path)
+	}
+	if opts.Charset != "" && !slices.Contains(render.Charsets, opts.Charset) {
+		return fmt.Errorf("invalid charset in %s (use ascii or unicode)", cfg.Path)
+	}
+	if opts.Shape == render.ShapeChart && opts.Format == render.FormatJSON {
+		return fmt.Errorf("chart shape is only supported with text format")
+	}
+	if opts.SortBy != "" && opts.SortBy != render.SortByName && opts.SortBy != render.SortByRows && opts.SortBy != render.SortBySize {
+		return fmt.Errorf("invalid sort in %s (use name, rows, or size)", cfg.Path)
+	}
+	if opts.SortBy != "" && opts.SortBy != render.SortByName && !opts.Stats {
+		return fmt.Errorf("sorting by rows or size in %s requires stats", cfg.Path)
+	}
+
+	// A saved connection is opened once the store is unlocked, and a
+	// connection URL right away
+	if name, ok := strings.CutPrefix(cfg.Conn, "@"); ok {
+		opts.Connection = name
+	} else if cfg.Conn != "" {
+		if isDBMLFile(cfg.Conn) {
+			return fmt.Errorf("conn in %s is a DBML file, which the TUI cannot open (use a database URL or @name)", cfg.Path)
+		}
+		conn, err := tui.NewConnection(store.StripPassword(cfg.Conn), cfg.Conn, "")
+		if err != nil {
+			return fmt.Errorf("invalid conn in %s: %w", cfg.Path, err)
+		}
+		opts.URL = &conn
+	}
+
+	if *passwordFile == "" {
+		*passwordFile = cfg.PasswordFile
+	}
+	if *passwordFile != "" {
+		if opts.Password, err = readPasswordFile(*passwordFile); err != nil {
+			return err
+		}
+	} else {
+		opts.Password = os.Getenv(passwordEnv)
+	}
+
+	return tui.Run(opts)
+}
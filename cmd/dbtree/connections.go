@@ -0,0 +1,710 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/viveknathani/dbtree/store"
+	"github.com/viveknathani/dbtree/tui"
+)
+
+// passwordEnv is the environment variable holding the master password of the
+// connection store, for use in scripts.
+const passwordEnv = "DBTREE_PASSWORD"
+
+// stdin is shared by all password prompts, so that passwords piped in on
+// separate lines are not lost to the buffering of an earlier prompt.
+var stdin = bufio.NewReader(os.Stdin)
+
+// runConnections implements the connections command, which manages the
+// encrypted connection store used by the TUI.
+func runConnections(args []string) error {
+	usage := func() {
+		fmt.Fprintf(os.Stderr, "dbtree connections - Manage saved connections\n")
+		fmt.Fprintf(os.Stderr, "Usage: %s connections <command> [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Commands:\n")
+		fmt.Fprintf(os.Stderr, "  list      List saved connections\n")
+		fmt.Fprintf(os.Stderr, "  add       Save a connection: add <name> <url>\n")
+		fmt.Fprintf(os.Stderr, "  remove    Delete a saved connection: remove <name>\n")
+		fmt.Fprintf(os.Stderr, "  show      Show a saved connection: show <name>\n")
+		fmt.Fprintf(os.Stderr, "  export    Write connections to a bundle: export [name...]\n")
+		fmt.Fprintf(os.Stderr, "  import    Add the connections of a bundle: import <file>\n")
+		fmt.Fprintf(os.Stderr, "  passwd    Change the master password\n")
+		fmt.Fprintf(os.Stderr, "  rekey     Change the cost of deriving the key from the master password\n\n")
+		fmt.Fprintf(os.Stderr, "The master password is read from --password-file, the %s\n", passwordEnv)
+		fmt.Fprintf(os.Stderr, "environment variable or a prompt, in that order. Saved connections can be\n")
+		fmt.Fprintf(os.Stderr, "used by other commands with --conn @name.\n")
+	}
+
+	if len(args) == 0 {
+		usage()
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "list":
+		return runConnectionsList(args[1:])
+	case "add":
+		return runConnectionsAdd(args[1:])
+	case "remove":
+		return runConnectionsRemove(args[1:])
+	case "show":
+		return runConnectionsShow(args[1:])
+	case "export":
+		return runConnectionsExport(args[1:])
+	case "import":
+		return runConnectionsImport(args[1:])
+	case "passwd":
+		return runConnectionsPasswd(args[1:])
+	case "rekey":
+		return runConnectionsRekey(args[1:])
+	case "help", "-h", "--help":
+		usage()
+		return nil
+	default:
+		usage()
+		return fmt.Errorf("unknown connections command: %s", args[0])
+	}
+}
+
+// newConnectionsFlagSet returns the flag set of a connections subcommand, with
+// the --password-file flag every subcommand accepts.
+func newConnectionsFlagSet(name, synopsis, description string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet("connections "+name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "dbtree connections %s - %s\n", name, description)
+		fmt.Fprintf(os.Stderr, "Usage: %s connections %s\n\n", os.Args[0], synopsis)
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		printFlagDefaults(fs)
+	}
+	passwordFile := fs.String("password-file", "", "Read the master password from this file")
+	return fs, passwordFile
+}
+
+// parseArgs parses flags that may appear before, between or after the
+// positional arguments, and returns the positional arguments.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// runConnectionsList prints the saved connections with their passwords redacted.
+func runConnectionsList(args []string) error {
+	fs, passwordFile := newConnectionsFlagSet("list", "list [options]", "List saved connections")
+	env := fs.String("env", "", "Only list connections of this environment")
+	tags := fs.String("tag", "", "Only list connections with all of these comma separated tags")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	s, err := openStore(*passwordFile, false)
+	if err != nil {
+		return err
+	}
+
+	connections, err := s.Load()
+	if err != nil {
+		return err
+	}
+
+	if len(connections) == 0 {
+		fmt.Fprintln(os.Stderr, "No saved connections yet.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tENV\tTAGS\tDRIVER\tURL\tLAST USED")
+	for _, conn := range connections {
+		if *env != "" && !strings.EqualFold(conn.Environment, *env) {
+			continue
+		}
+		if !slices.ContainsFunc(store.NormalizeTags(strings.Split(*tags, ",")), func(tag string) bool { return !conn.HasTag(tag) }) {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", conn.Name, orDash(conn.Environment), orDash(strings.Join(conn.Tags, ",")),
+				conn.Driver, redactURL(conn.URL), formatLastUsed(conn.LastUsed))
+		}
+	}
+	return w.Flush()
+}
+
+// runConnectionsAdd saves a new connection, creating the store on first use.
+func runConnectionsAdd(args []string) error {
+	fs, passwordFile := newConnectionsFlagSet("add", "add <name> <url> [options]", "Save a connection")
+	driver := fs.String("driver", "", "The database driver (detected from the URL if omitted)")
+	env := fs.String("env", "", "The environment of the connection, e.g. dev, staging or prod")
+	tags := fs.String("tags", "", "Comma separated tags of the connection")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	conn, err := tui.NewConnection(positional[0], positional[1], *driver)
+	if err != nil {
+		return err
+	}
+	conn.Environment = strings.ToLower(strings.TrimSpace(*env))
+	conn.Tags = store.NormalizeTags(strings.Split(*tags, ","))
+
+	s, err := openStore(*passwordFile, true)
+	if err != nil {
+		return err
+	}
+
+	if err := s.Add(conn); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Saved connection %s.\n", conn.Name)
+	return nil
+}
+
+// runConnectionsRemove deletes a saved connection.
+func runConnectionsRemove(args []string) error {
+	fs, passwordFile := newConnectionsFlagSet("remove", "remove <name> [options]", "Delete a saved connection")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	s, err := openStore(*passwordFile, false)
+	if err != nil {
+		return err
+	}
+
+	if _, err := findConnection(s, positional[0]); err != nil {
+		return err
+	}
+
+	if err := s.Remove(positional[0]); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Removed connection %s.\n", positional[0])
+	return nil
+}
+
+// runConnectionsShow prints the details of a saved connection.
+func runConnectionsShow(args []string) error {
+	fs, passwordFile := newConnectionsFlagSet("show", "show <name> [options]", "Show a saved connection")
+	reveal := fs.Bool("reveal", false, "Print the URL including its password")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	s, err := openStore(*passwordFile, false)
+	if err != nil {
+		return err
+	}
+
+	conn, err := findConnection(s, positional[0])
+	if err != nil {
+		return err
+	}
+
+	connURL := redactURL(conn.URL)
+	if *reveal {
+		connURL = conn.URL
+	}
+
+	fmt.Printf("Name:        %s\n", conn.Name)
+	fmt.Printf("Environment: %s\n", orDash(conn.Environment))
+	fmt.Printf("Tags:        %s\n", orDash(strings.Join(conn.Tags, ", ")))
+	fmt.Printf("Driver:      %s\n", conn.Driver)
+	fmt.Printf("URL:         %s\n", connURL)
+	fmt.Printf("Last used:   %s\n", formatLastUsed(conn.LastUsed))
+	return nil
+}
+
+// runConnectionsExport writes saved connections to a bundle that can be shared
+// and imported into another store.
+func runConnectionsExport(args []string) error {
+	fs, passwordFile := newConnectionsFlagSet("export", "export [name...] [options]", "Write saved connections to a bundle")
+	output := fs.String("output", "", "The bundle file to write (default: standard output)")
+	redact := fs.Bool("redact", false, "Remove passwords from the exported URLs")
+	encrypt := fs.Bool("encrypt", false, "Encrypt the bundle with a bundle password")
+	bundlePasswordFile := fs.String("bundle-password-file", "", "Read the bundle password from this file (implies --encrypt)")
+	names, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	s, err := openStore(*passwordFile, false)
+	if err != nil {
+		return err
+	}
+
+	var connections []store.Connection
+	if len(names) == 0 {
+		if connections, err = s.Load(); err != nil {
+			return err
+		}
+	} else {
+		for _, name := range names {
+			conn, err := findConnection(s, name)
+			if err != nil {
+				return err
+			}
+			connections = append(connections, conn)
+		}
+	}
+
+	if *redact {
+		for i := range connections {
+			connections[i].URL = store.StripPassword(connections[i].URL)
+		}
+	}
+
+	var bundlePassword string
+	if *encrypt || *bundlePasswordFile != "" {
+		if bundlePassword, err = newPassword(*bundlePasswordFile, "bundle"); err != nil {
+			return err
+		}
+	}
+
+	data, err := store.ExportBundle(connections, bundlePassword)
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		_, err := fmt.Println(string(data))
+		return err
+	}
+
+	if err := os.WriteFile(*output, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Exported %d connections to %s.\n", len(connections), *output)
+	return nil
+}
+
+// runConnectionsImport adds the connections of a bundle to the store. URLs
+// exported without their passwords are completed by prompting, when run in a
+// terminal.
+func runConnectionsImport(args []string) error {
+	fs, passwordFile := newConnectionsFlagSet("import", "import <file> [options]", "Add the connections of a bundle")
+	onConflict := fs.String("on-conflict", string(store.ConflictSkip), "What to do with connections whose name is taken (skip, overwrite, or rename)")
+	bundlePasswordFile := fs.String("bundle-password-file", "", "Read the bundle password from this file")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	mode := store.ConflictMode(*onConflict)
+	if mode != store.ConflictSkip && mode != store.ConflictOverwrite && mode != store.ConflictRename {
+		return fmt.Errorf("invalid conflict mode specified (use skip, overwrite, or rename)")
+	}
+
+	data, err := os.ReadFile(positional[0])
+	if err != nil {
+		return fmt.Errorf("failed to read bundle: %w", err)
+	}
+
+	encrypted, err := store.BundleEncrypted(data)
+	if err != nil {
+		return err
+	}
+
+	var bundlePassword string
+	if encrypted {
+		if *bundlePasswordFile != "" {
+			bundlePassword, err = readPasswordFile(*bundlePasswordFile)
+		} else {
+			bundlePassword, err = readPassword(stdin, "Bundle password: ")
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	connections, err := store.ReadBundle(data, bundlePassword)
+	if err != nil {
+		return err
+	}
+
+	s, err := openStore(*passwordFile, true)
+	if err != nil {
+		return err
+	}
+
+	saved, err := s.Load()
+	if err != nil {
+		return err
+	}
+	taken := make(map[string]bool, len(saved))
+	for _, conn := range saved {
+		taken[conn.Name] = true
+	}
+
+	var missing []string
+	for i, conn := range connections {
+		if !store.NeedsPassword(conn.URL) || mode == store.ConflictSkip && taken[conn.Name] {
+			continue
+		}
+		if !term.IsTerminal(os.Stdin.Fd()) {
+			missing = append(missing, conn.Name)
+			continue
+		}
+		password, err := readPassword(stdin, fmt.Sprintf("Password for %s (%s), empty for none: ", conn.Name, conn.URL))
+		if err != nil {
+			return err
+		}
+		if password != "" {
+			connections[i].URL = store.SetPassword(conn.URL, password)
+		}
+	}
+
+	result, err := s.Import(connections, mode)
+	if err != nil {
+		return fmt.Errorf("failed to import connections: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Imported %d connections", len(result.Added)+len(result.Renamed))
+	if len(result.Overwritten) > 0 {
+		fmt.Fprintf(os.Stderr, ", overwrote %d", len(result.Overwritten))
+	}
+	if len(result.Skipped) > 0 {
+		fmt.Fprintf(os.Stderr, ", skipped %d already saved (%s)", len(result.Skipped), strings.Join(result.Skipped, ", "))
+	}
+	fmt.Fprintln(os.Stderr, ".")
+	for _, conn := range connections {
+		if renamed, ok := result.Renamed[conn.Name]; ok {
+			fmt.Fprintf(os.Stderr, "  %s was saved as %s\n", conn.Name, renamed)
+		}
+	}
+	if len(missing) > 0 {
+		fmt.Fprintf(os.Stderr, "Connections without a password: %s (set them with the TUI editor)\n", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// newPassword reads a password to encrypt with, such as the bundle or the new
+// master password named by kind, from a file or a prompt with confirmation.
+func newPassword(passwordFile, kind string) (string, error) {
+	if passwordFile != "" {
+		return readPasswordFile(passwordFile)
+	}
+
+	password, err := readPassword(stdin, strings.ToUpper(kind[:1])+kind[1:]+" password: ")
+	if err != nil {
+		return "", err
+	}
+	if password == "" {
+		return "", fmt.Errorf("%s password must not be empty", kind)
+	}
+
+	confirmation, err := readPassword(stdin, "Confirm "+kind+" password: ")
+	if err != nil {
+		return "", err
+	}
+	if password != confirmation {
+		return "", fmt.Errorf("passwords do not match")
+	}
+
+	return password, nil
+}
+
+// openSavedConnection opens the saved connection referenced as @name by a
+// --conn flag and marks it as used.
+func openSavedConnection(ref, passwordFile string) (*sql.DB, error) {
+	s, err := openStore(passwordFile, false)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := findConnection(s, strings.TrimPrefix(ref, "@"))
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := tui.OpenConnection(context.Background(), &conn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.TouchLastUsed(conn.Name); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// openStore unlocks the connection store. Unless create is set, it is an
+// error for the store not to exist yet.
+func openStore(passwordFile string, create bool) (*store.Store, error) {
+	exists, err := store.Exists()
+	if err != nil {
+		return nil, err
+	}
+	if !exists && !create {
+		return nil, fmt.Errorf("no saved connections yet (add one with dbtree connections add)")
+	}
+
+	password, err := masterPassword(stdin, passwordFile)
+	if err != nil {
+		return nil, err
+	}
+
+	// A new store is encrypted with whatever is typed, so have it typed twice
+	if !exists && passwordFile == "" && os.Getenv(passwordEnv) == "" {
+		confirmation, err := readPassword(stdin, "Confirm master password: ")
+		if err != nil {
+			return nil, err
+		}
+		if password != confirmation {
+			return nil, fmt.Errorf("passwords do not match")
+		}
+	}
+
+	return store.NewStore(password)
+}
+
+// masterPassword returns the master password from the password file, the
+// DBTREE_PASSWORD environment variable or, failing both, a prompt.
+func masterPassword(stdin *bufio.Reader, passwordFile string) (string, error) {
+	if passwordFile != "" {
+		return readPasswordFile(passwordFile)
+	}
+
+	if password := os.Getenv(passwordEnv); password != "" {
+		return password, nil
+	}
+
+	return readPassword(stdin, "Master password: ")
+}
+
+// readPasswordFile reads a password from the first line of a file. Later
+// lines, such as a comment, are ignored.
+func readPasswordFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read password file: %w", err)
+	}
+	line, _, _ := strings.Cut(string(data), "\n")
+	return strings.TrimSuffix(line, "\r"), nil
+}
+
+// findConnection returns the saved connection with the given name.
+func findConnection(s *store.Store, name string) (store.Connection, error) {
+	connections, err := s.Load()
+	if err != nil {
+		return store.Connection{}, err
+	}
+
+	for _, conn := range connections {
+		if conn.Name == name {
+			return conn, nil
+		}
+	}
+
+	return store.Connection{}, fmt.Errorf("connection %s not found", name)
+}
+
+// redactURL masks the password of a connection URL. MySQL DSNs such as
+// user:pass@tcp(host:3306)/db are not valid URLs, so the user info is located
+// by hand when parsing fails.
+func redactURL(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil && u.Opaque == "" {
+		return u.Redacted()
+	}
+
+	rest := rawURL
+	if i := strings.Index(rest, "://"); i >= 0 {
+		rest = rest[i+3:]
+	}
+	at := strings.LastIndex(rest, "@")
+	if at < 0 {
+		return rawURL
+	}
+	colon := strings.Index(rest[:at], ":")
+	if colon < 0 {
+		return rawURL
+	}
+
+	prefix := rawURL[:len(rawURL)-len(rest)]
+	return prefix + rest[:colon+1] + "xxxxx" + rest[at:]
+}
+
+// orDash returns s, or "-" if it is empty.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// formatLastUsed formats a LastUsed timestamp.
+func formatLastUsed(lastUsed int64) string {
+	if lastUsed == 0 {
+		return "never"
+	}
+	return time.Unix(lastUsed, 0).Format("2006-01-02 15:04")
+}
+
+// runConnectionsPasswd re-encrypts the connection store under a new master password.
+func runConnectionsPasswd(args []string) error {
+	fs, passwordFile := newConnectionsFlagSet("passwd", "passwd [options]", "Change the master password")
+	newPasswordFile := fs.String("new-password-file", "", "Read the new master password from this file")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	exists, err := store.Exists()
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("no saved connections yet")
+	}
+
+	oldPassword, err := masterPassword(stdin, *passwordFile)
+	if err != nil {
+		return err
+	}
+
+	s, err := store.NewStore(oldPassword)
+	if err != nil {
+		return err
+	}
+
+	newPassword, err := newPassword(*newPasswordFile, "new master")
+	if err != nil {
+		return err
+	}
+
+	if err := s.ChangePassword(oldPassword, newPassword); err != nil {
+		return fmt.Errorf("failed to change password: %w", err)
+	}
+
+	fmt.Fprintln(os.Stderr, "Master password changed.")
+	return nil
+}
+
+// runConnectionsRekey re-encrypts the connection store with a key derived
+// with new Argon2id parameters. Parameters that are not given are kept.
+func runConnectionsRekey(args []string) error {
+	fs, passwordFile := newConnectionsFlagSet("rekey", "rekey [options]", "Change the cost of deriving the key from the master password")
+	timeCost := fs.Uint("time", 0, "The number of Argon2id passes (default: keep the current value)")
+	memory := fs.Uint("memory", 0, "The Argon2id memory in MiB (default: keep the current value)")
+	threads := fs.Uint("threads", 0, "The Argon2id parallelism (default: keep the current value)")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	if *memory > math.MaxUint32/1024 || *threads > math.MaxUint8 || *timeCost > math.MaxUint32 {
+		return fmt.Errorf("key derivation parameter out of range")
+	}
+
+	exists, err := store.Exists()
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("no saved connections yet")
+	}
+
+	password, err := masterPassword(stdin, *passwordFile)
+	if err != nil {
+		return err
+	}
+
+	s, err := store.NewStore(password)
+	if err != nil {
+		return err
+	}
+
+	previous := s.KDFParams()
+	params := previous
+	if *timeCost > 0 {
+		params.Time = uint32(*timeCost)
+	}
+	if *memory > 0 {
+		params.Memory = uint32(*memory) * 1024
+	}
+	if *threads > 0 {
+		params.Threads = uint8(*threads)
+	}
+
+	if err := s.Rekey(password, params); err != nil {
+		return fmt.Errorf("failed to rekey: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Key derivation changed from %s to %s.\n", previous, params)
+	return nil
+}
+
+// readPassword prompts for a password without echoing it. When stdin is not a
+// terminal, the password is read as a line so that it can be piped in.
+func readPassword(stdin *bufio.Reader, prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+
+	if term.IsTerminal(os.Stdin.Fd()) {
+		password, err := term.ReadPassword(os.Stdin.Fd())
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read password: %w", err)
+		}
+		return string(password), nil
+	}
+
+	line, err := stdin.ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	fmt.Fprintln(os.Stderr)
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+
+	"github.com/viveknathani/dbtree/database"
+)
+
+// runDiff implements the diff command, which compares the schemas of two
+// databases or DBML files, for example a design against the live database.
+// It exits with status 1 when the schemas differ.
+func runDiff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "dbtree diff - Compare two schemas\n")
+		fmt.Fprintf(os.Stderr, "Usage: %s diff --from <url|file.dbml> --to <url|file.dbml> [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Lists the tables and columns added (+), changed (~) and removed (-) going from one schema to the other.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		printFlagDefaults(fs)
+	}
+
+	from := fs.String("from", "", "The original schema: a database connection URL, @name for a saved connection, or a .dbml file")
+	to := fs.String("to", "", "The schema compared against it, in the same forms as --from")
+	passwordFile := fs.String("password-file", "", "Read the master password of saved connections from this file")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *from == "" || *to == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+
+	previous, err := loadSchema(ctx, *from, *passwordFile)
+	if err != nil {
+		return err
+	}
+
+	current, err := loadSchema(ctx, *to, *passwordFile)
+	if err != nil {
+		return err
+	}
+
+	changes := database.DiffSchemas(previous, current)
+	if changes.IsEmpty() {
+		fmt.Println("No differences")
+		return nil
+	}
+
+	printSchemaChanges(previous, current, changes)
+	os.Exit(1)
+	return nil
+}
+
+// printSchemaChanges prints the added, changed and removed tables, with the
+// columns and keys that differ for changed tables.
+func printSchemaChanges(previous, current *database.Database, changes database.SchemaChanges) {
+	for _, table := range changes.AddedTables {
+		fmt.Printf("+ %s\n", table)
+	}
+
+	for _, name := range changes.ChangedTables {
+		fmt.Printf("~ %s\n", name)
+		old, table := findTable(previous, name), findTable(current, name)
+
+		for _, column := range changes.Columns[name] {
+			if slices.ContainsFunc(old.Columns, func(c database.Column) bool { return c.Name == column }) {
+				fmt.Printf("    ~ %s\n", column)
+			} else {
+				fmt.Printf("    + %s\n", column)
+			}
+		}
+		for _, column := range changes.RemovedColumns[name] {
+			fmt.Printf("    - %s\n", column)
+		}
+
+		if !reflect.DeepEqual(old.Constraints, table.Constraints) {
+			fmt.Printf("    constraints differ\n")
+		}
+		if !reflect.DeepEqual(old.Indexes, table.Indexes) {
+			fmt.Printf("    indexes differ\n")
+		}
+	}
+
+	for _, table := range changes.RemovedTables {
+		fmt.Printf("- %s\n", table)
+	}
+}
+
+// findTable returns the table of a schema with the given name.
+func findTable(schema *database.Database, name string) *database.Table {
+	for i := range schema.Tables {
+		if schema.Tables[i].Name == name {
+			return &schema.Tables[i]
+		}
+	}
+	return &database.Table{}
+}
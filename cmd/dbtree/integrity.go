@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/viveknathani/dbtree/database"
+	"github.com/viveknathani/dbtree/graph"
+	"github.com/viveknathani/dbtree/render"
+)
+
+// runCheckIntegrity implements the check-integrity command, which reports rows
+// referencing a missing parent row for every foreign key in the schema graph.
+// It exits with status 1 when orphaned rows are found.
+func runCheckIntegrity(args []string) error {
+	fs := flag.NewFlagSet("check-integrity", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "dbtree check-integrity - Find rows referencing missing parent rows\n")
+		fmt.Fprintf(os.Stderr, "Usage: %s check-integrity --conn <url> [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		printFlagDefaults(fs)
+	}
+
+	dbUrl := fs.String("conn", "", "The database connection URL, or @name for a saved connection")
+	passwordFile := fs.String("password-file", "", "Read the master password of saved connections from this file")
+	tableName := fs.String("table", "", "Only check relationships of this referencing table")
+	maxRows := fs.Int("max-rows", 100000, "The maximum number of referencing rows checked per relationship")
+	samples := fs.Int("samples", 5, "The number of offending keys reported per relationship")
+	format := fs.String("format", string(render.FormatText), "The output format (text or json)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *dbUrl == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	if *format != string(render.FormatText) && *format != string(render.FormatJSON) {
+		return fmt.Errorf("invalid format specified (use text or json)")
+	}
+
+	if *maxRows <= 0 {
+		return fmt.Errorf("max rows must be positive")
+	}
+
+	db, err := connect(*dbUrl, *passwordFile)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	schema, err := database.InspectSchema(ctx, db)
+	if err != nil {
+		return fmt.Errorf("failed to inspect database schema: %w", err)
+	}
+
+	g, err := graph.Build(schema)
+	if err != nil {
+		return fmt.Errorf("failed to build schema graph: %w", err)
+	}
+
+	var checks []database.ForeignKeyCheck
+	for _, edge := range g.Edges {
+		if *tableName != "" && string(edge.FromTable) != *tableName {
+			continue
+		}
+		checks = append(checks, database.ForeignKeyCheck{
+			Table:            string(edge.FromTable),
+			Columns:          edge.Columns,
+			ReferenceTable:   string(edge.ToTable),
+			ReferenceColumns: edge.ReferenceColumns,
+		})
+	}
+
+	results, err := database.CheckForeignKeys(ctx, db, checks, database.IntegrityOptions{
+		MaxRows:    *maxRows,
+		SampleKeys: *samples,
+	})
+	if err != nil {
+		return err
+	}
+
+	output, err := render.RenderIntegrity(results, render.Format(*format))
+	if err != nil {
+		return fmt.Errorf("failed to render output: %w", err)
+	}
+
+	fmt.Println(output)
+
+	for _, result := range results {
+		if result.OrphanCount > 0 {
+			os.Exit(1)
+		}
+	}
+
+	return nil
+}
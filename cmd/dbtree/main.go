@@ -5,18 +5,21 @@ import (
 	"database/sql"
 	"flag"
 	"fmt"
+	"go/token"
 	"log"
 	"os"
+	"slices"
 	"strings"
 
 	_ "github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/charmbracelet/x/term"
 	_ "github.com/go-sql-driver/mysql"
 	_ "github.com/lib/pq"
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/viveknathani/dbtree/config"
 	"github.com/viveknathani/dbtree/database"
 	"github.com/viveknathani/dbtree/graph"
 	"github.com/viveknathani/dbtree/render"
-	"github.com/viveknathani/dbtree/tui"
 	"github.com/viveknathani/dbtree/updater"
 )
 
@@ -27,9 +30,39 @@ var (
 )
 
 type Configuration struct {
-	DatabaseUrl string
-	Format      string
-	Shape       string
+	DatabaseUrl  string
+	PasswordFile string
+	Format       string
+	Shape        string
+	Stats        bool
+	SortBy       string
+	Watch        watchFlag
+	Outputs      []string
+	Include      []string
+	Exclude      []string
+	Depth        int
+	Dialect      string
+	GoPackage    string
+	GoNaming     string
+	GoNullable   string
+	Charset      string
+	Width        int
+}
+
+// printFlagDefaults prints the flags of a flag set with their usage and defaults.
+func printFlagDefaults(fs *flag.FlagSet) {
+	fs.VisitAll(func(f *flag.Flag) {
+		dashes := "--"
+		if len(f.Name) == 1 {
+			dashes = "-"
+		}
+		fmt.Fprintf(os.Stderr, "  %s%s\n", dashes, f.Name)
+		fmt.Fprintf(os.Stderr, "        %s", f.Usage)
+		if f.DefValue != "" && f.DefValue != "false" {
+			fmt.Fprintf(os.Stderr, " (default: %s)", f.DefValue)
+		}
+		fmt.Fprintf(os.Stderr, "\n")
+	})
 }
 
 // parseFlags parses command-line flags and returns a Configuration struct.
@@ -39,36 +72,259 @@ func parseFlags() Configuration {
 		fmt.Fprintf(os.Stderr, "Usage: %s [options]\n\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "Commands:\n")
 		fmt.Fprintf(os.Stderr, "  open      Launch interactive TUI mode\n")
+		fmt.Fprintf(os.Stderr, "  profile   Profile the columns of a table (see dbtree profile --help)\n")
+		fmt.Fprintf(os.Stderr, "  diff      Compare two schemas, such as a DBML design and a database (see dbtree diff --help)\n")
+		fmt.Fprintf(os.Stderr, "  check-integrity\n")
+		fmt.Fprintf(os.Stderr, "            Find rows referencing missing parent rows (see dbtree check-integrity --help)\n")
+		fmt.Fprintf(os.Stderr, "  connections\n")
+		fmt.Fprintf(os.Stderr, "            Manage saved connections (see dbtree connections help)\n")
 		fmt.Fprintf(os.Stderr, "  update    Update dbtree to the latest version\n")
 		fmt.Fprintf(os.Stderr, "  version   Print the current version\n\n")
+		fmt.Fprintf(os.Stderr, "Settings are also read from a %s file in the working directory or its parents.\n\n", config.FileName)
 		fmt.Fprintf(os.Stderr, "Options:\n")
-		flag.VisitAll(func(f *flag.Flag) {
-			fmt.Fprintf(os.Stderr, "  --%s\n", f.Name)
-			fmt.Fprintf(os.Stderr, "        %s", f.Usage)
-			if f.DefValue != "" && f.DefValue != "false" {
-				fmt.Fprintf(os.Stderr, " (default: %s)", f.DefValue)
-			}
-			fmt.Fprintf(os.Stderr, "\n")
-		})
+		printFlagDefaults(flag.CommandLine)
 	}
 
-	dbUrl := flag.String("conn", "", "The database connection URL")
-	format := flag.String("format", string(render.FormatText), "The output format (text or json)")
+	configPath := flag.String("config", "", "Read settings from this file instead of the "+config.FileName+" found in the working directory or its parents")
+	dbUrl := flag.String("conn", "", "The database connection URL, @name for a saved connection, or a .dbml file")
+	passwordFile := flag.String("password-file", "", "Read the master password of saved connections from this file")
+	format := flag.String("format", string(render.FormatText), "The output format (text, json, markdown, mermaid, plantuml, dot, svg, dbml, sql, go, typescript, or jsonschema)")
+	dialect := flag.String("dialect", string(render.DialectPostgres), "The SQL dialect of the sql format (postgres, mysql, or sqlite)")
+	goPackage := flag.String("go-package", "models", "The package name of the go format")
+	goNaming := flag.String("go-naming", string(render.NamingSnake), "The case of json tags in the go format (snake, camel, or pascal)")
+	goNullable := flag.String("go-nullable", string(render.NullPointer), "The types of nullable columns in the go format (pointer or sql for sql.Null types)")
 	shape := flag.String("shape", string(render.ShapeTree), "The shape of the output (tree, flat, or chart)")
+	charset := flag.String("charset", string(render.CharsetASCII), "The characters the chart shape draws boxes and lines with (ascii or unicode)")
+	width := flag.Int("width", 0, "Fit the chart shape in this many columns (0 uses the terminal width, or no limit when not printing to a terminal)")
+	stats := flag.Bool("stats", false, "Include estimated row counts and table sizes")
+	sortBy := flag.String("sort", string(render.SortByName), "The table order of the flat shape (name, rows, or size)")
+	include := flag.String("include", "", "Only show tables matching these comma-separated globs")
+	exclude := flag.String("exclude", "", "Hide tables matching these comma-separated globs")
+	depth := flag.Int("depth", 0, "Show at most this many levels of tables in the tree shape (0 shows all)")
+	var outputs outputsFlag
+	flag.Var(&outputs, "output", "Write the schema to this file instead of stdout, in the format of its extension (.txt, .json, .md, .mmd, .puml, .dot, .svg, .dbml, .sql, .go, .ts, or .schema.json); can be repeated")
+	flag.Var(&outputs, "o", "Shorthand for --output")
+	var watch watchFlag
+	flag.Var(&watch, "watch", "Poll the database and print the schema again when it changes, every 2s or the given interval (--watch=5s)")
 	help := flag.Bool("help", false, "Display help information")
 
 	flag.Parse()
 
+	// --watch takes an optional interval, so "--watch 5s" leaves the interval
+	// as the first argument, followed by any remaining flags
+	if watch.enabled && flag.NArg() > 0 {
+		if err := watch.Set(flag.Arg(0)); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(2)
+		}
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+
 	if *help {
 		flag.Usage()
 		os.Exit(0)
 	}
 
-	return Configuration{
-		DatabaseUrl: *dbUrl,
-		Format:      *format,
-		Shape:       *shape,
+	configuration := Configuration{
+		DatabaseUrl:  *dbUrl,
+		PasswordFile: *passwordFile,
+		Format:       *format,
+		Shape:        *shape,
+		Stats:        *stats,
+		SortBy:       *sortBy,
+		Watch:        watch,
+		Outputs:      outputs,
+		Include:      splitList(*include),
+		Exclude:      splitList(*exclude),
+		Depth:        *depth,
+		Dialect:      *dialect,
+		GoPackage:    *goPackage,
+		GoNaming:     *goNaming,
+		GoNullable:   *goNullable,
+		Charset:      *charset,
+		Width:        *width,
+	}
+
+	cfg, err := config.Discover(*configPath)
+	if err != nil {
+		log.Fatalf("error: %v", err)
+	}
+	applyConfig(&configuration, cfg, setFlags(flag.CommandLine))
+
+	return configuration
+}
+
+// openDatabase determines the database driver from the connection URL, opens
+// the database and verifies that the connection is established.
+func openDatabase(databaseUrl string) (*sql.DB, error) {
+	var driver string
+	if strings.HasPrefix(databaseUrl, "postgres://") || strings.HasPrefix(databaseUrl, "postgresql://") {
+		driver = "postgres"
+	} else if strings.HasPrefix(databaseUrl, "mysql://") {
+		driver = "mysql"
+		// Remove the mysql:// prefix for the MySQL driver
+		databaseUrl = strings.TrimPrefix(databaseUrl, "mysql://")
+	} else if strings.HasPrefix(databaseUrl, "clickhouse://") {
+		driver = "clickhouse"
+	} else if strings.HasPrefix(databaseUrl, "sqlite://") {
+		driver = "sqlite3"
+		// Remove the sqlite:// prefix for the SQLite driver
+		databaseUrl = strings.TrimPrefix(databaseUrl, "sqlite://")
+	} else if strings.HasSuffix(databaseUrl, ".db") || strings.HasSuffix(databaseUrl, ".sqlite") || strings.HasSuffix(databaseUrl, ".sqlite3") {
+		// Assume SQLite if the URL ends with a database file extension
+		driver = "sqlite3"
+	} else {
+		return nil, fmt.Errorf("unsupported database URL format (supported: postgres://, mysql://, clickhouse://, sqlite://, or .db/.sqlite/.sqlite3 file)")
+	}
+
+	db, err := sql.Open(driver, databaseUrl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// Verify the connection is actually established
+	if err := db.PingContext(context.Background()); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	return db, nil
+}
+
+// connect opens the database given to --conn, which is either a connection
+// URL or @name for a connection saved with dbtree connections add.
+func connect(conn, passwordFile string) (*sql.DB, error) {
+	if strings.HasPrefix(conn, "@") {
+		return openSavedConnection(conn, passwordFile)
+	}
+	return openDatabase(conn)
+}
+
+// isDBMLFile reports whether the value of --conn is a DBML file rather than
+// a database.
+func isDBMLFile(conn string) bool {
+	return strings.HasSuffix(strings.ToLower(conn), ".dbml")
+}
+
+// loadSchema inspects the schema of the database given to --conn, or parses
+// it when conn is a DBML file.
+func loadSchema(ctx context.Context, conn, passwordFile string) (*database.Database, error) {
+	if isDBMLFile(conn) {
+		return database.ParseDBMLFile(conn)
+	}
+
+	db, err := connect(conn, passwordFile)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	schema, err := database.InspectSchema(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect database schema: %w", err)
 	}
+	return schema, nil
+}
+
+// inspectSchema inspects the schema of db, optionally with table statistics,
+// and builds its graph of the included tables.
+func inspectSchema(ctx context.Context, db *sql.DB, config Configuration) (*database.Database, *graph.SchemaGraph, error) {
+	schema, err := database.InspectSchema(ctx, db)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to inspect database schema: %w", err)
+	}
+
+	if schema == nil {
+		return nil, nil, fmt.Errorf("no schema information found")
+	}
+
+	if config.Stats {
+		if err := database.InspectStats(ctx, db, schema); err != nil {
+			return nil, nil, fmt.Errorf("failed to gather table statistics: %w", err)
+		}
+	}
+
+	g, err := buildGraph(schema, config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return schema, g, nil
+}
+
+// buildGraph builds the graph of the tables of schema included by the
+// command-line flags.
+func buildGraph(schema *database.Database, config Configuration) (*graph.SchemaGraph, error) {
+	g, err := graph.Build(schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build schema graph: %w", err)
+	}
+
+	if g == nil {
+		return nil, fmt.Errorf("schema graph is nil")
+	}
+
+	if len(config.Include) > 0 || len(config.Exclude) > 0 {
+		if g, err = graph.Filter(g, config.Include, config.Exclude); err != nil {
+			return nil, fmt.Errorf("failed to filter tables: %w", err)
+		}
+	}
+
+	return g, nil
+}
+
+// printSchema writes the schema graph to the outputs, or prints it when there
+// are none.
+func printSchema(g *graph.SchemaGraph, config Configuration, outputs []output) error {
+	if len(outputs) > 0 {
+		return writeOutputs(g, outputs, render.Shape(config.Shape), renderOptions(config))
+	}
+
+	renderedOutput, err := render.RenderWithOptions(g, render.Format(config.Format), render.Shape(config.Shape), terminalOptions(renderOptions(config)))
+	if err != nil {
+		return fmt.Errorf("failed to render output: %w", err)
+	}
+
+	fmt.Println(renderedOutput)
+	return nil
+}
+
+// renderOptions returns the render options selected by the command-line flags.
+func renderOptions(config Configuration) render.Options {
+	return render.Options{
+		SortBy:   render.SortOrder(config.SortBy),
+		MaxDepth: config.Depth,
+		Dialect:  render.Dialect(config.Dialect),
+		Go: render.GoOptions{
+			Package:  config.GoPackage,
+			Naming:   render.NamingStyle(config.GoNaming),
+			Nullable: render.NullStyle(config.GoNullable),
+		},
+		Charset: render.Charset(config.Charset),
+		Width:   config.Width,
+	}
+}
+
+// terminalOptions adapts render options to printing on stdout. When it is a
+// terminal, the chart shape fits its width unless --width is given, and is
+// coloured unless NO_COLOR is set.
+func terminalOptions(opts render.Options) render.Options {
+	if !term.IsTerminal(os.Stdout.Fd()) {
+		return opts
+	}
+	if opts.Width == 0 {
+		if width, _, err := term.GetSize(os.Stdout.Fd()); err == nil {
+			opts.Width = width
+		}
+	}
+	opts.Color = colorEnabled()
+	return opts
+}
+
+// colorEnabled reports whether output to the terminal may be coloured, which
+// the NO_COLOR environment variable turns off.
+func colorEnabled() bool {
+	return os.Getenv("NO_COLOR") == ""
 }
 
 func main() {
@@ -83,7 +339,27 @@ func main() {
 			}
 			return
 		case "open":
-			if err := tui.Run(); err != nil {
+			if err := runOpen(os.Args[2:]); err != nil {
+				log.Fatalf("error: %v", err)
+			}
+			return
+		case "profile":
+			if err := runProfile(os.Args[2:]); err != nil {
+				log.Fatalf("error: %v", err)
+			}
+			return
+		case "diff":
+			if err := runDiff(os.Args[2:]); err != nil {
+				log.Fatalf("error: %v", err)
+			}
+			return
+		case "check-integrity":
+			if err := runCheckIntegrity(os.Args[2:]); err != nil {
+				log.Fatalf("error: %v", err)
+			}
+			return
+		case "connections":
+			if err := runConnections(os.Args[2:]); err != nil {
 				log.Fatalf("error: %v", err)
 			}
 			return
@@ -97,73 +373,97 @@ func main() {
 		os.Exit(1)
 	}
 
-	if config.Format != string(render.FormatText) && config.Format != string(render.FormatJSON) {
-		log.Fatal("error: invalid format specified (use text or json)")
+	if !slices.Contains(render.Formats, render.Format(config.Format)) {
+		log.Fatal("error: invalid format specified (use text, json, markdown, mermaid, plantuml, dot, svg, dbml, sql, go, typescript, or jsonschema)")
+	}
+
+	if !slices.Contains(render.Dialects, render.Dialect(config.Dialect)) {
+		log.Fatal("error: invalid dialect specified (use postgres, mysql, or sqlite)")
+	}
+
+	if !token.IsIdentifier(config.GoPackage) {
+		log.Fatal("error: invalid go package name specified")
+	}
+
+	if !slices.Contains(render.NamingStyles, render.NamingStyle(config.GoNaming)) {
+		log.Fatal("error: invalid go naming style specified (use snake, camel, or pascal)")
+	}
+
+	if !slices.Contains(render.NullStyles, render.NullStyle(config.GoNullable)) {
+		log.Fatal("error: invalid go nullable style specified (use pointer or sql)")
 	}
 
 	if config.Shape != string(render.ShapeTree) && config.Shape != string(render.ShapeFlat) && config.Shape != string(render.ShapeChart) {
 		log.Fatal("error: invalid shape specified (use tree, flat, or chart)")
 	}
 
-	if config.Shape == string(render.ShapeChart) && config.Format == string(render.FormatJSON) {
+	if config.Shape == string(render.ShapeChart) && config.Format == string(render.FormatJSON) && len(config.Outputs) == 0 {
 		log.Fatal("error: chart shape is only supported with text format")
 	}
 
-	// Determine the database driver from the connection URL
-	var driver string
-	if strings.HasPrefix(config.DatabaseUrl, "postgres://") || strings.HasPrefix(config.DatabaseUrl, "postgresql://") {
-		driver = "postgres"
-	} else if strings.HasPrefix(config.DatabaseUrl, "mysql://") {
-		driver = "mysql"
-		// Remove the mysql:// prefix for the MySQL driver
-		config.DatabaseUrl = strings.TrimPrefix(config.DatabaseUrl, "mysql://")
-	} else if strings.HasPrefix(config.DatabaseUrl, "clickhouse://") {
-		driver = "clickhouse"
-	} else if strings.HasPrefix(config.DatabaseUrl, "sqlite://") {
-		driver = "sqlite3"
-		// Remove the sqlite:// prefix for the SQLite driver
-		config.DatabaseUrl = strings.TrimPrefix(config.DatabaseUrl, "sqlite://")
-	} else if strings.HasSuffix(config.DatabaseUrl, ".db") || strings.HasSuffix(config.DatabaseUrl, ".sqlite") || strings.HasSuffix(config.DatabaseUrl, ".sqlite3") {
-		// Assume SQLite if the URL ends with a database file extension
-		driver = "sqlite3"
-	} else {
-		log.Fatal("error: unsupported database URL format (supported: postgres://, mysql://, clickhouse://, sqlite://, or .db/.sqlite/.sqlite3 file)")
+	if config.Depth < 0 {
+		log.Fatal("error: depth must be a non-negative number")
 	}
 
-	db, err := sql.Open(driver, config.DatabaseUrl)
-	if err != nil {
-		log.Fatalf("error: failed to open database: %v", err)
+	if !slices.Contains(render.Charsets, render.Charset(config.Charset)) {
+		log.Fatal("error: invalid charset specified (use ascii or unicode)")
 	}
-	defer db.Close()
 
-	// Verify the connection is actually established
-	if err := db.PingContext(context.Background()); err != nil {
-		log.Fatalf("error: failed to connect to database: %v", err)
+	if config.Width < 0 {
+		log.Fatal("error: width must be a non-negative number")
 	}
 
-	schema, err := database.InspectSchema(context.Background(), db)
-
+	outputs, err := parseOutputs(config.Outputs, render.Shape(config.Shape))
 	if err != nil {
-		log.Fatalf("error: failed to inspect database schema: %v", err)
+		log.Fatalf("error: %v", err)
 	}
 
-	if schema == nil {
-		log.Fatal("error: no schema information found")
+	if config.SortBy != string(render.SortByName) && config.SortBy != string(render.SortByRows) && config.SortBy != string(render.SortBySize) {
+		log.Fatal("error: invalid sort specified (use name, rows, or size)")
 	}
 
-	graph, err := graph.Build(schema)
+	if config.SortBy != string(render.SortByName) && !config.Stats {
+		log.Fatal("error: sorting by rows or size requires --stats")
+	}
+
+	if isDBMLFile(config.DatabaseUrl) {
+		if config.Watch.enabled || config.Stats {
+			log.Fatal("error: --watch and --stats need a database connection, not a DBML file")
+		}
+
+		schema, err := database.ParseDBMLFile(config.DatabaseUrl)
+		if err != nil {
+			log.Fatalf("error: %v", err)
+		}
+		g, err := buildGraph(schema, config)
+		if err != nil {
+			log.Fatalf("error: %v", err)
+		}
+		if err := printSchema(g, config, outputs); err != nil {
+			log.Fatalf("error: %v", err)
+		}
+		return
+	}
+
+	db, err := connect(config.DatabaseUrl, config.PasswordFile)
 	if err != nil {
-		log.Fatalf("error: failed to build schema graph: %v", err)
+		log.Fatalf("error: %v", err)
 	}
+	defer db.Close()
 
-	if graph == nil {
-		log.Fatal("error: schema graph is nil")
+	if config.Watch.enabled {
+		if err := watchSchema(db, config, outputs); err != nil {
+			log.Fatalf("error: %v", err)
+		}
+		return
 	}
 
-	renderedOutput, err := render.Render(graph, render.Format(config.Format), render.Shape(config.Shape))
+	_, graph, err := inspectSchema(context.Background(), db, config)
 	if err != nil {
-		log.Fatalf("error: failed to render output: %v", err)
+		log.Fatalf("error: %v", err)
 	}
 
-	fmt.Println(renderedOutput)
+	if err := printSchema(graph, config, outputs); err != nil {
+		log.Fatalf("error: %v", err)
+	}
 }
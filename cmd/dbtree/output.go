@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/viveknathani/dbtree/graph"
+	"github.com/viveknathani/dbtree/render"
+)
+
+// outputsFlag collects the paths given to a repeatable --output flag.
+type outputsFlag []string
+
+func (o *outputsFlag) String() string {
+	return strings.Join(*o, ", ")
+}
+
+func (o *outputsFlag) Set(path string) error {
+	*o = append(*o, path)
+	return nil
+}
+
+// output is a file to write the schema to, in the format inferred from its
+// extension.
+type output struct {
+	path   string
+	format render.Format
+}
+
+// parseOutputs infers the format of each output path.
+func parseOutputs(paths []string, shape render.Shape) ([]output, error) {
+	outputs := make([]output, 0, len(paths))
+	for _, path := range paths {
+		format, err := render.FormatForPath(path)
+		if err != nil {
+			return nil, err
+		}
+		if format == render.FormatJSON && shape == render.ShapeChart {
+			return nil, fmt.Errorf("%s: chart shape is only supported with text format", path)
+		}
+		outputs = append(outputs, output{path: path, format: format})
+	}
+	return outputs, nil
+}
+
+// writeOutputs renders the schema graph once for each output and writes it to
+// the output's file.
+func writeOutputs(g *graph.SchemaGraph, outputs []output, shape render.Shape, opts render.Options) error {
+	for _, out := range outputs {
+		rendered, err := render.RenderWithOptions(g, out.format, shape, opts)
+		if err != nil {
+			return fmt.Errorf("failed to render %s: %w", out.path, err)
+		}
+		if !strings.HasSuffix(rendered, "\n") {
+			rendered += "\n"
+		}
+		if err := os.WriteFile(out.path, []byte(rendered), 0644); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+	}
+	return nil
+}
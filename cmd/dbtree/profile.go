@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/viveknathani/dbtree/database"
+	"github.com/viveknathani/dbtree/render"
+)
+
+// runProfile implements the profile command, which samples a table and
+// reports per-column statistics.
+func runProfile(args []string) error {
+	fs := flag.NewFlagSet("profile", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "dbtree profile - Report per-column statistics for a sample of a table\n")
+		fmt.Fprintf(os.Stderr, "Usage: %s profile --conn <url> --table <name> [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		printFlagDefaults(fs)
+	}
+
+	dbUrl := fs.String("conn", "", "The database connection URL, or @name for a saved connection")
+	passwordFile := fs.String("password-file", "", "Read the master password of saved connections from this file")
+	tableName := fs.String("table", "", "The table to profile")
+	sampleRows := fs.Int("sample", 10000, "The maximum number of rows to sample")
+	topValues := fs.Int("top", 5, "The number of most frequent values to report per column")
+	format := fs.String("format", string(render.FormatText), "The output format (text or json)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *dbUrl == "" || *tableName == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	if *format != string(render.FormatText) && *format != string(render.FormatJSON) {
+		return fmt.Errorf("invalid format specified (use text or json)")
+	}
+
+	if *sampleRows <= 0 {
+		return fmt.Errorf("sample size must be positive")
+	}
+
+	db, err := connect(*dbUrl, *passwordFile)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	schema, err := database.InspectSchema(ctx, db)
+	if err != nil {
+		return fmt.Errorf("failed to inspect database schema: %w", err)
+	}
+
+	var table *database.Table
+	for i := range schema.Tables {
+		if schema.Tables[i].Name == *tableName {
+			table = &schema.Tables[i]
+			break
+		}
+	}
+
+	if table == nil {
+		return fmt.Errorf("table %q not found", *tableName)
+	}
+
+	profile, err := database.ProfileTable(ctx, db, table, database.ProfileOptions{
+		SampleRows: *sampleRows,
+		TopValues:  *topValues,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to profile table: %w", err)
+	}
+
+	output, err := render.RenderProfile(profile, render.Format(*format))
+	if err != nil {
+		return fmt.Errorf("failed to render output: %w", err)
+	}
+
+	fmt.Println(output)
+	return nil
+}
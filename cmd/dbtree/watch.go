@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/viveknathani/dbtree/database"
+	"github.com/viveknathani/dbtree/render"
+)
+
+// defaultWatchInterval is how often --watch polls when no interval is given.
+const defaultWatchInterval = 2 * time.Second
+
+// watchFlag is the value of --watch. It is given alone, like a boolean flag,
+// or with an interval such as --watch=5s.
+type watchFlag struct {
+	enabled  bool
+	interval time.Duration
+}
+
+func (w *watchFlag) String() string {
+	if w == nil || !w.enabled {
+		return ""
+	}
+	return w.interval.String()
+}
+
+func (w *watchFlag) Set(value string) error {
+	switch value {
+	case "true":
+		w.enabled, w.interval = true, defaultWatchInterval
+		return nil
+	case "false":
+		w.enabled = false
+		return nil
+	}
+
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		// A bare number is a number of seconds
+		seconds, convErr := strconv.Atoi(value)
+		if convErr != nil {
+			return fmt.Errorf("invalid watch interval %q (use a duration such as 5s)", value)
+		}
+		interval = time.Duration(seconds) * time.Second
+	}
+	if interval <= 0 {
+		return fmt.Errorf("watch interval must be positive")
+	}
+
+	w.enabled, w.interval = true, interval
+	return nil
+}
+
+func (w *watchFlag) IsBoolFlag() bool {
+	return true
+}
+
+// watchSchema polls the schema fingerprint of db every interval and prints the
+// schema again whenever it changes, highlighting the tables and columns that
+// changed since the previous render. When outputs are given, they are written
+// again instead. It runs until interrupted.
+func watchSchema(db *sql.DB, config Configuration, outputs []output) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	terminal := term.IsTerminal(os.Stdout.Fd())
+	var previous *database.Database
+	var fingerprint string
+
+	for {
+		current, err := database.SchemaFingerprint(ctx, db)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if err != nil {
+			// The database may be restarting, so keep polling
+			fmt.Fprintf(os.Stderr, "%s  %v\n", time.Now().Format(time.TimeOnly), err)
+		} else if current != fingerprint {
+			schema, err := renderWatched(ctx, db, config, outputs, previous, terminal)
+			if ctx.Err() != nil {
+				return nil
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s  %v\n", time.Now().Format(time.TimeOnly), err)
+			} else {
+				previous, fingerprint = schema, current
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(config.Watch.interval):
+		}
+	}
+}
+
+// renderWatched inspects and prints the schema for watch mode, highlighting
+// changes since previous, or writes it to outputs. The screen is cleared
+// before printing when stdout is a terminal. It returns the inspected schema.
+func renderWatched(ctx context.Context, db *sql.DB, config Configuration, outputs []output, previous *database.Database, terminal bool) (*database.Database, error) {
+	schema, g, err := inspectSchema(ctx, db, config)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := database.DiffSchemas(previous, schema)
+	var rendered string
+	if len(outputs) > 0 {
+		if err := writeOutputs(g, outputs, render.Shape(config.Shape), renderOptions(config)); err != nil {
+			return nil, err
+		}
+	} else {
+		opts := terminalOptions(renderOptions(config))
+		opts.Changed = changes.Changed()
+		if terminal && colorEnabled() {
+			opts.Highlight = highlightChange
+		}
+
+		rendered, err = render.RenderWithOptions(g, render.Format(config.Format), render.Shape(config.Shape), opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render output: %w", err)
+		}
+
+		if terminal {
+			fmt.Print("\033[H\033[2J")
+		}
+	}
+
+	// The status line goes to stderr to keep stdout parseable
+	status := fmt.Sprintf("Every %s  %s", config.Watch.interval, time.Now().Format(time.TimeOnly))
+	if previous != nil {
+		if changes.IsEmpty() {
+			status += "  no structural changes"
+		} else {
+			status += "  changed: " + changes.String()
+		}
+	}
+	fmt.Fprintln(os.Stderr, status)
+	if len(outputs) == 0 {
+		fmt.Println(rendered)
+	}
+
+	return schema, nil
+}
+
+// highlightChange shows a changed table or column in bold yellow.
+func highlightChange(s string) string {
+	return "\033[1;33m" + s + "\033[0m"
+}
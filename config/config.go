@@ -0,0 +1,140 @@
+// Package config loads the project configuration file, which holds default
+// settings for the dbtree command line and TUI.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// FileName is the name of the configuration file looked up from the working
+// directory upwards.
+const FileName = ".dbtree.yaml"
+
+// Config holds the settings of a configuration file. Empty fields leave the
+// built-in defaults in place, and command-line flags override all of them.
+type Config struct {
+	// Conn is a connection URL, or @name for a saved connection.
+	Conn         string   `yaml:"conn"`
+	PasswordFile string   `yaml:"password_file"`
+	Format       string   `yaml:"format"`
+	Shape        string   `yaml:"shape"`
+	Sort         string   `yaml:"sort"`
+	Stats        bool     `yaml:"stats"`
+	Include      []string `yaml:"include"`
+	Exclude      []string `yaml:"exclude"`
+	Depth        int      `yaml:"depth"`
+	Dialect      string   `yaml:"dialect"`
+	GoPackage    string   `yaml:"go_package"`
+	GoNaming     string   `yaml:"go_naming"`
+	GoNullable   string   `yaml:"go_nullable"`
+	Charset      string   `yaml:"charset"`
+	Width        int      `yaml:"width"`
+	Output       []string `yaml:"output"`
+
+	// Path is the file the configuration was loaded from, or empty when no
+	// file was found.
+	Path string `yaml:"-"`
+}
+
+// Find looks for a configuration file in dir and its parents, returning an
+// empty path when there is none.
+func Find(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", dir, err)
+	}
+
+	for {
+		path := filepath.Join(dir, FileName)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("failed to check %s: %w", path, err)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// Load reads a configuration file. Unknown keys are rejected so that typos
+// do not go unnoticed. Relative output and password file paths are resolved
+// against the directory of the file.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	cfg := &Config{Path: path}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	if cfg.Depth < 0 {
+		return nil, fmt.Errorf("%s: depth must be a non-negative number", path)
+	}
+	if cfg.Width < 0 {
+		return nil, fmt.Errorf("%s: width must be a non-negative number", path)
+	}
+
+	dir := filepath.Dir(path)
+	if cfg.PasswordFile != "" {
+		cfg.PasswordFile = resolve(dir, cfg.PasswordFile)
+	}
+	for i, output := range cfg.Output {
+		cfg.Output[i] = resolve(dir, output)
+	}
+
+	return cfg, nil
+}
+
+// Discover loads the configuration file at path, or the one found from the
+// working directory upwards when path is empty. Without a file, the returned
+// configuration is empty.
+func Discover(path string) (*Config, error) {
+	if path == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get working directory: %w", err)
+		}
+		path, err = Find(wd)
+		if err != nil {
+			return nil, err
+		}
+		if path == "" {
+			return &Config{}, nil
+		}
+	}
+
+	return Load(path)
+}
+
+// Filter returns the table patterns of the configuration in the form of the
+// TUI filter, where exclude patterns are prefixed with "!".
+func (c *Config) Filter() []string {
+	filter := append([]string(nil), c.Include...)
+	for _, pattern := range c.Exclude {
+		filter = append(filter, "!"+pattern)
+	}
+	return filter
+}
+
+func resolve(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
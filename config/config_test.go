@@ -0,0 +1,124 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, FileName)
+	data := `
+conn: "@prod"
+password_file: secrets/master
+format: json
+shape: flat
+stats: true
+include: [users, "order*"]
+exclude: ["*_audit"]
+depth: 2
+dialect: sqlite
+go_package: schema
+go_naming: camel
+go_nullable: sql
+charset: unicode
+width: 100
+output:
+  - docs/schema.md
+  - /tmp/schema.svg
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+
+	expected := &Config{
+		Conn:         "@prod",
+		PasswordFile: filepath.Join(dir, "secrets/master"),
+		Format:       "json",
+		Shape:        "flat",
+		Stats:        true,
+		Include:      []string{"users", "order*"},
+		Exclude:      []string{"*_audit"},
+		Depth:        2,
+		Dialect:      "sqlite",
+		GoPackage:    "schema",
+		GoNaming:     "camel",
+		GoNullable:   "sql",
+		Charset:      "unicode",
+		Width:        100,
+		Output:       []string{filepath.Join(dir, "docs/schema.md"), "/tmp/schema.svg"},
+		Path:         path,
+	}
+	if !reflect.DeepEqual(cfg, expected) {
+		t.Errorf("expected %+v, got %+v", expected, cfg)
+	}
+
+	if filter := cfg.Filter(); !reflect.DeepEqual(filter, []string{"users", "order*", "!*_audit"}) {
+		t.Errorf("unexpected filter %v", filter)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := map[string]string{
+		"unknown key":    "fromat: json\n",
+		"negative depth": "depth: -1\n",
+		"negative width": "width: -1\n",
+		"wrong type":     "include: users\n",
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), FileName)
+			if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Load(path); err == nil {
+				t.Errorf("expected an error for %q", data)
+			}
+		})
+	}
+
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err := Load(path); err != nil || cfg.Format != "" {
+		t.Errorf("expected an empty file to load as an empty config, got %+v (err %v)", cfg, err)
+	}
+}
+
+func TestFind(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if path, err := Find(nested); err != nil || strings.HasPrefix(path, root) {
+		t.Errorf("expected no config below %s, got %q (err %v)", root, path, err)
+	}
+
+	expected := filepath.Join(root, "a", FileName)
+	if err := os.WriteFile(expected, []byte("shape: flat\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := Find(nested)
+	if err != nil || path != expected {
+		t.Errorf("expected %s, got %q (err %v)", expected, path, err)
+	}
+
+	t.Chdir(nested)
+	cfg, err := Discover("")
+	if err != nil || cfg.Shape != "flat" || cfg.Path != expected {
+		t.Errorf("expected to discover %s, got %+v (err %v)", expected, cfg, err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"regexp"
 	"strings"
 )
 
@@ -11,7 +12,9 @@ import (
 type clickhouseInspector struct{}
 
 // InspectSchema inspects a ClickHouse database and returns its complete schema.
-// It retrieves all tables, columns, and primary keys from the current database.
+// It retrieves all tables, columns, and primary keys from the current database,
+// along with the data flow between tables introduced by materialized views and
+// Distributed tables.
 // Note: ClickHouse does not enforce foreign keys, so they are not included.
 func (c *clickhouseInspector) InspectSchema(ctx context.Context, db *sql.DB) (*Database, error) {
 	dbName, err := c.getDatabaseName(ctx, db)
@@ -34,10 +37,22 @@ func (c *clickhouseInspector) InspectSchema(ctx context.Context, db *sql.DB) (*D
 		return nil, fmt.Errorf("failed to get all constraints: %w", err)
 	}
 
+	allIndexes, err := c.getAllIndexes(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get all indexes: %w", err)
+	}
+
+	allDataSources, err := c.getAllDataSources(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data lineage: %w", err)
+	}
+
 	for i := range tables {
 		tableName := tables[i].Name
 		tables[i].Columns = allColumns[tableName]
 		tables[i].Constraints = allConstraints[tableName]
+		tables[i].Indexes = allIndexes[tableName]
+		tables[i].DataSources = allDataSources[tableName]
 	}
 
 	return &Database{
@@ -54,13 +69,14 @@ func (c *clickhouseInspector) getDatabaseName(ctx context.Context, db *sql.DB) (
 }
 
 // getTables retrieves all tables from the current database.
-// Excludes views and dictionary tables.
+// Excludes plain views and dictionary tables. Materialized views are kept
+// because they are part of the data lineage between tables.
 func (c *clickhouseInspector) getTables(ctx context.Context, db *sql.DB) ([]Table, error) {
 	query := `
-		SELECT name
+		SELECT name, comment
 		FROM system.tables
 		WHERE database = currentDatabase()
-		  AND engine NOT LIKE '%View%'
+		  AND (engine NOT LIKE '%View%' OR engine = 'MaterializedView')
 		  AND engine NOT LIKE 'Dictionary%'
 		ORDER BY name
 	`
@@ -74,10 +90,11 @@ func (c *clickhouseInspector) getTables(ctx context.Context, db *sql.DB) ([]Tabl
 	var tables []Table
 	for rows.Next() {
 		var tableName string
-		if err := rows.Scan(&tableName); err != nil {
+		var comment string
+		if err := rows.Scan(&tableName, &comment); err != nil {
 			return nil, err
 		}
-		tables = append(tables, Table{Name: tableName})
+		tables = append(tables, Table{Name: tableName, Comment: comment})
 	}
 
 	return tables, rows.Err()
@@ -92,7 +109,8 @@ func (c *clickhouseInspector) getAllColumns(ctx context.Context, db *sql.DB) (ma
 		  name,
 		  type,
 		  default_kind,
-		  default_expression
+		  default_expression,
+		  comment
 		FROM system.columns
 		WHERE database = currentDatabase()
 		ORDER BY table, position
@@ -111,8 +129,9 @@ func (c *clickhouseInspector) getAllColumns(ctx context.Context, db *sql.DB) (ma
 		var columnType string
 		var defaultKind string
 		var defaultExpression string
+		var comment string
 
-		if err := rows.Scan(&tableName, &columnName, &columnType, &defaultKind, &defaultExpression); err != nil {
+		if err := rows.Scan(&tableName, &columnName, &columnType, &defaultKind, &defaultExpression, &comment); err != nil {
 			return nil, err
 		}
 
@@ -120,6 +139,8 @@ func (c *clickhouseInspector) getAllColumns(ctx context.Context, db *sql.DB) (ma
 			Name:       columnName,
 			Type:       DataType(c.formatClickHouseType(columnType)),
 			IsNullable: strings.HasPrefix(columnType, "Nullable("),
+			Comment:    comment,
+			EnumValues: parseEnumValues(columnType),
 		}
 
 		if defaultKind != "" && defaultExpression != "" {
@@ -187,6 +208,318 @@ func (c *clickhouseInspector) getPrimaryKeys(ctx context.Context, db *sql.DB) (m
 	return pks, rows.Err()
 }
 
+// getAllIndexes retrieves the data skipping indexes of all tables. The primary
+// key of a MergeTree table is its sparse index and is reported as a constraint.
+func (c *clickhouseInspector) getAllIndexes(ctx context.Context, db *sql.DB) (map[string][]Index, error) {
+	query := `
+		SELECT table, name, type, expr
+		FROM system.data_skipping_indices
+		WHERE database = currentDatabase()
+		ORDER BY table, name
+	`
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string][]Index)
+	for rows.Next() {
+		var tableName, indexName, indexType, expr string
+		if err := rows.Scan(&tableName, &indexName, &indexType, &expr); err != nil {
+			return nil, err
+		}
+
+		result[tableName] = append(result[tableName], Index{
+			Name:    fmt.Sprintf("%s (%s)", indexName, indexType),
+			Columns: []string{expr},
+		})
+	}
+
+	return result, rows.Err()
+}
+
+// getAllDataSources builds the data lineage of the current database.
+// Returns a map of table name to the tables whose data flows into it.
+//
+// Three sources of lineage are combined:
+//   - dependencies_table lists the materialized views reading from a table
+//   - the TO clause of a materialized view names the table it writes into
+//   - the engine parameters of a Distributed table name its local table
+func (c *clickhouseInspector) getAllDataSources(ctx context.Context, db *sql.DB) (map[string][]DataSource, error) {
+	query := `
+		SELECT
+		  currentDatabase(),
+		  name,
+		  engine,
+		  engine_full,
+		  create_table_query,
+		  dependencies_database,
+		  dependencies_table
+		FROM system.tables
+		WHERE database = currentDatabase()
+		ORDER BY name
+	`
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string][]DataSource)
+	addSource := func(tableName string, source DataSource) {
+		for _, existing := range result[tableName] {
+			if existing == source {
+				return
+			}
+		}
+		result[tableName] = append(result[tableName], source)
+	}
+
+	for rows.Next() {
+		var currentDatabase string
+		var tableName string
+		var engine string
+		var engineFull string
+		var createQuery string
+		var dependentDatabases []string
+		var dependentTables []string
+
+		if err := rows.Scan(&currentDatabase, &tableName, &engine, &engineFull, &createQuery,
+			&dependentDatabases, &dependentTables); err != nil {
+			return nil, err
+		}
+
+		// Materialized views reading from this table
+		for i, dependent := range dependentTables {
+			if i < len(dependentDatabases) && dependentDatabases[i] != currentDatabase {
+				continue
+			}
+			addSource(dependent, DataSource{Kind: MaterializedViewFlow, Table: tableName})
+		}
+
+		switch engine {
+		case "MaterializedView":
+			targetDatabase, targetTable := parseMaterializedViewTarget(createQuery)
+			if targetTable != "" && (targetDatabase == "" || targetDatabase == currentDatabase) {
+				addSource(targetTable, DataSource{Kind: MaterializedViewFlow, Table: tableName})
+			}
+		case "Distributed":
+			localDatabase, localTable := parseDistributedEngine(engineFull)
+			if localTable != "" && (localDatabase == "" || localDatabase == currentDatabase) {
+				addSource(tableName, DataSource{Kind: DistributedFlow, Table: localTable})
+			}
+		}
+	}
+
+	return result, rows.Err()
+}
+
+var (
+	// materializedViewHeadPattern cuts a CREATE MATERIALIZED VIEW statement
+	// before its SELECT so that the TO clause search cannot match inside it.
+	materializedViewHeadPattern = regexp.MustCompile(`(?is)^(.*?)\sAS\s+(SELECT|WITH|\()`)
+	materializedViewToPattern   = regexp.MustCompile("(?is)^CREATE\\s+MATERIALIZED\\s+VIEW\\s+.*?\\sTO\\s+((?:`[^`]+`|[^\\s(.]+)(?:\\.(?:`[^`]+`|[^\\s(.]+))?)")
+)
+
+// parseMaterializedViewTarget extracts the database and table named in the TO
+// clause of a CREATE MATERIALIZED VIEW statement. The database is empty when the
+// target is not qualified, and both are empty when the view has no TO clause.
+func parseMaterializedViewTarget(createQuery string) (string, string) {
+	head := createQuery
+	if match := materializedViewHeadPattern.FindStringSubmatch(createQuery); match != nil {
+		head = match[1]
+	}
+
+	match := materializedViewToPattern.FindStringSubmatch(head)
+	if match == nil {
+		return "", ""
+	}
+
+	return splitQualifiedName(match[1])
+}
+
+// parseDistributedEngine extracts the database and table from Distributed engine
+// parameters such as Distributed('cluster', 'db', 'events_local', rand()).
+// The database is empty when it is given as currentDatabase().
+func parseDistributedEngine(engineFull string) (string, string) {
+	start := strings.Index(engineFull, "(")
+	if !strings.HasPrefix(engineFull, "Distributed") || start == -1 {
+		return "", ""
+	}
+
+	args := splitEngineArguments(engineFull[start+1:])
+	if len(args) < 3 {
+		return "", ""
+	}
+
+	database := unquoteClickHouseIdentifier(args[1])
+	if strings.EqualFold(database, "currentDatabase()") {
+		database = ""
+	}
+
+	return database, unquoteClickHouseIdentifier(args[2])
+}
+
+// splitEngineArguments splits the comma-separated arguments of an engine
+// definition, respecting quotes and nested parentheses. Parsing stops at the
+// parenthesis closing the argument list.
+func splitEngineArguments(s string) []string {
+	var args []string
+	var current strings.Builder
+	depth := 0
+	var quote rune
+
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			current.WriteRune(r)
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+			current.WriteRune(r)
+		case r == '(':
+			depth++
+			current.WriteRune(r)
+		case r == ')':
+			if depth == 0 {
+				return append(args, strings.TrimSpace(current.String()))
+			}
+			depth--
+			current.WriteRune(r)
+		case r == ',' && depth == 0:
+			args = append(args, strings.TrimSpace(current.String()))
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+
+	return append(args, strings.TrimSpace(current.String()))
+}
+
+// splitQualifiedName splits a possibly database-qualified ClickHouse name
+// into its database and table parts.
+func splitQualifiedName(name string) (string, string) {
+	if strings.HasPrefix(name, "`") {
+		if end := strings.Index(name[1:], "`"); end != -1 {
+			first := name[1 : end+1]
+			rest := name[end+2:]
+			if strings.HasPrefix(rest, ".") {
+				return first, unquoteClickHouseIdentifier(rest[1:])
+			}
+			return "", first
+		}
+	}
+
+	if i := strings.Index(name, "."); i != -1 {
+		return unquoteClickHouseIdentifier(name[:i]), unquoteClickHouseIdentifier(name[i+1:])
+	}
+
+	return "", unquoteClickHouseIdentifier(name)
+}
+
+// unquoteClickHouseIdentifier strips the quotes around a string literal or identifier.
+func unquoteClickHouseIdentifier(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 {
+		first, last := s[0], s[len(s)-1]
+		if first == last && (first == '\'' || first == '"' || first == '`') {
+			return s[1 : len(s)-1]
+		}
+	}
+	return s
+}
+
+// SchemaFingerprint sums hashes of the column and table definitions in the
+// system tables of the current database.
+func (c *clickhouseInspector) SchemaFingerprint(ctx context.Context, db *sql.DB) (string, error) {
+	columns := `
+		SELECT concat(toString(count()), ':', toString(sum(cityHash64(table, name, type, default_kind, default_expression))))
+		FROM system.columns
+		WHERE database = currentDatabase()
+	`
+	tables := `
+		SELECT concat(toString(count()), ':', toString(sum(cityHash64(name, engine_full, create_table_query))))
+		FROM system.tables
+		WHERE database = currentDatabase()
+	`
+
+	return fingerprintQueries(ctx, db, columns, tables)
+}
+
+// InspectStats fills in row counts and compressed on-disk sizes from the active
+// parts in system.parts. Tables without parts of their own, such as views and
+// Distributed tables, are left without statistics.
+func (c *clickhouseInspector) InspectStats(ctx context.Context, db *sql.DB, tables []Table) error {
+	query := `
+		SELECT
+		  table,
+		  toInt64(sum(rows)),
+		  toInt64(sum(bytes_on_disk))
+		FROM system.parts
+		WHERE database = currentDatabase()
+		  AND active
+		GROUP BY table
+	`
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	stats := make(map[string]*TableStats)
+	for rows.Next() {
+		var tableName string
+		var rowCount, sizeBytes int64
+		if err := rows.Scan(&tableName, &rowCount, &sizeBytes); err != nil {
+			return err
+		}
+		stats[tableName] = &TableStats{RowCount: rowCount, SizeBytes: sizeBytes}
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range tables {
+		if tableStats, exists := stats[tables[i].Name]; exists {
+			tables[i].Stats = tableStats
+		}
+	}
+
+	return nil
+}
+
+// sampleQuery builds a query returning up to limit rows of a table. The SAMPLE
+// clause is only available for tables declaring a sampling key, so other tables
+// are read from the start.
+func (c *clickhouseInspector) sampleQuery(ctx context.Context, db *sql.DB, table string, columns []string, limit int) (string, bool, error) {
+	query := `
+		SELECT sampling_key
+		FROM system.tables
+		WHERE database = currentDatabase()
+		  AND name = ?
+	`
+
+	var samplingKey string
+	if err := db.QueryRowContext(ctx, query, table).Scan(&samplingKey); err != nil {
+		return "", false, err
+	}
+
+	selectQuery := fmt.Sprintf("SELECT %s FROM %s", selectColumns("clickhouse", columns), quoteIdentifier("clickhouse", table))
+	if samplingKey == "" {
+		return fmt.Sprintf("%s LIMIT %d", selectQuery, limit), false, nil
+	}
+
+	return fmt.Sprintf("%s SAMPLE %d LIMIT %d", selectQuery, limit, limit), true, nil
+}
+
 // formatClickHouseType formats ClickHouse types for display.
 // ClickHouse has types like: UInt64, String, Nullable(String), DateTime64(3), Array(String), etc.
 func (c *clickhouseInspector) formatClickHouseType(columnType string) string {
@@ -126,6 +126,137 @@ func TestClickHouseDatabaseDetection(t *testing.T) {
 	}
 }
 
+// TestClickHouseDataFlow tests that materialized views and Distributed tables are
+// reported as data lineage between tables.
+func TestClickHouseDataFlow(t *testing.T) {
+	// Connect to dockerized ClickHouse
+	db, err := sql.Open("clickhouse", clickhouseConnStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	// Verify connection
+	if err := db.Ping(); err != nil {
+		t.Skipf("Skipping test: ClickHouse not available: %v", err)
+	}
+
+	ctx := context.Background()
+
+	queries := []string{
+		`CREATE TABLE IF NOT EXISTS test_queue (raw String) ENGINE = Null`,
+		`CREATE TABLE IF NOT EXISTS test_events_local (id UInt64, payload String)
+		ENGINE = MergeTree() ORDER BY id`,
+		`CREATE MATERIALIZED VIEW IF NOT EXISTS test_mv_parse TO test_events_local AS
+		SELECT cityHash64(raw) AS id, raw AS payload FROM test_queue`,
+	}
+	for _, query := range queries {
+		if _, err := db.ExecContext(ctx, query); err != nil {
+			t.Fatalf("Failed to create test schema: %v", err)
+		}
+	}
+	defer func() {
+		for _, query := range []string{
+			`DROP TABLE IF EXISTS test_mv_parse`,
+			`DROP TABLE IF EXISTS test_events_local`,
+			`DROP TABLE IF EXISTS test_queue`,
+		} {
+			db.ExecContext(ctx, query)
+		}
+	}()
+
+	result, err := InspectSchema(ctx, db)
+	if err != nil {
+		t.Fatalf("InspectSchema failed: %v", err)
+	}
+
+	sources := make(map[string][]DataSource)
+	for _, table := range result.Tables {
+		sources[table.Name] = table.DataSources
+	}
+
+	if _, exists := sources["test_mv_parse"]; !exists {
+		t.Fatal("Expected materialized view test_mv_parse to be listed as a table")
+	}
+
+	expectSource := func(table string, source DataSource) {
+		for _, s := range sources[table] {
+			if s == source {
+				return
+			}
+		}
+		t.Errorf("Expected %s to have data source %+v, got %+v", table, source, sources[table])
+	}
+
+	expectSource("test_mv_parse", DataSource{Kind: MaterializedViewFlow, Table: "test_queue"})
+	expectSource("test_events_local", DataSource{Kind: MaterializedViewFlow, Table: "test_mv_parse"})
+}
+
+// TestParseMaterializedViewTarget tests extraction of the TO table from materialized view definitions.
+func TestParseMaterializedViewTarget(t *testing.T) {
+	tests := []struct {
+		query    string
+		database string
+		table    string
+	}{
+		{
+			query:    "CREATE MATERIALIZED VIEW db.mv_parse TO db.events_local (`id` UInt64) AS SELECT id FROM db.kafka_queue",
+			database: "db",
+			table:    "events_local",
+		},
+		{
+			query:    "CREATE MATERIALIZED VIEW `db`.`mv` TO `db`.`my table` AS SELECT * FROM src",
+			database: "db",
+			table:    "my table",
+		},
+		{
+			query: "CREATE MATERIALIZED VIEW mv TO target AS SELECT * FROM src",
+			table: "target",
+		},
+		{
+			query: "CREATE MATERIALIZED VIEW db.mv ENGINE = MergeTree ORDER BY id AS SELECT toDate(ts) AS d FROM src",
+		},
+	}
+
+	for _, tt := range tests {
+		database, table := parseMaterializedViewTarget(tt.query)
+		if database != tt.database || table != tt.table {
+			t.Errorf("parseMaterializedViewTarget(%q) = (%q, %q), want (%q, %q)",
+				tt.query, database, table, tt.database, tt.table)
+		}
+	}
+}
+
+// TestParseDistributedEngine tests extraction of the local table from Distributed engine parameters.
+func TestParseDistributedEngine(t *testing.T) {
+	tests := []struct {
+		engineFull string
+		database   string
+		table      string
+	}{
+		{
+			engineFull: "Distributed('cluster', 'db', 'events_local', rand())",
+			database:   "db",
+			table:      "events_local",
+		},
+		{
+			engineFull: "Distributed(cluster, currentDatabase(), events_local, cityHash64(user_id, 'x'))",
+			table:      "events_local",
+		},
+		{
+			engineFull: "MergeTree ORDER BY id",
+		},
+	}
+
+	for _, tt := range tests {
+		database, table := parseDistributedEngine(tt.engineFull)
+		if database != tt.database || table != tt.table {
+			t.Errorf("parseDistributedEngine(%q) = (%q, %q), want (%q, %q)",
+				tt.engineFull, database, table, tt.database, tt.table)
+		}
+	}
+}
+
 // createClickHouseTestSchema creates test tables with various column types and constraints for testing.
 func createClickHouseTestSchema(ctx context.Context, db *sql.DB) error {
 	queries := []string{
@@ -21,6 +21,14 @@ const (
 	Check      ConstraintKind = "CHECK"
 )
 
+// DataFlowKind represents how data moves from one table into another.
+type DataFlowKind string
+
+const (
+	MaterializedViewFlow DataFlowKind = "MATERIALIZED_VIEW"
+	DistributedFlow      DataFlowKind = "DISTRIBUTED"
+)
+
 // Constraint represents a database table constraint including primary keys, foreign keys,
 // unique constraints, and check constraints.
 type Constraint struct {
@@ -37,6 +45,42 @@ type Column struct {
 	Type         DataType
 	IsNullable   bool
 	DefaultValue string
+	// Comment is the description attached to the column, if the engine
+	// supports comments.
+	Comment string
+	// EnumValues lists the values of an enum column, in order, when the
+	// engine reports them.
+	EnumValues []string
+}
+
+// DataSource represents an upstream table whose data flows into a table,
+// for example the source of a materialized view or the local table behind
+// a Distributed table.
+type DataSource struct {
+	Kind  DataFlowKind
+	Table string
+}
+
+// TableStats holds size statistics for a table. Row counts are estimates
+// taken from engine metadata unless the engine only supports counting.
+type TableStats struct {
+	RowCount int64
+	// RowCountExact is true when rows were counted rather than estimated.
+	RowCountExact bool
+	// RowCountCapped is true when counting stopped at a limit, in which case
+	// RowCount is a lower bound.
+	RowCountCapped bool
+	// SizeBytes is the on-disk size including indexes, or 0 when unavailable.
+	SizeBytes int64
+}
+
+// Index represents a secondary index of a table. Indexes backing a primary
+// key are not included. Columns holds the expression text for entries that
+// are not plain columns.
+type Index struct {
+	Name    string
+	Columns []string
+	Unique  bool
 }
 
 // Table represents a database table with its columns and constraints.
@@ -44,6 +88,11 @@ type Table struct {
 	Name        string
 	Columns     []Column
 	Constraints []Constraint
+	Indexes     []Index
+	DataSources []DataSource
+	Comment     string
+	// Stats is only populated by InspectStats.
+	Stats *TableStats
 }
 
 // Database represents a database schema with all its tables.
@@ -57,6 +106,23 @@ type SchemaInspector interface {
 	InspectSchema(ctx context.Context, db *sql.DB) (*Database, error)
 }
 
+// StatsInspector defines the interface for gathering table size statistics.
+// Implementations fill in Table.Stats for the given tables.
+type StatsInspector interface {
+	InspectStats(ctx context.Context, db *sql.DB, tables []Table) error
+}
+
+// FingerprintInspector defines the interface for computing a schema
+// fingerprint, a short value that changes whenever tables, columns,
+// constraints or indexes change. It is meant to be cheap enough to poll.
+type FingerprintInspector interface {
+	SchemaFingerprint(ctx context.Context, db *sql.DB) (string, error)
+}
+
+// statsCountLimit bounds the number of rows counted for engines that have no
+// row estimates, so that gathering statistics stays cheap on large tables.
+const statsCountLimit = 1_000_000
+
 // detectDatabaseType determines the database type by querying the version string.
 // It supports PostgreSQL, MySQL, ClickHouse, and SQLite detection.
 func detectDatabaseType(ctx context.Context, db *sql.DB) (string, error) {
@@ -97,27 +163,196 @@ func detectDatabaseType(ctx context.Context, db *sql.DB) (string, error) {
 	return "", fmt.Errorf("could not determine database type")
 }
 
-// InspectSchema analyzes a database connection and returns a complete schema representation.
-// It automatically detects the database type and uses the appropriate inspector implementation.
-func InspectSchema(ctx context.Context, db *sql.DB) (*Database, error) {
+// ServerVersion returns the version string reported by the database server.
+func ServerVersion(ctx context.Context, db *sql.DB) (string, error) {
 	dbType, err := detectDatabaseType(ctx, db)
 	if err != nil {
-		return nil, err
+		return "", err
+	}
+
+	query := "SELECT version()"
+	switch dbType {
+	case "sqlite":
+		query = "SELECT sqlite_version()"
+	case "mysql":
+		query = "SELECT VERSION()"
+	}
+
+	var version string
+	if err := db.QueryRowContext(ctx, query).Scan(&version); err != nil {
+		return "", fmt.Errorf("failed to get server version: %w", err)
 	}
 
-	var inspector SchemaInspector
+	return version, nil
+}
+
+// newInspector returns the inspector implementation for a database type.
+func newInspector(dbType string) (SchemaInspector, error) {
 	switch dbType {
 	case "postgres":
-		inspector = &postgresInspector{}
+		return &postgresInspector{}, nil
 	case "mysql":
-		inspector = &mysqlInspector{}
+		return &mysqlInspector{}, nil
 	case "clickhouse":
-		inspector = &clickhouseInspector{}
+		return &clickhouseInspector{}, nil
 	case "sqlite":
-		inspector = &sqliteInspector{}
+		return &sqliteInspector{}, nil
 	default:
 		return nil, fmt.Errorf("unsupported database type: %s", dbType)
 	}
+}
+
+// InspectSchema analyzes a database connection and returns a complete schema representation.
+// It automatically detects the database type and uses the appropriate inspector implementation.
+func InspectSchema(ctx context.Context, db *sql.DB) (*Database, error) {
+	dbType, err := detectDatabaseType(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	inspector, err := newInspector(dbType)
+	if err != nil {
+		return nil, err
+	}
 
 	return inspector.InspectSchema(ctx, db)
 }
+
+// InspectStats gathers estimated row counts and on-disk sizes for the tables of
+// an already inspected schema. It is kept separate from InspectSchema because
+// some engines can only provide statistics by scanning tables.
+func InspectStats(ctx context.Context, db *sql.DB, schema *Database) error {
+	if schema == nil {
+		return fmt.Errorf("schema cannot be nil")
+	}
+
+	dbType, err := detectDatabaseType(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	inspector, err := newInspector(dbType)
+	if err != nil {
+		return err
+	}
+
+	statsInspector, ok := inspector.(StatsInspector)
+	if !ok {
+		return fmt.Errorf("statistics are not supported for database type: %s", dbType)
+	}
+
+	return statsInspector.InspectStats(ctx, db, schema.Tables)
+}
+
+// SchemaFingerprint returns a fingerprint of the schema of a database. Two
+// fingerprints differ when the schema changed between them, so that callers
+// can skip inspecting a schema that is unchanged.
+func SchemaFingerprint(ctx context.Context, db *sql.DB) (string, error) {
+	dbType, err := detectDatabaseType(ctx, db)
+	if err != nil {
+		return "", err
+	}
+
+	inspector, err := newInspector(dbType)
+	if err != nil {
+		return "", err
+	}
+
+	fingerprintInspector, ok := inspector.(FingerprintInspector)
+	if !ok {
+		return "", fmt.Errorf("schema fingerprints are not supported for database type: %s", dbType)
+	}
+
+	return fingerprintInspector.SchemaFingerprint(ctx, db)
+}
+
+// fingerprintQueries runs queries that each return a single value and joins
+// the values into a fingerprint.
+func fingerprintQueries(ctx context.Context, db *sql.DB, queries ...string) (string, error) {
+	values := make([]string, len(queries))
+	for i, query := range queries {
+		var value sql.NullString
+		if err := db.QueryRowContext(ctx, query).Scan(&value); err != nil {
+			return "", fmt.Errorf("failed to compute schema fingerprint: %w", err)
+		}
+		values[i] = value.String
+	}
+	return strings.Join(values, "/"), nil
+}
+
+// countRowsWithLimit counts the rows of a table, stopping at limit.
+// The table name must already be quoted for the target engine.
+func countRowsWithLimit(ctx context.Context, db *sql.DB, quotedTable string, limit int) (int64, bool, error) {
+	query := fmt.Sprintf("SELECT COUNT(*) FROM (SELECT 1 FROM %s LIMIT %d) AS limited", quotedTable, limit+1)
+
+	var count int64
+	if err := db.QueryRowContext(ctx, query).Scan(&count); err != nil {
+		return 0, false, err
+	}
+
+	if count > int64(limit) {
+		return int64(limit), true, nil
+	}
+	return count, false, nil
+}
+
+// quoteIdentifier quotes a table or column name for use in a query against
+// the given database type.
+func quoteIdentifier(dbType, name string) string {
+	switch dbType {
+	case "mysql", "clickhouse":
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	default:
+		return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+	}
+}
+
+// parseEnumValues returns the values of an enum type as MySQL and ClickHouse
+// write it, such as enum('a','b') or Nullable(Enum8('a' = 1, 'b' = 2)), and
+// nil for other types.
+func parseEnumValues(columnType string) []string {
+	for _, wrapper := range []string{"Nullable(", "LowCardinality("} {
+		if inner, ok := strings.CutPrefix(columnType, wrapper); ok && strings.HasSuffix(inner, ")") {
+			columnType = inner[:len(inner)-1]
+		}
+	}
+
+	lower := strings.ToLower(columnType)
+	for _, prefix := range []string{"enum(", "enum8(", "enum16("} {
+		if strings.HasPrefix(lower, prefix) && strings.HasSuffix(lower, ")") {
+			return parseQuotedList(columnType[len(prefix) : len(columnType)-1])
+		}
+	}
+	return nil
+}
+
+// parseQuotedList returns the single quoted strings of a list such as
+// 'a', 'b' or 'a' = 1, 'b' = 2, undoing doubled quotes and backslash escapes.
+// Text outside the quotes is ignored.
+func parseQuotedList(s string) []string {
+	var values []string
+	var value strings.Builder
+	inString := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case !inString:
+			if c == '\'' {
+				inString = true
+				value.Reset()
+			}
+		case c == '\\' && i+1 < len(s):
+			i++
+			value.WriteByte(s[i])
+		case c == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+			value.WriteByte('\'')
+		case c == '\'':
+			inString = false
+			values = append(values, value.String())
+		default:
+			value.WriteByte(c)
+		}
+	}
+	return values
+}
@@ -0,0 +1,25 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseEnumValues(t *testing.T) {
+	tests := []struct {
+		columnType string
+		expected   []string
+	}{
+		{"enum('small','it''s large')", []string{"small", "it's large"}},
+		{"Enum8('a' = 1, 'b\\'c' = 2)", []string{"a", "b'c"}},
+		{"Nullable(Enum16('x' = -1))", []string{"x"}},
+		{"LowCardinality(String)", nil},
+		{"varchar(20)", nil},
+	}
+
+	for _, tt := range tests {
+		if got := parseEnumValues(tt.columnType); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("parseEnumValues(%q) = %q; expected %q", tt.columnType, got, tt.expected)
+		}
+	}
+}
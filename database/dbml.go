@@ -0,0 +1,1014 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// ParseDBML parses a schema written in DBML, the language of dbdiagram.io,
+// into a Database. Tables, columns with their pk, unique, not null, default,
+// increment and note settings, indexes, checks and refs, inline or
+// standalone, are read, and the values of enums are attached to the columns
+// of their type. Incrementing columns default to the sequence PostgreSQL
+// would create for them.
+// Table groups and sticky notes are skipped. The database is named after the
+// Project, if any.
+func ParseDBML(src string) (*Database, error) {
+	tokens, err := lexDBML(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &dbmlParser{
+		tokens:  tokens,
+		tables:  make(map[string]int),
+		aliases: make(map[string]string),
+		enums:   make(map[string][]string),
+	}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+
+	return &p.db, nil
+}
+
+// ParseDBMLFile parses the DBML file at path. The database is named after the
+// file when it has no Project.
+func ParseDBMLFile(path string) (*Database, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DBML file: %w", err)
+	}
+
+	db, err := ParseDBML(string(src))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	if db.Name == "" {
+		db.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return db, nil
+}
+
+type dbmlTokenKind int
+
+const (
+	dbmlEOF    dbmlTokenKind = iota
+	dbmlIdent                // bare word
+	dbmlQuoted               // "double quoted" name
+	dbmlString               // 'single' or '''triple''' quoted string
+	dbmlExpr                 // `backtick` expression
+	dbmlNumber
+	dbmlPunct
+)
+
+type dbmlToken struct {
+	kind dbmlTokenKind
+	text string
+	line int
+}
+
+// lexDBML splits DBML source into tokens, dropping comments.
+func lexDBML(src string) ([]dbmlToken, error) {
+	var tokens []dbmlToken
+	runes := []rune(src)
+	line := 1
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case r == '\n':
+			line++
+			i++
+
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			start := line
+			i += 2
+			for i < len(runes) && !(runes[i] == '*' && i+1 < len(runes) && runes[i+1] == '/') {
+				if runes[i] == '\n' {
+					line++
+				}
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("line %d: unterminated comment", start)
+			}
+			i += 2
+
+		case r == '\'' || r == '"' || r == '`':
+			start := line
+			quote := string(r)
+			kind := dbmlString
+			if r == '"' {
+				kind = dbmlQuoted
+			} else if r == '`' {
+				kind = dbmlExpr
+			}
+			if r == '\'' && hasRunePrefix(runes[i:], "'''") {
+				quote = "'''"
+			}
+			i += len(quote)
+
+			var sb strings.Builder
+			for {
+				if i >= len(runes) {
+					return nil, fmt.Errorf("line %d: unterminated string", start)
+				}
+				if hasRunePrefix(runes[i:], quote) {
+					i += len(quote)
+					break
+				}
+				if runes[i] == '\\' && r != '`' && i+1 < len(runes) {
+					i++
+				}
+				if runes[i] == '\n' {
+					line++
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+
+			text := sb.String()
+			if quote == "'''" {
+				text = dedent(text)
+			}
+			tokens = append(tokens, dbmlToken{kind: kind, text: text, line: start})
+
+		case r >= '0' && r <= '9':
+			start := i
+			for i < len(runes) && (runes[i] >= '0' && runes[i] <= '9' || runes[i] == '.' && i+1 < len(runes) && runes[i+1] >= '0' && runes[i+1] <= '9') {
+				i++
+			}
+			tokens = append(tokens, dbmlToken{kind: dbmlNumber, text: string(runes[start:i]), line: line})
+
+		case r == '_' || r == '#' || unicode.IsLetter(r):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || runes[i] == '#' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, dbmlToken{kind: dbmlIdent, text: string(runes[start:i]), line: line})
+
+		case r == '<' && i+1 < len(runes) && runes[i+1] == '>':
+			tokens = append(tokens, dbmlToken{kind: dbmlPunct, text: "<>", line: line})
+			i += 2
+
+		case strings.ContainsRune("{}[]():,.<>-", r):
+			tokens = append(tokens, dbmlToken{kind: dbmlPunct, text: string(r), line: line})
+			i++
+
+		default:
+			return nil, fmt.Errorf("line %d: unexpected character %q", line, r)
+		}
+	}
+
+	return append(tokens, dbmlToken{kind: dbmlEOF, line: line}), nil
+}
+
+// hasRunePrefix reports whether runes starts with prefix.
+func hasRunePrefix(runes []rune, prefix string) bool {
+	for i, r := range []rune(prefix) {
+		if i >= len(runes) || runes[i] != r {
+			return false
+		}
+	}
+	return true
+}
+
+// dedent removes the blank first and last lines of a multi-line string and
+// the indentation its lines have in common.
+func dedent(s string) string {
+	lines := strings.Split(strings.Trim(s, "\n"), "\n")
+	indent := -1
+	for _, l := range lines {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		n := len(l) - len(strings.TrimLeft(l, " \t"))
+		if indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	for i, l := range lines {
+		if len(l) >= indent && indent > 0 {
+			lines[i] = l[indent:]
+		}
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), " \t\n")
+}
+
+// dbmlEndpoint is one side of a ref: a table, possibly aliased or
+// schema-qualified, and its columns.
+type dbmlEndpoint struct {
+	table   []string
+	columns []string
+}
+
+// dbmlRef is a foreign key from one endpoint to another, resolved once every
+// table has been read.
+type dbmlRef struct {
+	from, to dbmlEndpoint
+	line     int
+}
+
+// dbmlSetting is one entry of a [...] settings list, such as "not null" or
+// "default: 0".
+type dbmlSetting struct {
+	key   string
+	value []dbmlToken
+	line  int
+}
+
+type dbmlParser struct {
+	tokens  []dbmlToken
+	pos     int
+	db      Database
+	tables  map[string]int // table name to index in db.Tables
+	aliases map[string]string
+	enums   map[string][]string // enum name to values
+	refs    []dbmlRef
+}
+
+func (p *dbmlParser) peek() dbmlToken {
+	return p.tokens[p.pos]
+}
+
+func (p *dbmlParser) next() dbmlToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != dbmlEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// is reports whether the next token is the given punctuation.
+func (p *dbmlParser) is(punct string) bool {
+	tok := p.peek()
+	return tok.kind == dbmlPunct && tok.text == punct
+}
+
+func (p *dbmlParser) expect(punct string) error {
+	if !p.is(punct) {
+		return p.unexpected(fmt.Sprintf("%q", punct))
+	}
+	p.next()
+	return nil
+}
+
+func (p *dbmlParser) unexpected(expected string) error {
+	tok := p.peek()
+	if tok.kind == dbmlEOF {
+		return fmt.Errorf("line %d: expected %s, found end of file", tok.line, expected)
+	}
+	return fmt.Errorf("line %d: expected %s, found %q", tok.line, expected, tok.text)
+}
+
+// name reads a bare or double quoted name.
+func (p *dbmlParser) name() (string, error) {
+	tok := p.peek()
+	if tok.kind != dbmlIdent && tok.kind != dbmlQuoted {
+		return "", p.unexpected("a name")
+	}
+	p.next()
+	return tok.text, nil
+}
+
+// qualifiedName reads a name with optional schema, such as public.users.
+func (p *dbmlParser) qualifiedName() ([]string, error) {
+	var parts []string
+	for {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, name)
+		if !p.is(".") {
+			return parts, nil
+		}
+		p.next()
+	}
+}
+
+// skipBlock skips a {...} block, including nested blocks.
+func (p *dbmlParser) skipBlock() error {
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	for depth := 1; depth > 0; {
+		tok := p.next()
+		switch {
+		case tok.kind == dbmlEOF:
+			return fmt.Errorf("line %d: unterminated block", tok.line)
+		case tok.kind == dbmlPunct && tok.text == "{":
+			depth++
+		case tok.kind == dbmlPunct && tok.text == "}":
+			depth--
+		}
+	}
+	return nil
+}
+
+func (p *dbmlParser) parse() error {
+	for p.peek().kind != dbmlEOF {
+		tok := p.next()
+		if tok.kind != dbmlIdent {
+			p.pos--
+			return p.unexpected("Project, Table, Ref, Enum, TableGroup or Note")
+		}
+
+		var err error
+		switch strings.ToLower(tok.text) {
+		case "project":
+			if !p.is("{") {
+				if p.db.Name, err = p.name(); err != nil {
+					return err
+				}
+			}
+			err = p.skipBlock()
+		case "table":
+			err = p.parseTable()
+		case "ref":
+			err = p.parseRef()
+		case "enum":
+			err = p.parseEnum()
+		case "tablegroup", "note", "tablepartial", "records":
+			// Not part of the schema graph; skip the name and settings
+			for !p.is("{") && p.peek().kind != dbmlEOF {
+				p.next()
+			}
+			err = p.skipBlock()
+		default:
+			p.pos--
+			err = p.unexpected("Project, Table, Ref, Enum, TableGroup or Note")
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	p.resolveEnums()
+	return p.resolveRefs()
+}
+
+// tableName returns the name dbtree uses for a table, leaving out the
+// default public schema.
+func (p *dbmlParser) tableName(parts []string) string {
+	name := strings.Join(parts, ".")
+	if table, ok := p.aliases[name]; ok {
+		return table
+	}
+	if len(parts) == 2 && parts[0] == "public" {
+		return parts[1]
+	}
+	return name
+}
+
+func (p *dbmlParser) parseTable() error {
+	line := p.peek().line
+	parts, err := p.qualifiedName()
+	if err != nil {
+		return err
+	}
+
+	table := Table{Name: p.tableName(parts)}
+	if _, exists := p.tables[table.Name]; exists {
+		return fmt.Errorf("line %d: table %s is defined twice", line, table.Name)
+	}
+
+	if tok := p.peek(); tok.kind == dbmlIdent && strings.EqualFold(tok.text, "as") {
+		p.next()
+		alias, err := p.name()
+		if err != nil {
+			return err
+		}
+		p.aliases[alias] = table.Name
+	}
+
+	if p.is("[") {
+		settings, err := p.settings()
+		if err != nil {
+			return err
+		}
+		for _, s := range settings {
+			if s.key == "note" {
+				table.Comment = settingText(s)
+			}
+		}
+	}
+
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+
+	var primaryKey []string
+	var constraints []Constraint
+	for !p.is("}") {
+		tok := p.peek()
+		if tok.kind == dbmlEOF {
+			return fmt.Errorf("line %d: unterminated table %s", line, table.Name)
+		}
+
+		// Notes, indexes and checks are keywords only when followed by a
+		// colon or block; otherwise they are column names
+		keyword := ""
+		if tok.kind == dbmlIdent {
+			following := p.tokens[p.pos+1]
+			if following.kind == dbmlPunct && (following.text == ":" || following.text == "{") {
+				keyword = strings.ToLower(tok.text)
+			}
+		}
+
+		switch keyword {
+		case "note":
+			p.next()
+			if table.Comment, err = p.note(); err != nil {
+				return err
+			}
+		case "indexes":
+			p.next()
+			indexes, keys, err := p.indexes()
+			if err != nil {
+				return err
+			}
+			table.Indexes = append(table.Indexes, indexes...)
+			for _, key := range keys {
+				primaryKey = append(primaryKey, key...)
+			}
+		case "checks":
+			p.next()
+			checks, err := p.checks()
+			if err != nil {
+				return err
+			}
+			constraints = append(constraints, checks...)
+		default:
+			column, pk, columnConstraints, err := p.column(table.Name)
+			if err != nil {
+				return err
+			}
+			table.Columns = append(table.Columns, column)
+			if pk {
+				primaryKey = append(primaryKey, column.Name)
+			}
+			constraints = append(constraints, columnConstraints...)
+		}
+	}
+	p.next()
+
+	if len(primaryKey) > 0 {
+		constraints = append([]Constraint{{Kind: PrimaryKey, Columns: primaryKey}}, constraints...)
+		for i := range table.Columns {
+			if slices.Contains(primaryKey, table.Columns[i].Name) {
+				table.Columns[i].IsNullable = false
+			}
+		}
+	}
+	table.Constraints = constraints
+
+	p.tables[table.Name] = len(p.db.Tables)
+	p.db.Tables = append(p.db.Tables, table)
+	return nil
+}
+
+// parseEnum reads an Enum block, a value per line with optional settings.
+func (p *dbmlParser) parseEnum() error {
+	line := p.peek().line
+	parts, err := p.qualifiedName()
+	if err != nil {
+		return err
+	}
+	name := strings.Join(parts, ".")
+	if len(parts) == 2 && parts[0] == "public" {
+		name = parts[1]
+	}
+
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	var values []string
+	for !p.is("}") {
+		if p.peek().kind == dbmlEOF {
+			return fmt.Errorf("line %d: unterminated enum %s", line, name)
+		}
+		value, err := p.name()
+		if err != nil {
+			return err
+		}
+		values = append(values, value)
+		if p.is("[") {
+			if _, err := p.settings(); err != nil {
+				return err
+			}
+		}
+	}
+	p.next()
+
+	p.enums[name] = values
+	return nil
+}
+
+// resolveEnums sets the enum values of the columns whose type is an enum,
+// once all enums are known.
+func (p *dbmlParser) resolveEnums() {
+	for i := range p.db.Tables {
+		columns := p.db.Tables[i].Columns
+		for j := range columns {
+			if values, ok := p.enums[strings.TrimPrefix(string(columns[j].Type), "public.")]; ok {
+				columns[j].EnumValues = values
+			}
+		}
+	}
+}
+
+// note reads the text of a Note, either "Note: '...'" or "Note { '...' }".
+func (p *dbmlParser) note() (string, error) {
+	block := p.is("{")
+	if !block && !p.is(":") {
+		return "", p.unexpected(`":" or "{"`)
+	}
+	p.next()
+	if p.peek().kind != dbmlString {
+		return "", p.unexpected("a string")
+	}
+	text := p.next().text
+	if block {
+		if err := p.expect("}"); err != nil {
+			return "", err
+		}
+	}
+	return text, nil
+}
+
+// column reads a column definition, "name type [settings]", which ends with
+// its line. It returns whether the column is part of the primary key and the
+// constraints its settings declare.
+func (p *dbmlParser) column(table string) (Column, bool, []Constraint, error) {
+	line := p.peek().line
+	name, err := p.name()
+	if err != nil {
+		return Column{}, false, nil, err
+	}
+
+	dataType, err := p.columnType(line)
+	if err != nil {
+		return Column{}, false, nil, err
+	}
+
+	column := Column{Name: name, Type: DataType(dataType), IsNullable: true}
+	var pk, increment bool
+	var constraints []Constraint
+
+	if p.is("[") && p.peek().line == line {
+		settings, err := p.settings()
+		if err != nil {
+			return Column{}, false, nil, err
+		}
+		for _, s := range settings {
+			switch s.key {
+			case "pk", "primary key":
+				pk = true
+			case "not null":
+				column.IsNullable = false
+			case "null":
+				column.IsNullable = true
+			case "unique":
+				constraints = append(constraints, Constraint{Kind: Unique, Columns: []string{name}})
+			case "default":
+				column.DefaultValue = settingValue(s)
+			case "increment":
+				increment = true
+			case "note":
+				column.Comment = settingText(s)
+			case "check":
+				constraints = append(constraints, Constraint{Kind: Check, Columns: []string{name}, CheckExpression: settingText(s)})
+			case "ref":
+				sub := &dbmlParser{tokens: append(s.value, dbmlToken{kind: dbmlEOF, line: s.line})}
+				op := sub.next()
+				to, err := sub.endpoint()
+				if err != nil {
+					return Column{}, false, nil, err
+				}
+				if sub.peek().kind != dbmlEOF {
+					return Column{}, false, nil, sub.unexpected("the end of the ref")
+				}
+				self := dbmlEndpoint{table: []string{table}, columns: []string{name}}
+				if err := p.addRef(self, op, to); err != nil {
+					return Column{}, false, nil, err
+				}
+			}
+		}
+	}
+	// Serial types increment by themselves
+	if increment && column.DefaultValue == "" && !IsSerialType(column.Type) {
+		column.DefaultValue = SequenceDefault(table, name)
+	}
+
+	return column, pk, constraints, nil
+}
+
+// IsSerialType reports whether t is one of PostgreSQL's serial types, which
+// draw their values from a sequence.
+func IsSerialType(t DataType) bool {
+	switch strings.ToLower(string(t)) {
+	case "serial", "serial2", "serial4", "serial8", "smallserial", "bigserial":
+		return true
+	}
+	return false
+}
+
+// SequenceDefault returns the default PostgreSQL gives a serial column: the
+// next value of the sequence named after its table and column, in the schema
+// of the table.
+func SequenceDefault(table, column string) string {
+	sequence := ""
+	if schema, name, ok := strings.Cut(table, "."); ok {
+		sequence, table = postgresIdentifier(schema)+".", name
+	}
+	sequence += postgresIdentifier(table + "_" + column + "_seq")
+	return "nextval('" + strings.ReplaceAll(sequence, "'", "''") + "'::regclass)"
+}
+
+// postgresIdentifier quotes a name unless PostgreSQL reads it as written.
+func postgresIdentifier(s string) string {
+	for i, r := range s {
+		if r != '_' && (r < 'a' || r > 'z') && (i == 0 || r < '0' || r > '9') {
+			return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+		}
+	}
+	return s
+}
+
+// columnType reads the type of a column up to its settings or the end of its
+// line, such as "decimal(10,2)", "int[]" or "double precision".
+func (p *dbmlParser) columnType(line int) (string, error) {
+	var sb strings.Builder
+	depth := 0
+	previous := dbmlPunct
+
+loop:
+	for {
+		tok := p.peek()
+		if tok.kind == dbmlEOF || tok.line != line && depth == 0 {
+			break
+		}
+		if tok.kind == dbmlPunct {
+			switch tok.text {
+			case "(":
+				depth++
+			case ")":
+				depth--
+			case "[":
+				// [] marks an array type, anything else starts the settings
+				if following := p.tokens[p.pos+1]; following.kind != dbmlPunct || following.text != "]" {
+					break loop
+				}
+			case "}":
+				if depth == 0 {
+					break loop
+				}
+			}
+		}
+
+		text := tok.text
+		if tok.kind == dbmlString {
+			text = "'" + tok.text + "'"
+		}
+		if sb.Len() > 0 && tok.kind != dbmlPunct && previous != dbmlPunct {
+			sb.WriteString(" ")
+		}
+		sb.WriteString(text)
+		previous = tok.kind
+		p.next()
+	}
+
+	if sb.Len() == 0 {
+		return "", p.unexpected("a column type")
+	}
+	return sb.String(), nil
+}
+
+// settings reads a [...] settings list.
+func (p *dbmlParser) settings() ([]dbmlSetting, error) {
+	if err := p.expect("["); err != nil {
+		return nil, err
+	}
+
+	var settings []dbmlSetting
+	for !p.is("]") {
+		setting := dbmlSetting{line: p.peek().line}
+
+		var key []string
+		for p.peek().kind == dbmlIdent {
+			key = append(key, strings.ToLower(p.next().text))
+		}
+		if len(key) == 0 {
+			return nil, p.unexpected("a setting")
+		}
+		setting.key = strings.Join(key, " ")
+
+		if p.is(":") {
+			p.next()
+			depth := 0
+			for depth > 0 || !p.is(",") && !p.is("]") {
+				tok := p.next()
+				if tok.kind == dbmlEOF {
+					return nil, fmt.Errorf("line %d: unterminated settings", setting.line)
+				}
+				if tok.kind == dbmlPunct && tok.text == "(" {
+					depth++
+				} else if tok.kind == dbmlPunct && tok.text == ")" {
+					depth--
+				}
+				setting.value = append(setting.value, tok)
+			}
+		}
+		settings = append(settings, setting)
+
+		if p.is(",") {
+			p.next()
+		} else if !p.is("]") {
+			return nil, p.unexpected(`"," or "]"`)
+		}
+	}
+	p.next()
+
+	return settings, nil
+}
+
+// settingText returns the value of a setting that takes a string or
+// expression, such as a note or a check.
+func settingText(s dbmlSetting) string {
+	var parts []string
+	for _, tok := range s.value {
+		parts = append(parts, tok.text)
+	}
+	return strings.Join(parts, " ")
+}
+
+// settingValue returns the value of a default setting as SQL: strings are
+// quoted, while numbers, booleans, null and expressions are kept as written.
+func settingValue(s dbmlSetting) string {
+	if len(s.value) == 1 && s.value[0].kind == dbmlString {
+		return "'" + strings.ReplaceAll(s.value[0].text, "'", "''") + "'"
+	}
+	var sb strings.Builder
+	for _, tok := range s.value {
+		sb.WriteString(tok.text)
+	}
+	return sb.String()
+}
+
+// indexes reads an indexes block. Entries marked pk are returned as primary
+// key columns instead of indexes.
+func (p *dbmlParser) indexes() ([]Index, [][]string, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, nil, err
+	}
+
+	var indexes []Index
+	var primaryKeys [][]string
+	for !p.is("}") {
+		line := p.peek().line
+		var columns []string
+
+		if p.is("(") {
+			p.next()
+			for {
+				column, err := p.indexColumn()
+				if err != nil {
+					return nil, nil, err
+				}
+				columns = append(columns, column)
+				if !p.is(",") {
+					break
+				}
+				p.next()
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, nil, err
+			}
+		} else {
+			column, err := p.indexColumn()
+			if err != nil {
+				return nil, nil, err
+			}
+			columns = append(columns, column)
+		}
+
+		index := Index{Columns: columns}
+		pk := false
+		if p.is("[") && p.peek().line == line {
+			settings, err := p.settings()
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, s := range settings {
+				switch s.key {
+				case "pk":
+					pk = true
+				case "unique":
+					index.Unique = true
+				case "name":
+					index.Name = settingText(s)
+				}
+			}
+		}
+
+		if pk {
+			primaryKeys = append(primaryKeys, columns)
+		} else {
+			indexes = append(indexes, index)
+		}
+	}
+	p.next()
+
+	return indexes, primaryKeys, nil
+}
+
+// indexColumn reads a column name or `expression` of an index.
+func (p *dbmlParser) indexColumn() (string, error) {
+	if tok := p.peek(); tok.kind == dbmlExpr {
+		p.next()
+		return tok.text, nil
+	}
+	return p.name()
+}
+
+// checks reads a checks block of `expression` [name: '...'] entries.
+func (p *dbmlParser) checks() ([]Constraint, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	var checks []Constraint
+	for !p.is("}") {
+		tok := p.peek()
+		if tok.kind != dbmlExpr {
+			return nil, p.unexpected("a check expression")
+		}
+		p.next()
+		if p.is("[") && p.peek().line == tok.line {
+			if _, err := p.settings(); err != nil {
+				return nil, err
+			}
+		}
+		checks = append(checks, Constraint{Kind: Check, CheckExpression: tok.text})
+	}
+	p.next()
+
+	return checks, nil
+}
+
+// parseRef reads a standalone ref, "Ref name: a.b > c.d" or
+// "Ref name { a.b > c.d }".
+func (p *dbmlParser) parseRef() error {
+	if !p.is(":") && !p.is("{") {
+		if _, err := p.name(); err != nil {
+			return err
+		}
+	}
+
+	if p.is(":") {
+		p.next()
+		return p.refBody()
+	}
+
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	for !p.is("}") {
+		if p.peek().kind == dbmlEOF {
+			return p.unexpected(`"}"`)
+		}
+		if err := p.refBody(); err != nil {
+			return err
+		}
+	}
+	p.next()
+	return nil
+}
+
+// refBody reads "a.b > c.d [settings]". The settings, such as delete and
+// update actions, are not kept.
+func (p *dbmlParser) refBody() error {
+	from, err := p.endpoint()
+	if err != nil {
+		return err
+	}
+	op := p.next()
+	to, err := p.endpoint()
+	if err != nil {
+		return err
+	}
+	if p.is("[") {
+		if _, err := p.settings(); err != nil {
+			return err
+		}
+	}
+	return p.addRef(from, op, to)
+}
+
+// endpoint reads a ref endpoint, table.column or table.(column, column),
+// with an optional schema before the table.
+func (p *dbmlParser) endpoint() (dbmlEndpoint, error) {
+	var endpoint dbmlEndpoint
+	for {
+		if p.is("(") {
+			p.next()
+			for {
+				column, err := p.name()
+				if err != nil {
+					return endpoint, err
+				}
+				endpoint.columns = append(endpoint.columns, column)
+				if !p.is(",") {
+					break
+				}
+				p.next()
+			}
+			if err := p.expect(")"); err != nil {
+				return endpoint, err
+			}
+			break
+		}
+
+		name, err := p.name()
+		if err != nil {
+			return endpoint, err
+		}
+		endpoint.table = append(endpoint.table, name)
+		if !p.is(".") {
+			break
+		}
+		p.next()
+	}
+
+	if len(endpoint.columns) == 0 {
+		if len(endpoint.table) < 2 {
+			return endpoint, p.unexpected(`"." and a column`)
+		}
+		endpoint.columns = endpoint.table[len(endpoint.table)-1:]
+		endpoint.table = endpoint.table[:len(endpoint.table)-1]
+	}
+	if len(endpoint.table) == 0 {
+		return endpoint, p.unexpected("a table")
+	}
+	return endpoint, nil
+}
+
+// addRef records a ref with the foreign key on the many side. One-to-one refs
+// put it on the left, and many-to-many refs have no foreign key.
+func (p *dbmlParser) addRef(left dbmlEndpoint, op dbmlToken, right dbmlEndpoint) error {
+	if op.kind != dbmlPunct {
+		return fmt.Errorf("line %d: expected a relationship (<, >, - or <>), found %q", op.line, op.text)
+	}
+	switch op.text {
+	case ">", "-":
+		p.refs = append(p.refs, dbmlRef{from: left, to: right, line: op.line})
+	case "<":
+		p.refs = append(p.refs, dbmlRef{from: right, to: left, line: op.line})
+	case "<>":
+	default:
+		return fmt.Errorf("line %d: expected a relationship (<, >, - or <>), found %q", op.line, op.text)
+	}
+	return nil
+}
+
+// resolveRefs adds the foreign keys of the refs to their tables, once all
+// tables and aliases are known.
+func (p *dbmlParser) resolveRefs() error {
+	for _, ref := range p.refs {
+		from, to := p.tableName(ref.from.table), p.tableName(ref.to.table)
+		index, ok := p.tables[from]
+		if !ok {
+			return fmt.Errorf("line %d: ref from unknown table %s", ref.line, from)
+		}
+		if _, ok := p.tables[to]; !ok {
+			return fmt.Errorf("line %d: ref to unknown table %s", ref.line, to)
+		}
+		if len(ref.from.columns) != len(ref.to.columns) {
+			return fmt.Errorf("line %d: ref from %d columns to %d columns", ref.line, len(ref.from.columns), len(ref.to.columns))
+		}
+
+		table := &p.db.Tables[index]
+		table.Constraints = append(table.Constraints, Constraint{
+			Kind:             ForeignKey,
+			Columns:          ref.from.columns,
+			ReferenceTable:   to,
+			ReferenceColumns: ref.to.columns,
+		})
+	}
+	return nil
+}
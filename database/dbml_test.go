@@ -0,0 +1,186 @@
+package database
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDBML(t *testing.T) {
+	src := `
+// Shop schema
+Project shop {
+  database_type: 'PostgreSQL'
+  Note: 'Designed in dbdiagram.io'
+}
+
+Table public.users as U [headercolor: #3498DB] {
+  id integer [pk, increment]
+  email "character varying(255)" [not null, unique, note: 'Login address']
+  status varchar(20) [default: 'it\'s new', note: 'it\\ is \\ complicated']
+  created_at timestamp [not null, default: ` + "`now()`" + `]
+  score decimal(10,2) [default: -1.5]
+  Note: '''
+    Registered customers
+    of the shop
+  '''
+}
+
+Table orders {
+  id integer
+  user_id integer [ref: > U.id]
+  shop_id int
+  region_id int
+  note text
+
+  indexes {
+    (id, shop_id) [pk]
+    (user_id, ` + "`lower(note)`" + `) [unique, name: 'orders_user_idx']
+    region_id
+  }
+
+  checks {
+    ` + "`id > 0`" + `
+  }
+}
+
+Table regions {
+  shop_id int
+  id int
+  indexes {
+    (shop_id, id) [pk]
+  }
+}
+
+/* Composite and reversed refs */
+Ref orders_region: orders.(shop_id, region_id) > regions.(shop_id, id) [delete: cascade]
+Ref {
+  users.id < payments.user_id
+}
+Ref: users.id <> regions.id
+
+Table payments {
+  user_id integer
+  state public.status [not null]
+}
+
+Enum status {
+  new
+  done [note: 'finished']
+}
+
+TableGroup sales {
+  orders
+  payments
+}
+`
+
+	db, err := ParseDBML(src)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := &Database{
+		Name: "shop",
+		Tables: []Table{
+			{
+				Name:    "users",
+				Comment: "Registered customers\nof the shop",
+				Columns: []Column{
+					{Name: "id", Type: "integer", DefaultValue: "nextval('users_id_seq'::regclass)"},
+					{Name: "email", Type: "character varying(255)", Comment: "Login address"},
+					{Name: "status", Type: "varchar(20)", IsNullable: true, DefaultValue: "'it''s new'", Comment: `it\ is \ complicated`},
+					{Name: "created_at", Type: "timestamp", DefaultValue: "now()"},
+					{Name: "score", Type: "decimal(10,2)", IsNullable: true, DefaultValue: "-1.5"},
+				},
+				Constraints: []Constraint{
+					{Kind: PrimaryKey, Columns: []string{"id"}},
+					{Kind: Unique, Columns: []string{"email"}},
+				},
+			},
+			{
+				Name: "orders",
+				Columns: []Column{
+					{Name: "id", Type: "integer"},
+					{Name: "user_id", Type: "integer", IsNullable: true},
+					{Name: "shop_id", Type: "int"},
+					{Name: "region_id", Type: "int", IsNullable: true},
+					{Name: "note", Type: "text", IsNullable: true},
+				},
+				Constraints: []Constraint{
+					{Kind: PrimaryKey, Columns: []string{"id", "shop_id"}},
+					{Kind: Check, CheckExpression: "id > 0"},
+					{Kind: ForeignKey, Columns: []string{"user_id"}, ReferenceTable: "users", ReferenceColumns: []string{"id"}},
+					{Kind: ForeignKey, Columns: []string{"shop_id", "region_id"}, ReferenceTable: "regions", ReferenceColumns: []string{"shop_id", "id"}},
+				},
+				Indexes: []Index{
+					{Name: "orders_user_idx", Columns: []string{"user_id", "lower(note)"}, Unique: true},
+					{Columns: []string{"region_id"}},
+				},
+			},
+			{
+				Name:        "regions",
+				Columns:     []Column{{Name: "shop_id", Type: "int"}, {Name: "id", Type: "int"}},
+				Constraints: []Constraint{{Kind: PrimaryKey, Columns: []string{"shop_id", "id"}}},
+			},
+			{
+				Name: "payments",
+				Columns: []Column{
+					{Name: "user_id", Type: "integer", IsNullable: true},
+					{Name: "state", Type: "public.status", EnumValues: []string{"new", "done"}},
+				},
+				Constraints: []Constraint{
+					{Kind: ForeignKey, Columns: []string{"user_id"}, ReferenceTable: "users", ReferenceColumns: []string{"id"}},
+				},
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(db, expected) {
+		t.Errorf("expected %+v, got %+v", expected, db)
+	}
+}
+
+func TestSequenceDefault(t *testing.T) {
+	tests := []struct {
+		table, column string
+		expected      string
+	}{
+		{"users", "id", "nextval('users_id_seq'::regclass)"},
+		{"sales.orders", "id", "nextval('sales.orders_id_seq'::regclass)"},
+		{"Order Items", "line", `nextval('"Order Items_line_seq"'::regclass)`},
+		{"it's", "id", `nextval('"it''s_id_seq"'::regclass)`},
+	}
+
+	for _, tt := range tests {
+		if got := SequenceDefault(tt.table, tt.column); got != tt.expected {
+			t.Errorf("SequenceDefault(%q, %q) = %q, expected %q", tt.table, tt.column, got, tt.expected)
+		}
+	}
+}
+
+func TestParseDBMLErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		expected string
+	}{
+		{"unknown element", "Tabel users {}", "line 1: expected Project, Table, Ref"},
+		{"missing type", "Table users {\n  id\n}", "line 3: expected a column type"},
+		{"unterminated table", "Table users {\n  id int\n", "line 1: unterminated table users"},
+		{"unterminated string", "Table users {\n  id int [note: 'oops]\n}", "line 2: unterminated string"},
+		{"unknown table", "Table users {\n  id int\n}\nRef: users.id > accounts.id", "line 4: ref to unknown table accounts"},
+		{"column count", "Table a {\n  x int\n}\nRef: a.(x, x) > a.x", "line 4: ref from 2 columns to 1 columns"},
+		{"unterminated enum", "Enum status {\n  new\n", "line 1: unterminated enum status"},
+		{"duplicate table", "Table a {\n  x int\n}\nTable a {\n  x int\n}", "line 4: table a is defined twice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDBML(tt.src)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package database
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// SchemaChanges lists the differences between two inspections of a schema.
+type SchemaChanges struct {
+	AddedTables   []string
+	RemovedTables []string
+	// ChangedTables lists the tables present in both schemas whose columns,
+	// constraints, indexes or data sources differ.
+	ChangedTables []string
+	// Columns maps a table to its added or modified columns. Columns of
+	// added tables are included.
+	Columns map[string][]string
+	// RemovedColumns maps a changed table to the columns it no longer has.
+	RemovedColumns map[string][]string
+}
+
+// DiffSchemas compares two inspections of a schema. A nil previous schema has
+// no changes, since there is nothing to compare against.
+func DiffSchemas(previous, current *Database) SchemaChanges {
+	changes := SchemaChanges{
+		Columns:        make(map[string][]string),
+		RemovedColumns: make(map[string][]string),
+	}
+	if previous == nil || current == nil {
+		return changes
+	}
+
+	oldTables := make(map[string]*Table, len(previous.Tables))
+	for i := range previous.Tables {
+		oldTables[previous.Tables[i].Name] = &previous.Tables[i]
+	}
+	newTables := make(map[string]bool, len(current.Tables))
+
+	for i := range current.Tables {
+		table := &current.Tables[i]
+		newTables[table.Name] = true
+
+		old, exists := oldTables[table.Name]
+		if !exists {
+			changes.AddedTables = append(changes.AddedTables, table.Name)
+			for _, col := range table.Columns {
+				changes.Columns[table.Name] = append(changes.Columns[table.Name], col.Name)
+			}
+			continue
+		}
+
+		modified, removed := diffColumns(old, table)
+		if len(modified) > 0 {
+			changes.Columns[table.Name] = modified
+		}
+		if len(removed) > 0 {
+			changes.RemovedColumns[table.Name] = removed
+		}
+
+		if len(modified) > 0 || len(removed) > 0 ||
+			!reflect.DeepEqual(old.Constraints, table.Constraints) ||
+			!reflect.DeepEqual(old.Indexes, table.Indexes) ||
+			!reflect.DeepEqual(old.DataSources, table.DataSources) {
+			changes.ChangedTables = append(changes.ChangedTables, table.Name)
+		}
+	}
+
+	for name := range oldTables {
+		if !newTables[name] {
+			changes.RemovedTables = append(changes.RemovedTables, name)
+		}
+	}
+
+	sort.Strings(changes.AddedTables)
+	sort.Strings(changes.RemovedTables)
+	sort.Strings(changes.ChangedTables)
+	return changes
+}
+
+// diffColumns returns the columns of current that are new or differ from
+// those of previous, including in the constraints they take part in, and the
+// columns of previous that current no longer has.
+func diffColumns(previous, current *Table) (modified, removed []string) {
+	oldColumns := make(map[string]Column, len(previous.Columns))
+	for _, col := range previous.Columns {
+		oldColumns[col.Name] = col
+	}
+	newColumns := make(map[string]bool, len(current.Columns))
+
+	for _, col := range current.Columns {
+		newColumns[col.Name] = true
+
+		old, exists := oldColumns[col.Name]
+		if !exists || !reflect.DeepEqual(old, col) ||
+			!reflect.DeepEqual(columnConstraints(previous, col.Name), columnConstraints(current, col.Name)) {
+			modified = append(modified, col.Name)
+		}
+	}
+
+	for _, col := range previous.Columns {
+		if !newColumns[col.Name] {
+			removed = append(removed, col.Name)
+		}
+	}
+
+	return modified, removed
+}
+
+// columnConstraints returns the constraints of a table that include a column.
+func columnConstraints(table *Table, column string) []Constraint {
+	var constraints []Constraint
+	for _, constraint := range table.Constraints {
+		for _, name := range constraint.Columns {
+			if name == column {
+				constraints = append(constraints, constraint)
+				break
+			}
+		}
+	}
+	return constraints
+}
+
+// IsEmpty reports whether the schema is unchanged.
+func (c SchemaChanges) IsEmpty() bool {
+	return len(c.AddedTables) == 0 && len(c.RemovedTables) == 0 && len(c.ChangedTables) == 0
+}
+
+// Changed returns the added and changed tables, and their added or modified
+// columns as "table.column", as a set.
+func (c SchemaChanges) Changed() map[string]bool {
+	changed := make(map[string]bool)
+	for _, table := range c.AddedTables {
+		changed[table] = true
+	}
+	for _, table := range c.ChangedTables {
+		changed[table] = true
+	}
+	for table, columns := range c.Columns {
+		for _, col := range columns {
+			changed[table+"."+col] = true
+		}
+	}
+	return changed
+}
+
+// String summarizes the changes, for example
+// "+orders ~users(email, -nickname) -sessions".
+func (c SchemaChanges) String() string {
+	var parts []string
+	for _, table := range c.AddedTables {
+		parts = append(parts, "+"+table)
+	}
+	for _, table := range c.ChangedTables {
+		columns := append([]string(nil), c.Columns[table]...)
+		for _, col := range c.RemovedColumns[table] {
+			columns = append(columns, "-"+col)
+		}
+		if len(columns) > 0 {
+			parts = append(parts, "~"+table+"("+strings.Join(columns, ", ")+")")
+		} else {
+			parts = append(parts, "~"+table)
+		}
+	}
+	for _, table := range c.RemovedTables {
+		parts = append(parts, "-"+table)
+	}
+	return strings.Join(parts, " ")
+}
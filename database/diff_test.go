@@ -0,0 +1,71 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffSchemas(t *testing.T) {
+	previous := &Database{
+		Name: "shop",
+		Tables: []Table{
+			{
+				Name: "users",
+				Columns: []Column{
+					{Name: "id", Type: "integer"},
+					{Name: "email", Type: "varchar(100)"},
+					{Name: "nickname", Type: "text", IsNullable: true},
+				},
+				Constraints: []Constraint{{Kind: PrimaryKey, Columns: []string{"id"}}},
+			},
+			{
+				Name:    "orders",
+				Columns: []Column{{Name: "id", Type: "integer"}, {Name: "user_id", Type: "integer"}},
+			},
+			{Name: "sessions", Columns: []Column{{Name: "token", Type: "text"}}},
+		},
+	}
+	current := &Database{
+		Name: "shop",
+		Tables: []Table{
+			{
+				Name: "users",
+				Columns: []Column{
+					{Name: "id", Type: "integer"},
+					{Name: "email", Type: "varchar(255)"},
+				},
+				Constraints: []Constraint{{Kind: PrimaryKey, Columns: []string{"id"}}},
+			},
+			{
+				Name:    "orders",
+				Columns: []Column{{Name: "id", Type: "integer"}, {Name: "user_id", Type: "integer"}},
+				Constraints: []Constraint{
+					{Kind: ForeignKey, Columns: []string{"user_id"}, ReferenceTable: "users", ReferenceColumns: []string{"id"}},
+				},
+			},
+			{Name: "payments", Columns: []Column{{Name: "id", Type: "integer"}}},
+		},
+	}
+
+	changes := DiffSchemas(previous, current)
+
+	if got, expected := changes.String(), "+payments ~orders(user_id) ~users(email, -nickname) -sessions"; got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+
+	expected := map[string]bool{
+		"payments": true, "payments.id": true,
+		"orders": true, "orders.user_id": true,
+		"users": true, "users.email": true,
+	}
+	if got := changes.Changed(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	if !DiffSchemas(current, current).IsEmpty() {
+		t.Error("expected no changes between identical schemas")
+	}
+	if !DiffSchemas(nil, current).IsEmpty() {
+		t.Error("expected no changes without a previous schema")
+	}
+}
@@ -0,0 +1,147 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// ForeignKeyCheck describes a relationship whose referencing rows should all
+// have a matching row in the referenced table.
+type ForeignKeyCheck struct {
+	Table            string
+	Columns          []string
+	ReferenceTable   string
+	ReferenceColumns []string
+}
+
+// IntegrityResult reports the rows of a relationship that reference a missing parent row.
+type IntegrityResult struct {
+	Check ForeignKeyCheck
+	// ScannedRows is the number of referencing rows that were checked. Rows with
+	// a NULL in any referencing column are never orphans and are not scanned.
+	ScannedRows int64
+	// Capped is true when the scan stopped at IntegrityOptions.MaxRows, in which
+	// case OrphanCount only covers the scanned rows.
+	Capped      bool
+	OrphanCount int64
+	// SampleKeys holds distinct referencing key values without a parent row.
+	SampleKeys [][]string
+}
+
+// IntegrityOptions bounds the cost of an integrity check.
+type IntegrityOptions struct {
+	// MaxRows is the maximum number of referencing rows checked per relationship.
+	MaxRows int
+	// SampleKeys is the maximum number of offending keys reported per relationship.
+	SampleKeys int
+}
+
+// CheckForeignKeys counts, for each relationship, the referencing rows whose
+// columns have no matching row in the referenced table. This is useful where
+// foreign keys are not enforced, such as SQLite with foreign_keys=OFF or ClickHouse.
+func CheckForeignKeys(ctx context.Context, db *sql.DB, checks []ForeignKeyCheck, opts IntegrityOptions) ([]IntegrityResult, error) {
+	if opts.MaxRows <= 0 {
+		return nil, fmt.Errorf("max rows must be positive")
+	}
+
+	dbType, err := detectDatabaseType(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []IntegrityResult
+	for _, check := range checks {
+		result, err := checkForeignKey(ctx, db, dbType, check, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check %s(%s) → %s(%s): %w",
+				check.Table, strings.Join(check.Columns, ", "),
+				check.ReferenceTable, strings.Join(check.ReferenceColumns, ", "), err)
+		}
+		results = append(results, *result)
+	}
+
+	return results, nil
+}
+
+// checkForeignKey runs the integrity queries for a single relationship.
+func checkForeignKey(ctx context.Context, db *sql.DB, dbType string, check ForeignKeyCheck, opts IntegrityOptions) (*IntegrityResult, error) {
+	if len(check.Columns) == 0 || len(check.Columns) != len(check.ReferenceColumns) {
+		return nil, fmt.Errorf("referencing and referenced columns do not match")
+	}
+
+	// Only the first MaxRows referencing rows are checked so that the cost of
+	// a check is bounded regardless of table size
+	var notNull []string
+	for _, col := range check.Columns {
+		notNull = append(notNull, quoteIdentifier(dbType, col)+" IS NOT NULL")
+	}
+	referencing := func(limit int) string {
+		return fmt.Sprintf("(SELECT %s FROM %s WHERE %s LIMIT %d) AS c",
+			selectColumns(dbType, check.Columns), quoteIdentifier(dbType, check.Table),
+			strings.Join(notNull, " AND "), limit)
+	}
+
+	result := &IntegrityResult{Check: check}
+
+	// One more row than MaxRows tells a capped scan from a table with exactly
+	// MaxRows referencing rows
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+referencing(opts.MaxRows+1)).Scan(&result.ScannedRows); err != nil {
+		return nil, err
+	}
+	if result.ScannedRows > int64(opts.MaxRows) {
+		result.Capped = true
+		result.ScannedRows = int64(opts.MaxRows)
+	}
+
+	orphans := orphanQuery(dbType, referencing(opts.MaxRows), check)
+
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+orphans).Scan(&result.OrphanCount); err != nil {
+		return nil, err
+	}
+
+	if result.OrphanCount == 0 || opts.SampleKeys <= 0 {
+		return result, nil
+	}
+
+	keyColumns := make([]string, len(check.Columns))
+	for i, col := range check.Columns {
+		keyColumns[i] = "c." + quoteIdentifier(dbType, col)
+	}
+	sampleQuery := fmt.Sprintf("SELECT DISTINCT %s FROM %s LIMIT %d",
+		strings.Join(keyColumns, ", "), orphans, opts.SampleKeys)
+
+	values, err := readColumnValues(ctx, db, sampleQuery, len(check.Columns))
+	if err != nil {
+		return nil, err
+	}
+
+	for row := range values[0] {
+		key := make([]string, len(values))
+		for col := range values {
+			key[col] = values[col][row].text
+		}
+		result.SampleKeys = append(result.SampleKeys, key)
+	}
+
+	return result, nil
+}
+
+// orphanQuery builds the FROM clause selecting scanned rows without a parent row.
+// ClickHouse has no correlated subqueries, so it uses an anti join instead of NOT EXISTS.
+func orphanQuery(dbType, scanned string, check ForeignKeyCheck) string {
+	var conditions []string
+	for i, col := range check.Columns {
+		conditions = append(conditions, fmt.Sprintf("p.%s = c.%s",
+			quoteIdentifier(dbType, check.ReferenceColumns[i]), quoteIdentifier(dbType, col)))
+	}
+	parent := quoteIdentifier(dbType, check.ReferenceTable)
+
+	if dbType == "clickhouse" {
+		return fmt.Sprintf("%s LEFT ANTI JOIN %s AS p ON %s", scanned, parent, strings.Join(conditions, " AND "))
+	}
+
+	return fmt.Sprintf("%s WHERE NOT EXISTS (SELECT 1 FROM %s p WHERE %s)",
+		scanned, parent, strings.Join(conditions, " AND "))
+}
@@ -33,10 +33,16 @@ func (m *mysqlInspector) InspectSchema(ctx context.Context, db *sql.DB) (*Databa
 		return nil, fmt.Errorf("failed to get all constraints: %w", err)
 	}
 
+	allIndexes, err := m.getAllIndexes(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get all indexes: %w", err)
+	}
+
 	for i := range tables {
 		tableName := tables[i].Name
 		tables[i].Columns = allColumns[tableName]
 		tables[i].Constraints = allConstraints[tableName]
+		tables[i].Indexes = allIndexes[tableName]
 	}
 
 	return &Database{
@@ -55,7 +61,7 @@ func (m *mysqlInspector) getDatabaseName(ctx context.Context, db *sql.DB) (strin
 // getTables retrieves all tables from the current database.
 func (m *mysqlInspector) getTables(ctx context.Context, db *sql.DB) ([]Table, error) {
 	query := `
-		SELECT table_name
+		SELECT table_name, table_comment
 		FROM information_schema.tables
 		WHERE table_schema = DATABASE()
 		  AND table_type = 'BASE TABLE'
@@ -71,10 +77,11 @@ func (m *mysqlInspector) getTables(ctx context.Context, db *sql.DB) ([]Table, er
 	var tables []Table
 	for rows.Next() {
 		var tableName string
-		if err := rows.Scan(&tableName); err != nil {
+		var comment sql.NullString
+		if err := rows.Scan(&tableName, &comment); err != nil {
 			return nil, err
 		}
-		tables = append(tables, Table{Name: tableName})
+		tables = append(tables, Table{Name: tableName, Comment: comment.String})
 	}
 
 	return tables, rows.Err()
@@ -89,7 +96,8 @@ func (m *mysqlInspector) getAllColumns(ctx context.Context, db *sql.DB) (map[str
 		  column_name,
 		  column_type,
 		  is_nullable,
-		  column_default
+		  column_default,
+		  column_comment
 		FROM information_schema.columns
 		WHERE table_schema = DATABASE()
 		ORDER BY table_name, ordinal_position
@@ -108,8 +116,9 @@ func (m *mysqlInspector) getAllColumns(ctx context.Context, db *sql.DB) (map[str
 		var columnType string
 		var isNullable string
 		var columnDefault sql.NullString
+		var comment sql.NullString
 
-		if err := rows.Scan(&tableName, &columnName, &columnType, &isNullable, &columnDefault); err != nil {
+		if err := rows.Scan(&tableName, &columnName, &columnType, &isNullable, &columnDefault, &comment); err != nil {
 			return nil, err
 		}
 
@@ -117,6 +126,8 @@ func (m *mysqlInspector) getAllColumns(ctx context.Context, db *sql.DB) (map[str
 			Name:       columnName,
 			Type:       DataType(columnType),
 			IsNullable: isNullable == "YES",
+			Comment:    comment.String,
+			EnumValues: parseEnumValues(columnType),
 		}
 
 		if columnDefault.Valid {
@@ -323,6 +334,65 @@ func (m *mysqlInspector) getUniqueConstraints(ctx context.Context, db *sql.DB) (
 	return result, rows.Err()
 }
 
+// getAllIndexes retrieves all indexes for all tables, except the primary key.
+// Functional key parts have no column name and are reported by their
+// expression, on servers that have them (MySQL 8.0.13+, not MariaDB).
+func (m *mysqlInspector) getAllIndexes(ctx context.Context, db *sql.DB) (map[string][]Index, error) {
+	var expressions int
+	err := db.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = 'information_schema'
+		  AND TABLE_NAME = 'STATISTICS'
+		  AND COLUMN_NAME = 'EXPRESSION'
+	`).Scan(&expressions)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, mysqlIndexQuery(expressions > 0))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string][]Index)
+	for rows.Next() {
+		var tableName, indexName, columnName string
+		var nonUnique int
+		if err := rows.Scan(&tableName, &indexName, &nonUnique, &columnName); err != nil {
+			return nil, err
+		}
+
+		// Rows are ordered by index, so a new index starts whenever the name changes
+		indexes := result[tableName]
+		if len(indexes) == 0 || indexes[len(indexes)-1].Name != indexName {
+			indexes = append(indexes, Index{Name: indexName, Unique: nonUnique == 0})
+		}
+		indexes[len(indexes)-1].Columns = append(indexes[len(indexes)-1].Columns, columnName)
+		result[tableName] = indexes
+	}
+
+	return result, rows.Err()
+}
+
+// mysqlIndexQuery returns the query listing the key parts of the indexes,
+// reading the expression of functional key parts when the server has them.
+func mysqlIndexQuery(expressions bool) string {
+	column := "COLUMN_NAME"
+	if expressions {
+		column = "COALESCE(COLUMN_NAME, EXPRESSION)"
+	}
+	return `
+		SELECT TABLE_NAME, INDEX_NAME, NON_UNIQUE, ` + column + `
+		FROM information_schema.STATISTICS
+		WHERE TABLE_SCHEMA = DATABASE()
+		  AND INDEX_NAME <> 'PRIMARY'
+		  AND ` + column + ` IS NOT NULL
+		ORDER BY TABLE_NAME, INDEX_NAME, SEQ_IN_INDEX
+	`
+}
+
 // getCheckConstraints retrieves all check constraints (MySQL 8.0.16+).
 func (m *mysqlInspector) getCheckConstraints(ctx context.Context, db *sql.DB) (map[string][]Constraint, error) {
 	query := `
@@ -371,3 +441,100 @@ func (m *mysqlInspector) getCheckConstraints(ctx context.Context, db *sql.DB) (m
 
 	return result, rows.Err()
 }
+
+// SchemaFingerprint sums checksums of the column, key and index definitions in
+// information_schema. The sums do not depend on row order, so no sorting is
+// needed.
+func (m *mysqlInspector) SchemaFingerprint(ctx context.Context, db *sql.DB) (string, error) {
+	columns := `
+		SELECT CONCAT(COUNT(*), ':', COALESCE(SUM(CRC32(CONCAT_WS(' ',
+		  table_name, column_name, column_type, is_nullable, COALESCE(column_default, ''), column_key, extra
+		))), 0))
+		FROM information_schema.columns
+		WHERE table_schema = DATABASE()
+	`
+	keys := `
+		SELECT CONCAT(COUNT(*), ':', COALESCE(SUM(CRC32(CONCAT_WS(' ',
+		  table_name, constraint_name, column_name, COALESCE(referenced_table_name, ''), COALESCE(referenced_column_name, '')
+		))), 0))
+		FROM information_schema.key_column_usage
+		WHERE table_schema = DATABASE()
+	`
+	indexes := `
+		SELECT CONCAT(COUNT(*), ':', COALESCE(SUM(CRC32(CONCAT_WS(' ',
+		  table_name, index_name, seq_in_index, COALESCE(column_name, ''), non_unique
+		))), 0))
+		FROM information_schema.statistics
+		WHERE table_schema = DATABASE()
+	`
+
+	return fingerprintQueries(ctx, db, columns, keys, indexes)
+}
+
+// InspectStats fills in estimated row counts and data plus index sizes from
+// information_schema.tables. For InnoDB the row count is an estimate.
+func (m *mysqlInspector) InspectStats(ctx context.Context, db *sql.DB, tables []Table) error {
+	query := `
+		SELECT
+		  table_name,
+		  table_rows,
+		  COALESCE(data_length, 0) + COALESCE(index_length, 0)
+		FROM information_schema.tables
+		WHERE table_schema = DATABASE()
+		  AND table_type = 'BASE TABLE'
+	`
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	stats := make(map[string]*TableStats)
+	for rows.Next() {
+		var tableName string
+		var rowCount sql.NullInt64
+		var sizeBytes int64
+		if err := rows.Scan(&tableName, &rowCount, &sizeBytes); err != nil {
+			return err
+		}
+		stats[tableName] = &TableStats{RowCount: rowCount.Int64, SizeBytes: sizeBytes}
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range tables {
+		if tableStats, exists := stats[tables[i].Name]; exists {
+			tables[i].Stats = tableStats
+		}
+	}
+
+	return nil
+}
+
+// sampleQuery builds a query returning up to limit rows of a table. MySQL has no
+// TABLESAMPLE, so tables estimated to hold more rows than the limit are sampled
+// by filtering on RAND().
+func (m *mysqlInspector) sampleQuery(ctx context.Context, db *sql.DB, table string, columns []string, limit int) (string, bool, error) {
+	query := `
+		SELECT COALESCE(MAX(table_rows), 0)
+		FROM information_schema.tables
+		WHERE table_schema = DATABASE()
+		  AND table_name = ?
+	`
+
+	var estimate int64
+	if err := db.QueryRowContext(ctx, query, table).Scan(&estimate); err != nil {
+		return "", false, err
+	}
+
+	selectQuery := fmt.Sprintf("SELECT %s FROM %s", selectColumns("mysql", columns), quoteIdentifier("mysql", table))
+	if estimate <= int64(limit) {
+		return fmt.Sprintf("%s LIMIT %d", selectQuery, limit), false, nil
+	}
+
+	fraction := min(1, float64(limit)/float64(estimate)*1.5)
+	return fmt.Sprintf("%s WHERE RAND() < %f LIMIT %d", selectQuery, fraction, limit), true, nil
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"database/sql"
+	"strings"
 	"testing"
 
 	_ "github.com/go-sql-driver/mysql"
@@ -115,6 +116,23 @@ func TestMySQLInspectSchema(t *testing.T) {
 	}
 }
 
+// TestMySQLIndexQuery tests that servers without STATISTICS.EXPRESSION, such
+// as MariaDB and MySQL before 8.0.13, are only asked for column names.
+func TestMySQLIndexQuery(t *testing.T) {
+	query := mysqlIndexQuery(true)
+	if !strings.Contains(query, "COALESCE(COLUMN_NAME, EXPRESSION)") {
+		t.Errorf("expected expressions in the query:\n%s", query)
+	}
+
+	query = mysqlIndexQuery(false)
+	if strings.Contains(query, "EXPRESSION") {
+		t.Errorf("expected no EXPRESSION column in the fallback query:\n%s", query)
+	}
+	if !strings.Contains(query, "SELECT TABLE_NAME, INDEX_NAME, NON_UNIQUE, COLUMN_NAME") {
+		t.Errorf("expected column names in the fallback query:\n%s", query)
+	}
+}
+
 // TestMySQLDatabaseDetection tests the database type detection functionality for MySQL.
 func TestMySQLDatabaseDetection(t *testing.T) {
 	// Connect to dockerized MySQL
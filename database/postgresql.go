@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/lib/pq"
@@ -35,10 +36,16 @@ func (p *postgresInspector) InspectSchema(ctx context.Context, db *sql.DB) (*Dat
 		return nil, fmt.Errorf("failed to get all constraints: %w", err)
 	}
 
+	allIndexes, err := p.getAllIndexes(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get all indexes: %w", err)
+	}
+
 	for i := range tables {
 		tableName := tables[i].Name
 		tables[i].Columns = allColumns[tableName]
 		tables[i].Constraints = allConstraints[tableName]
+		tables[i].Indexes = allIndexes[tableName]
 	}
 
 	return &Database{
@@ -57,7 +64,9 @@ func (p *postgresInspector) getDatabaseName(ctx context.Context, db *sql.DB) (st
 // getTables retrieves all tables from the public schema.
 func (p *postgresInspector) getTables(ctx context.Context, db *sql.DB) ([]Table, error) {
 	query := `
-		select table_name 
+		select
+			table_name,
+			obj_description(format('%I.%I', table_schema, table_name)::regclass, 'pg_class')
 		from information_schema.tables 
 		where table_schema = 'public' 
 		and table_type = 'BASE TABLE'
@@ -73,10 +82,11 @@ func (p *postgresInspector) getTables(ctx context.Context, db *sql.DB) ([]Table,
 	var tables []Table
 	for rows.Next() {
 		var tableName string
-		if err := rows.Scan(&tableName); err != nil {
+		var comment sql.NullString
+		if err := rows.Scan(&tableName, &comment); err != nil {
 			return nil, err
 		}
-		tables = append(tables, Table{Name: tableName})
+		tables = append(tables, Table{Name: tableName, Comment: comment.String})
 	}
 
 	return tables, rows.Err()
@@ -94,7 +104,15 @@ func (p *postgresInspector) getAllColumns(ctx context.Context, db *sql.DB) (map[
 			numeric_precision,
 			numeric_scale,
 			is_nullable,
-			column_default
+			column_default,
+			col_description(format('%I.%I', table_schema, table_name)::regclass, ordinal_position),
+			(
+				select string_agg(quote_literal(e.enumlabel), ', ' order by e.enumsortorder)
+				from pg_catalog.pg_enum e
+				join pg_catalog.pg_type t on t.oid = e.enumtypid
+				join pg_catalog.pg_namespace n on n.oid = t.typnamespace
+				where n.nspname = udt_schema and t.typname = udt_name
+			)
 		from information_schema.columns
 		where table_schema = 'public' 
 		order by table_name, ordinal_position
@@ -117,10 +135,12 @@ func (p *postgresInspector) getAllColumns(ctx context.Context, db *sql.DB) (map[
 			numericScale     sql.NullInt64
 			isNullable       string
 			columnDefault    sql.NullString
+			comment          sql.NullString
+			enumValues       sql.NullString
 		)
 
 		if err := rows.Scan(&tableName, &columnName, &dataType, &charMaxLength, &numericPrecision,
-			&numericScale, &isNullable, &columnDefault); err != nil {
+			&numericScale, &isNullable, &columnDefault, &comment, &enumValues); err != nil {
 			return nil, err
 		}
 
@@ -131,6 +151,8 @@ func (p *postgresInspector) getAllColumns(ctx context.Context, db *sql.DB) (map[
 			Type:         DataType(pgType),
 			IsNullable:   isNullable == "YES",
 			DefaultValue: columnDefault.String,
+			Comment:      comment.String,
+			EnumValues:   parseQuotedList(enumValues.String),
 		}
 
 		allColumns[tableName] = append(allColumns[tableName], column)
@@ -460,6 +482,49 @@ func (p *postgresInspector) getAllUniqueConstraints(ctx context.Context, db *sql
 	return result, rows.Err()
 }
 
+// getAllIndexes retrieves all indexes for all tables, except those backing primary keys.
+// Expression entries of an index have no attribute and are read from its definition.
+func (p *postgresInspector) getAllIndexes(ctx context.Context, db *sql.DB) (map[string][]Index, error) {
+	query := `
+		select t.relname, i.relname, ix.indisunique,
+			coalesce(a.attname, pg_get_indexdef(ix.indexrelid, k.ord::int, true))
+		from pg_index ix
+		join pg_class t on t.oid = ix.indrelid
+		join pg_class i on i.oid = ix.indexrelid
+		join pg_namespace n on n.oid = t.relnamespace
+		cross join lateral unnest(ix.indkey::int2[]) with ordinality as k(attnum, ord)
+		left join pg_attribute a on a.attrelid = t.oid and a.attnum = k.attnum and k.attnum > 0
+		where n.nspname = 'public'
+			and not ix.indisprimary
+		order by t.relname, i.relname, k.ord
+	`
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string][]Index)
+	for rows.Next() {
+		var tableName, indexName, columnName string
+		var unique bool
+		if err := rows.Scan(&tableName, &indexName, &unique, &columnName); err != nil {
+			return nil, err
+		}
+
+		// Rows are ordered by index, so a new index starts whenever the name changes
+		indexes := result[tableName]
+		if len(indexes) == 0 || indexes[len(indexes)-1].Name != indexName {
+			indexes = append(indexes, Index{Name: indexName, Unique: unique})
+		}
+		indexes[len(indexes)-1].Columns = append(indexes[len(indexes)-1].Columns, columnName)
+		result[tableName] = indexes
+	}
+
+	return result, rows.Err()
+}
+
 // getAllCheckConstraints retrieves all check constraints for all tables.
 func (p *postgresInspector) getAllCheckConstraints(ctx context.Context, db *sql.DB) (map[string][]Constraint, error) {
 	query := `
@@ -498,6 +563,136 @@ func (p *postgresInspector) getAllCheckConstraints(ctx context.Context, db *sql.
 	return result, rows.Err()
 }
 
+// SchemaFingerprint hashes the columns, constraints and indexes of the tables
+// in the public schema from the system catalogs.
+func (p *postgresInspector) SchemaFingerprint(ctx context.Context, db *sql.DB) (string, error) {
+	query := `
+		select md5(coalesce(string_agg(entry, ',' order by entry), ''))
+		from (
+			select c.relname || '.' || a.attname || ' ' || format_type(a.atttypid, a.atttypmod)
+				|| ' ' || a.attnotnull::text || ' ' || coalesce(pg_get_expr(d.adbin, d.adrelid), '') as entry
+			from pg_attribute a
+			join pg_class c on c.oid = a.attrelid
+			join pg_namespace n on n.oid = c.relnamespace
+			left join pg_attrdef d on d.adrelid = a.attrelid and d.adnum = a.attnum
+			where n.nspname = 'public'
+			and c.relkind in ('r', 'p')
+			and a.attnum > 0
+			and not a.attisdropped
+			union all
+			select c.relname || ' ' || con.conname || ' ' || pg_get_constraintdef(con.oid)
+			from pg_constraint con
+			join pg_class c on c.oid = con.conrelid
+			join pg_namespace n on n.oid = c.relnamespace
+			where n.nspname = 'public'
+			union all
+			select pg_get_indexdef(i.indexrelid)
+			from pg_index i
+			join pg_class c on c.oid = i.indrelid
+			join pg_namespace n on n.oid = c.relnamespace
+			where n.nspname = 'public'
+		) entries
+	`
+
+	return fingerprintQueries(ctx, db, query)
+}
+
+// InspectStats fills in estimated row counts and total relation sizes (including
+// indexes and TOAST) from pg_class. Tables that have never been analyzed have no
+// estimate, so their rows are counted up to statsCountLimit instead.
+func (p *postgresInspector) InspectStats(ctx context.Context, db *sql.DB, tables []Table) error {
+	query := `
+		select
+			c.relname,
+			c.reltuples::bigint,
+			pg_total_relation_size(c.oid)
+		from pg_class c
+		join pg_namespace n on n.oid = c.relnamespace
+		where n.nspname = 'public'
+			and c.relkind in ('r', 'p')
+	`
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	stats := make(map[string]*TableStats)
+	for rows.Next() {
+		var tableName string
+		var rowCount, sizeBytes int64
+		if err := rows.Scan(&tableName, &rowCount, &sizeBytes); err != nil {
+			return err
+		}
+		stats[tableName] = &TableStats{RowCount: rowCount, SizeBytes: sizeBytes}
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range tables {
+		tableStats, exists := stats[tables[i].Name]
+		if !exists {
+			continue
+		}
+
+		// reltuples is -1 until the table is first vacuumed or analyzed
+		if tableStats.RowCount < 0 {
+			count, capped, err := countRowsWithLimit(ctx, db, quoteIdentifier("postgres", tables[i].Name), statsCountLimit)
+			if err != nil {
+				return fmt.Errorf("failed to count rows for table %s: %w", tables[i].Name, err)
+			}
+			tableStats.RowCount = count
+			tableStats.RowCountExact = !capped
+			tableStats.RowCountCapped = capped
+		}
+
+		tables[i].Stats = tableStats
+	}
+
+	return nil
+}
+
+// sampleQuery builds a query returning up to limit rows of a table. Tables estimated
+// to hold more rows than the limit are sampled with TABLESAMPLE SYSTEM.
+func (p *postgresInspector) sampleQuery(ctx context.Context, db *sql.DB, table string, columns []string, limit int) (string, bool, error) {
+	query := `
+		select coalesce((
+			select c.reltuples::bigint
+			from pg_class c
+			join pg_namespace n on n.oid = c.relnamespace
+			where n.nspname = 'public'
+				and c.relname = $1
+		), -1)
+	`
+
+	var estimate int64
+	if err := db.QueryRowContext(ctx, query, table).Scan(&estimate); err != nil {
+		return "", false, err
+	}
+
+	selectQuery := fmt.Sprintf("select %s from %s", selectColumns("postgres", columns), quoteIdentifier("postgres", table))
+	if estimate <= int64(limit) {
+		return fmt.Sprintf("%s limit %d", selectQuery, limit), false, nil
+	}
+
+	return fmt.Sprintf("%s tablesample system (%s) limit %d", selectQuery, samplePercent(limit, estimate), limit), true, nil
+}
+
+// minSamplePercent keeps the sample of a huge table from rounding down to no
+// pages at all.
+const minSamplePercent = 0.0001
+
+// samplePercent returns the TABLESAMPLE SYSTEM percentage that yields about
+// limit rows of a table estimated to hold estimate rows. SYSTEM sampling picks
+// whole pages, so it oversamples and leaves the cut to the limit.
+func samplePercent(limit int, estimate int64) string {
+	percent := min(100, max(minSamplePercent, float64(limit)/float64(estimate)*150))
+	return strconv.FormatFloat(percent, 'f', -1, 64)
+}
+
 // formatPostgresType converts PostgreSQL data type information into a standardized format.
 // Handles varchar, char, numeric, timestamp, and other PostgreSQL-specific types.
 func formatPostgresType(dataType string, charMaxLength, numericPrecision, numericScale sql.NullInt64) string {
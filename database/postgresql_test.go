@@ -181,3 +181,24 @@ func cleanupPostgreSQLTestSchema(ctx context.Context, db *sql.DB) {
 		db.ExecContext(ctx, query)
 	}
 }
+
+// TestPostgreSQLSamplePercent tests the TABLESAMPLE percentage for tables of
+// different sizes.
+func TestPostgreSQLSamplePercent(t *testing.T) {
+	tests := []struct {
+		limit    int
+		estimate int64
+		want     string
+	}{
+		{limit: 1000, estimate: 1500, want: "100"},
+		{limit: 1000, estimate: 1_000_000, want: "0.15"},
+		{limit: 1000, estimate: 300_000_000, want: "0.0005"},
+		{limit: 1000, estimate: 1 << 50, want: "0.0001"},
+	}
+
+	for _, tt := range tests {
+		if got := samplePercent(tt.limit, tt.estimate); got != tt.want {
+			t.Errorf("samplePercent(%d, %d) = %s, want %s", tt.limit, tt.estimate, got, tt.want)
+		}
+	}
+}
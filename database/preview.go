@@ -0,0 +1,58 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// RowPreview holds the first rows of a table in text form.
+type RowPreview struct {
+	Columns []string
+	// Rows holds one value per column, with NULL values set to "NULL".
+	Rows [][]string
+}
+
+// PreviewRows reads the first rows of a table, up to limit. Only a plain SELECT
+// is issued, so previewing never modifies the database.
+func PreviewRows(ctx context.Context, db *sql.DB, table *Table, limit int) (*RowPreview, error) {
+	if table == nil {
+		return nil, fmt.Errorf("table cannot be nil")
+	}
+
+	if len(table.Columns) == 0 {
+		return nil, fmt.Errorf("table %s has no columns", table.Name)
+	}
+
+	dbType, err := detectDatabaseType(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &RowPreview{}
+	for _, col := range table.Columns {
+		preview.Columns = append(preview.Columns, col.Name)
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s LIMIT %d",
+		selectColumns(dbType, preview.Columns), quoteIdentifier(dbType, table.Name), limit)
+
+	values, err := readColumnValues(ctx, db, query, len(preview.Columns))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rows of table %s: %w", table.Name, err)
+	}
+
+	for row := range values[0] {
+		cells := make([]string, len(values))
+		for col := range values {
+			if values[col][row].isNull {
+				cells[col] = "NULL"
+			} else {
+				cells[col] = values[col][row].text
+			}
+		}
+		preview.Rows = append(preview.Rows, cells)
+	}
+
+	return preview, nil
+}
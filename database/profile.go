@@ -0,0 +1,296 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// TableProfile holds per-column statistics computed over a sample of a table.
+type TableProfile struct {
+	Table      string
+	SampleSize int
+	// Sampled is true when the rows were drawn with engine sampling rather
+	// than read in full.
+	Sampled bool
+	Columns []ColumnProfile
+}
+
+// ColumnProfile holds statistics for a single column of a table sample.
+type ColumnProfile struct {
+	Name         string
+	Type         DataType
+	NullFraction float64
+	// DistinctCount is the number of distinct non-null values in the sample.
+	DistinctCount int
+	Min           string
+	Max           string
+	TopValues     []ValueCount
+	// Lengths is only set for text columns.
+	Lengths *LengthDistribution
+}
+
+// ValueCount is a value together with the number of times it occurs in the sample.
+type ValueCount struct {
+	Value string
+	Count int
+}
+
+// LengthDistribution summarizes the character lengths of a text column.
+type LengthDistribution struct {
+	Min  int
+	P50  int
+	P90  int
+	Max  int
+	Mean float64
+}
+
+// ProfileOptions controls how a table is sampled and profiled.
+type ProfileOptions struct {
+	// SampleRows is the maximum number of rows to read.
+	SampleRows int
+	// TopValues is the number of most frequent values reported per column.
+	TopValues int
+}
+
+// rowSampler is implemented by inspectors that can build an engine-specific
+// query returning a random sample of a table.
+type rowSampler interface {
+	sampleQuery(ctx context.Context, db *sql.DB, table string, columns []string, limit int) (query string, sampled bool, err error)
+}
+
+// ProfileTable samples a table and computes per-column null fractions, distinct
+// counts, min/max, most frequent values and text length distributions.
+func ProfileTable(ctx context.Context, db *sql.DB, table *Table, opts ProfileOptions) (*TableProfile, error) {
+	if table == nil {
+		return nil, fmt.Errorf("table cannot be nil")
+	}
+
+	if len(table.Columns) == 0 {
+		return nil, fmt.Errorf("table %s has no columns", table.Name)
+	}
+
+	if opts.SampleRows <= 0 {
+		return nil, fmt.Errorf("sample size must be positive")
+	}
+
+	dbType, err := detectDatabaseType(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	inspector, err := newInspector(dbType)
+	if err != nil {
+		return nil, err
+	}
+
+	sampler, ok := inspector.(rowSampler)
+	if !ok {
+		return nil, fmt.Errorf("profiling is not supported for database type: %s", dbType)
+	}
+
+	columnNames := make([]string, len(table.Columns))
+	for i, col := range table.Columns {
+		columnNames[i] = col.Name
+	}
+
+	query, sampled, err := sampler.sampleQuery(ctx, db, table.Name, columnNames, opts.SampleRows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build sample query: %w", err)
+	}
+
+	values, err := readColumnValues(ctx, db, query, len(table.Columns))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sample table %s: %w", table.Name, err)
+	}
+
+	profile := &TableProfile{
+		Table:   table.Name,
+		Sampled: sampled,
+	}
+	if len(values) > 0 {
+		profile.SampleSize = len(values[0])
+	}
+
+	for i, col := range table.Columns {
+		profile.Columns = append(profile.Columns, profileColumn(col, values[i], opts.TopValues))
+	}
+
+	return profile, nil
+}
+
+// sampleValue is a scanned value converted to its text form.
+type sampleValue struct {
+	text   string
+	isNull bool
+	isText bool
+}
+
+// readColumnValues runs a query and returns its values column by column.
+func readColumnValues(ctx context.Context, db *sql.DB, query string, columnCount int) ([][]sampleValue, error) {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := make([][]sampleValue, columnCount)
+	dest := make([]any, columnCount)
+	raw := make([]any, columnCount)
+	for i := range dest {
+		dest[i] = &raw[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		for i, v := range raw {
+			values[i] = append(values[i], toSampleValue(v))
+		}
+	}
+
+	return values, rows.Err()
+}
+
+// toSampleValue converts a value scanned by a driver into its text form.
+func toSampleValue(v any) sampleValue {
+	switch v := v.(type) {
+	case nil:
+		return sampleValue{isNull: true}
+	case []byte:
+		return sampleValue{text: string(v), isText: true}
+	case string:
+		return sampleValue{text: v, isText: true}
+	case *string:
+		if v == nil {
+			return sampleValue{isNull: true}
+		}
+		return sampleValue{text: *v, isText: true}
+	case time.Time:
+		return sampleValue{text: v.Format(time.RFC3339Nano)}
+	case *time.Time:
+		if v == nil {
+			return sampleValue{isNull: true}
+		}
+		return sampleValue{text: v.Format(time.RFC3339Nano)}
+	default:
+		return sampleValue{text: fmt.Sprint(v)}
+	}
+}
+
+// profileColumn computes the statistics of one column from its sampled values.
+func profileColumn(col Column, values []sampleValue, topValues int) ColumnProfile {
+	profile := ColumnProfile{
+		Name: col.Name,
+		Type: col.Type,
+	}
+
+	if len(values) == 0 {
+		return profile
+	}
+
+	counts := make(map[string]int)
+	var nonNull []string
+	isText := false
+	isNumeric := true
+	for _, v := range values {
+		if v.isNull {
+			continue
+		}
+		counts[v.text]++
+		nonNull = append(nonNull, v.text)
+		if v.isText {
+			isText = true
+		}
+		if _, err := strconv.ParseFloat(v.text, 64); err != nil {
+			isNumeric = false
+		}
+	}
+
+	profile.NullFraction = float64(len(values)-len(nonNull)) / float64(len(values))
+	profile.DistinctCount = len(counts)
+
+	if len(nonNull) == 0 {
+		return profile
+	}
+
+	// Text columns holding only numbers (e.g. SQLite or numeric types scanned
+	// as bytes) still compare numerically but keep their length distribution
+	less := func(a, b string) bool { return a < b }
+	if isNumeric {
+		less = func(a, b string) bool {
+			x, _ := strconv.ParseFloat(a, 64)
+			y, _ := strconv.ParseFloat(b, 64)
+			return x < y
+		}
+	}
+
+	profile.Min, profile.Max = nonNull[0], nonNull[0]
+	for _, v := range nonNull[1:] {
+		if less(v, profile.Min) {
+			profile.Min = v
+		}
+		if less(profile.Max, v) {
+			profile.Max = v
+		}
+	}
+
+	for value, count := range counts {
+		profile.TopValues = append(profile.TopValues, ValueCount{Value: value, Count: count})
+	}
+	sort.Slice(profile.TopValues, func(i, j int) bool {
+		if profile.TopValues[i].Count != profile.TopValues[j].Count {
+			return profile.TopValues[i].Count > profile.TopValues[j].Count
+		}
+		return profile.TopValues[i].Value < profile.TopValues[j].Value
+	})
+	if len(profile.TopValues) > topValues {
+		profile.TopValues = profile.TopValues[:max(topValues, 0)]
+	}
+
+	if isText && !isNumeric {
+		profile.Lengths = lengthDistribution(nonNull)
+	}
+
+	return profile
+}
+
+// lengthDistribution computes character length percentiles of text values.
+func lengthDistribution(values []string) *LengthDistribution {
+	lengths := make([]int, len(values))
+	total := 0
+	for i, v := range values {
+		lengths[i] = utf8.RuneCountInString(v)
+		total += lengths[i]
+	}
+	sort.Ints(lengths)
+
+	percentile := func(p float64) int {
+		index := int(math.Ceil(p*float64(len(lengths)))) - 1
+		return lengths[max(index, 0)]
+	}
+
+	return &LengthDistribution{
+		Min:  lengths[0],
+		P50:  percentile(0.5),
+		P90:  percentile(0.9),
+		Max:  lengths[len(lengths)-1],
+		Mean: float64(total) / float64(len(lengths)),
+	}
+}
+
+// selectColumns builds a quoted column list for a sample query.
+func selectColumns(dbType string, columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = quoteIdentifier(dbType, col)
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,53 @@
+package database
+
+import (
+	"testing"
+)
+
+// TestProfileColumn tests the statistics computed for sampled column values.
+func TestProfileColumn(t *testing.T) {
+	text := func(s string) sampleValue { return sampleValue{text: s, isText: true} }
+	number := func(s string) sampleValue { return sampleValue{text: s} }
+	null := sampleValue{isNull: true}
+
+	t.Run("text column", func(t *testing.T) {
+		values := []sampleValue{text("shipped"), text("pending"), text("shipped"), null, text("cancelled")}
+		profile := profileColumn(Column{Name: "status", Type: "text"}, values, 2)
+
+		if profile.NullFraction != 0.2 {
+			t.Errorf("NullFraction = %v, want 0.2", profile.NullFraction)
+		}
+		if profile.DistinctCount != 3 {
+			t.Errorf("DistinctCount = %v, want 3", profile.DistinctCount)
+		}
+		if profile.Min != "cancelled" || profile.Max != "shipped" {
+			t.Errorf("Min/Max = %q/%q, want cancelled/shipped", profile.Min, profile.Max)
+		}
+		if len(profile.TopValues) != 2 || profile.TopValues[0] != (ValueCount{Value: "shipped", Count: 2}) {
+			t.Errorf("TopValues = %+v, want shipped first", profile.TopValues)
+		}
+		if profile.Lengths == nil || profile.Lengths.Min != 7 || profile.Lengths.Max != 9 {
+			t.Errorf("Lengths = %+v, want min 7 and max 9", profile.Lengths)
+		}
+	})
+
+	t.Run("numeric column", func(t *testing.T) {
+		values := []sampleValue{number("9"), number("10"), number("-3.5")}
+		profile := profileColumn(Column{Name: "amount", Type: "numeric"}, values, 5)
+
+		if profile.Min != "-3.5" || profile.Max != "10" {
+			t.Errorf("Min/Max = %q/%q, want -3.5/10", profile.Min, profile.Max)
+		}
+		if profile.Lengths != nil {
+			t.Errorf("Lengths = %+v, want nil for numeric column", profile.Lengths)
+		}
+	})
+
+	t.Run("all null column", func(t *testing.T) {
+		profile := profileColumn(Column{Name: "deleted_at", Type: "timestamp"}, []sampleValue{null, null}, 5)
+
+		if profile.NullFraction != 1 || profile.DistinctCount != 0 || len(profile.TopValues) != 0 {
+			t.Errorf("profile = %+v, want only nulls", profile)
+		}
+	})
+}
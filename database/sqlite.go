@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -37,6 +38,12 @@ func (s *sqliteInspector) InspectSchema(ctx context.Context, db *sql.DB) (*Datab
 			return nil, fmt.Errorf("failed to get constraints for table %s: %w", tableName, err)
 		}
 		tables[i].Constraints = constraints
+
+		indexes, err := s.getIndexes(ctx, db, tableName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get indexes for table %s: %w", tableName, err)
+		}
+		tables[i].Indexes = indexes
 	}
 
 	return &Database{
@@ -273,9 +280,54 @@ func (s *sqliteInspector) getUniqueConstraints(ctx context.Context, db *sql.DB,
 	return constraints, rows.Err()
 }
 
+// getIndexes retrieves all indexes of a table except the one backing its primary key.
+func (s *sqliteInspector) getIndexes(ctx context.Context, db *sql.DB, tableName string) ([]Index, error) {
+	query := fmt.Sprintf("PRAGMA index_list(%s)", quoteIdentifier("sqlite", tableName))
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var indexes []Index
+	for rows.Next() {
+		var seq int
+		var name string
+		var unique int
+		var origin string
+		var partial int
+
+		if err := rows.Scan(&seq, &name, &unique, &origin, &partial); err != nil {
+			return nil, err
+		}
+
+		if origin == "pk" {
+			continue
+		}
+
+		indexes = append(indexes, Index{Name: name, Unique: unique == 1})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range indexes {
+		columns, err := s.getIndexColumns(ctx, db, indexes[i].Name)
+		if err != nil {
+			return nil, err
+		}
+		indexes[i].Columns = columns
+	}
+
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i].Name < indexes[j].Name })
+
+	return indexes, nil
+}
+
 // getIndexColumns retrieves the columns for a specific index.
 func (s *sqliteInspector) getIndexColumns(ctx context.Context, db *sql.DB, indexName string) ([]string, error) {
-	query := fmt.Sprintf("PRAGMA index_info(%s)", indexName)
+	query := fmt.Sprintf("PRAGMA index_info(%s)", quoteIdentifier("sqlite", indexName))
 
 	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
@@ -287,14 +339,87 @@ func (s *sqliteInspector) getIndexColumns(ctx context.Context, db *sql.DB, index
 	for rows.Next() {
 		var seqno int
 		var cid int
-		var name string
+		var name sql.NullString
 
 		if err := rows.Scan(&seqno, &cid, &name); err != nil {
 			return nil, err
 		}
 
-		columns = append(columns, name)
+		// Expression index entries have no column name
+		if !name.Valid {
+			columns = append(columns, "<expression>")
+			continue
+		}
+
+		columns = append(columns, name.String)
 	}
 
 	return columns, rows.Err()
 }
+
+// InspectStats counts rows for each table up to statsCountLimit, since SQLite
+// keeps no row estimates. Sizes are read from the dbstat virtual table when
+// SQLite was compiled with it, and are left at 0 otherwise.
+func (s *sqliteInspector) InspectStats(ctx context.Context, db *sql.DB, tables []Table) error {
+	sizes, err := s.getTableSizes(ctx, db)
+	if err != nil {
+		sizes = make(map[string]int64)
+	}
+
+	for i := range tables {
+		count, capped, err := countRowsWithLimit(ctx, db, quoteIdentifier("sqlite", tables[i].Name), statsCountLimit)
+		if err != nil {
+			return fmt.Errorf("failed to count rows for table %s: %w", tables[i].Name, err)
+		}
+
+		tables[i].Stats = &TableStats{
+			RowCount:       count,
+			RowCountExact:  !capped,
+			RowCountCapped: capped,
+			SizeBytes:      sizes[tables[i].Name],
+		}
+	}
+
+	return nil
+}
+
+// SchemaFingerprint returns the schema version, which SQLite increments on
+// every schema change.
+func (s *sqliteInspector) SchemaFingerprint(ctx context.Context, db *sql.DB) (string, error) {
+	return fingerprintQueries(ctx, db, "PRAGMA schema_version")
+}
+
+// getTableSizes retrieves the size of each table and its indexes from dbstat.
+func (s *sqliteInspector) getTableSizes(ctx context.Context, db *sql.DB) (map[string]int64, error) {
+	query := `
+		SELECT m.tbl_name, SUM(d.pgsize)
+		FROM dbstat d
+		JOIN sqlite_master m ON m.name = d.name
+		GROUP BY m.tbl_name
+	`
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sizes := make(map[string]int64)
+	for rows.Next() {
+		var tableName string
+		var size int64
+		if err := rows.Scan(&tableName, &size); err != nil {
+			return nil, err
+		}
+		sizes[tableName] = size
+	}
+
+	return sizes, rows.Err()
+}
+
+// sampleQuery builds a query returning a random sample of up to limit rows.
+func (s *sqliteInspector) sampleQuery(ctx context.Context, db *sql.DB, table string, columns []string, limit int) (string, bool, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s ORDER BY random() LIMIT %d",
+		selectColumns("sqlite", columns), quoteIdentifier("sqlite", table), limit)
+	return query, true, nil
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"database/sql"
+	"strings"
 	"testing"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -127,6 +128,247 @@ func TestSQLiteDatabaseDetection(t *testing.T) {
 	if dbType != "sqlite" {
 		t.Errorf("Expected database type 'sqlite', got '%s'", dbType)
 	}
+
+	version, err := ServerVersion(ctx, db)
+	if err != nil {
+		t.Fatalf("Failed to get server version: %v", err)
+	}
+
+	if !strings.HasPrefix(version, "3.") {
+		t.Errorf("Expected a SQLite 3 version, got '%s'", version)
+	}
+}
+
+// TestSQLiteInspectStats tests row counting for SQLite table statistics.
+func TestSQLiteInspectStats(t *testing.T) {
+	// Use in-memory database for testing
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create in-memory database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	// Create test schema
+	if err := createSQLiteTestSchema(ctx, db); err != nil {
+		t.Fatalf("Failed to create test schema: %v", err)
+	}
+
+	for _, query := range []string{
+		`INSERT INTO test_users (email, name) VALUES ('a@example.com', 'a'), ('b@example.com', 'b')`,
+		`INSERT INTO test_posts (user_id, title) VALUES (1, 'first')`,
+	} {
+		if _, err := db.ExecContext(ctx, query); err != nil {
+			t.Fatalf("Failed to insert test data: %v", err)
+		}
+	}
+
+	result, err := InspectSchema(ctx, db)
+	if err != nil {
+		t.Fatalf("InspectSchema failed: %v", err)
+	}
+
+	if err := InspectStats(ctx, db, result); err != nil {
+		t.Fatalf("InspectStats failed: %v", err)
+	}
+
+	expectedRows := map[string]int64{"test_users": 2, "test_posts": 1}
+	for _, table := range result.Tables {
+		want, exists := expectedRows[table.Name]
+		if !exists {
+			continue
+		}
+		if table.Stats == nil {
+			t.Errorf("Expected stats for table %s", table.Name)
+			continue
+		}
+		if table.Stats.RowCount != want || !table.Stats.RowCountExact {
+			t.Errorf("Table %s stats = %+v, want %d rows", table.Name, table.Stats, want)
+		}
+	}
+}
+
+// TestSQLiteProfileTable tests sampling and profiling a SQLite table.
+func TestSQLiteProfileTable(t *testing.T) {
+	// Use in-memory database for testing
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create in-memory database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	// Create test schema
+	if err := createSQLiteTestSchema(ctx, db); err != nil {
+		t.Fatalf("Failed to create test schema: %v", err)
+	}
+
+	if _, err := db.ExecContext(ctx, `INSERT INTO test_users (email, name) VALUES
+		('a@example.com', 'alice'), ('b@example.com', NULL), ('c@example.com', 'alice'), ('d@example.com', NULL)`); err != nil {
+		t.Fatalf("Failed to insert test data: %v", err)
+	}
+
+	result, err := InspectSchema(ctx, db)
+	if err != nil {
+		t.Fatalf("InspectSchema failed: %v", err)
+	}
+
+	var usersTable *Table
+	for i := range result.Tables {
+		if result.Tables[i].Name == "test_users" {
+			usersTable = &result.Tables[i]
+		}
+	}
+
+	if usersTable == nil {
+		t.Fatal("test_users table not found")
+	}
+
+	profile, err := ProfileTable(ctx, db, usersTable, ProfileOptions{SampleRows: 100, TopValues: 3})
+	if err != nil {
+		t.Fatalf("ProfileTable failed: %v", err)
+	}
+
+	if profile.SampleSize != 4 {
+		t.Errorf("Expected sample size 4, got %d", profile.SampleSize)
+	}
+
+	if len(profile.Columns) != len(usersTable.Columns) {
+		t.Fatalf("Expected %d column profiles, got %d", len(usersTable.Columns), len(profile.Columns))
+	}
+
+	for _, col := range profile.Columns {
+		switch col.Name {
+		case "name":
+			if col.NullFraction != 0.5 || col.DistinctCount != 1 {
+				t.Errorf("Unexpected profile for name column: %+v", col)
+			}
+		case "email":
+			if col.DistinctCount != 4 || col.Lengths == nil || col.Lengths.Max != 13 {
+				t.Errorf("Unexpected profile for email column: %+v", col)
+			}
+		}
+	}
+}
+
+// TestSQLiteCheckForeignKeys tests counting orphaned rows when foreign keys are not enforced.
+func TestSQLiteCheckForeignKeys(t *testing.T) {
+	// Use in-memory database for testing
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create in-memory database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	// Create test schema
+	if err := createSQLiteTestSchema(ctx, db); err != nil {
+		t.Fatalf("Failed to create test schema: %v", err)
+	}
+
+	for _, query := range []string{
+		`PRAGMA foreign_keys = OFF`,
+		`INSERT INTO test_users (id, email) VALUES (1, 'a@example.com')`,
+		`INSERT INTO test_posts (user_id, title) VALUES (1, 'ok'), (7, 'orphan'), (7, 'orphan again'), (9, 'orphan'), (NULL, 'no author')`,
+	} {
+		if _, err := db.ExecContext(ctx, query); err != nil {
+			t.Fatalf("Failed to insert test data: %v", err)
+		}
+	}
+
+	check := ForeignKeyCheck{
+		Table:            "test_posts",
+		Columns:          []string{"user_id"},
+		ReferenceTable:   "test_users",
+		ReferenceColumns: []string{"id"},
+	}
+
+	results, err := CheckForeignKeys(ctx, db, []ForeignKeyCheck{check}, IntegrityOptions{MaxRows: 100, SampleKeys: 10})
+	if err != nil {
+		t.Fatalf("CheckForeignKeys failed: %v", err)
+	}
+
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 result, got %d", len(results))
+	}
+
+	result := results[0]
+	if result.ScannedRows != 4 || result.Capped {
+		t.Errorf("Expected 4 scanned rows without cap, got %d (capped: %v)", result.ScannedRows, result.Capped)
+	}
+	if result.OrphanCount != 3 {
+		t.Errorf("Expected 3 orphaned rows, got %d", result.OrphanCount)
+	}
+	if len(result.SampleKeys) != 2 {
+		t.Errorf("Expected 2 distinct offending keys, got %v", result.SampleKeys)
+	}
+
+	// A bounded scan only checks the first rows
+	results, err = CheckForeignKeys(ctx, db, []ForeignKeyCheck{check}, IntegrityOptions{MaxRows: 2, SampleKeys: 1})
+	if err != nil {
+		t.Fatalf("CheckForeignKeys failed: %v", err)
+	}
+
+	if !results[0].Capped || results[0].ScannedRows != 2 || len(results[0].SampleKeys) > 1 {
+		t.Errorf("Expected capped scan of 2 rows with at most 1 sample key, got %+v", results[0])
+	}
+
+	// Exactly MaxRows referencing rows are all checked, so the scan is complete
+	results, err = CheckForeignKeys(ctx, db, []ForeignKeyCheck{check}, IntegrityOptions{MaxRows: 4, SampleKeys: 10})
+	if err != nil {
+		t.Fatalf("CheckForeignKeys failed: %v", err)
+	}
+
+	if results[0].Capped || results[0].ScannedRows != 4 || results[0].OrphanCount != 3 {
+		t.Errorf("Expected complete scan of 4 rows with 3 orphans, got %+v", results[0])
+	}
+}
+
+// TestSQLitePreviewRows tests reading the first rows of a SQLite table.
+func TestSQLitePreviewRows(t *testing.T) {
+	// Use in-memory database for testing
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create in-memory database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	// Create test schema
+	if err := createSQLiteTestSchema(ctx, db); err != nil {
+		t.Fatalf("Failed to create test schema: %v", err)
+	}
+
+	if _, err := db.ExecContext(ctx, `INSERT INTO test_users (email, name) VALUES
+		('a@example.com', 'alice'), ('b@example.com', NULL), ('c@example.com', 'carol')`); err != nil {
+		t.Fatalf("Failed to insert test data: %v", err)
+	}
+
+	table := &Table{
+		Name:    "test_users",
+		Columns: []Column{{Name: "email"}, {Name: "name"}},
+	}
+
+	preview, err := PreviewRows(ctx, db, table, 2)
+	if err != nil {
+		t.Fatalf("PreviewRows failed: %v", err)
+	}
+
+	if len(preview.Columns) != 2 || preview.Columns[0] != "email" {
+		t.Errorf("Unexpected preview columns: %v", preview.Columns)
+	}
+
+	if len(preview.Rows) != 2 {
+		t.Fatalf("Expected 2 rows, got %d", len(preview.Rows))
+	}
+
+	if preview.Rows[1][0] != "b@example.com" || preview.Rows[1][1] != "NULL" {
+		t.Errorf("Unexpected second row: %v", preview.Rows[1])
+	}
 }
 
 // createSQLiteTestSchema creates test tables with various column types and constraints for testing.
@@ -156,3 +398,105 @@ func createSQLiteTestSchema(ctx context.Context, db *sql.DB) error {
 
 	return nil
 }
+
+// TestSQLiteIndexes tests that secondary indexes are collected for SQLite tables.
+func TestSQLiteIndexes(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create in-memory database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	statements := []string{
+		`CREATE TABLE events (id INTEGER PRIMARY KEY, user_id INTEGER, kind TEXT, slug TEXT UNIQUE)`,
+		`CREATE INDEX idx_events_user_kind ON events (user_id, kind)`,
+		`CREATE INDEX idx_events_lower_kind ON events (lower(kind))`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("Failed to execute %q: %v", stmt, err)
+		}
+	}
+
+	result, err := InspectSchema(ctx, db)
+	if err != nil {
+		t.Fatalf("InspectSchema failed: %v", err)
+	}
+
+	indexes := make(map[string]Index)
+	for _, index := range result.Tables[0].Indexes {
+		indexes[index.Name] = index
+	}
+
+	if len(indexes) != 3 {
+		t.Fatalf("Expected 3 indexes, got %d: %+v", len(indexes), result.Tables[0].Indexes)
+	}
+
+	composite := indexes["idx_events_user_kind"]
+	if composite.Unique || len(composite.Columns) != 2 || composite.Columns[0] != "user_id" || composite.Columns[1] != "kind" {
+		t.Errorf("Unexpected composite index: %+v", composite)
+	}
+
+	if expression := indexes["idx_events_lower_kind"]; len(expression.Columns) != 1 || expression.Columns[0] != "<expression>" {
+		t.Errorf("Unexpected expression index: %+v", expression)
+	}
+
+	if unique := indexes["sqlite_autoindex_events_1"]; !unique.Unique || len(unique.Columns) != 1 || unique.Columns[0] != "slug" {
+		t.Errorf("Unexpected unique index: %+v", unique)
+	}
+}
+
+// TestSQLiteSchemaFingerprint tests that the fingerprint changes with the schema
+// and the diff reports what changed.
+func TestSQLiteSchemaFingerprint(t *testing.T) {
+	// Use in-memory database for testing
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create in-memory database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	// Create test schema
+	if err := createSQLiteTestSchema(ctx, db); err != nil {
+		t.Fatalf("Failed to create test schema: %v", err)
+	}
+
+	before, err := SchemaFingerprint(ctx, db)
+	if err != nil {
+		t.Fatalf("SchemaFingerprint failed: %v", err)
+	}
+	previous, err := InspectSchema(ctx, db)
+	if err != nil {
+		t.Fatalf("InspectSchema failed: %v", err)
+	}
+
+	if _, err := db.ExecContext(ctx, `INSERT INTO test_users (email, name) VALUES ('a@example.com', 'a')`); err != nil {
+		t.Fatalf("Failed to insert test data: %v", err)
+	}
+	if unchanged, err := SchemaFingerprint(ctx, db); err != nil || unchanged != before {
+		t.Errorf("Expected fingerprint %s after inserting rows, got %s (err %v)", before, unchanged, err)
+	}
+
+	if _, err := db.ExecContext(ctx, `ALTER TABLE test_users ADD COLUMN nickname TEXT`); err != nil {
+		t.Fatalf("Failed to alter table: %v", err)
+	}
+	after, err := SchemaFingerprint(ctx, db)
+	if err != nil {
+		t.Fatalf("SchemaFingerprint failed: %v", err)
+	}
+	if after == before {
+		t.Errorf("Expected fingerprint to change after altering a table, got %s", after)
+	}
+
+	current, err := InspectSchema(ctx, db)
+	if err != nil {
+		t.Fatalf("InspectSchema failed: %v", err)
+	}
+	if changes := DiffSchemas(previous, current).String(); changes != "~test_users(nickname)" {
+		t.Errorf("Expected test_users.nickname to be added, got %q", changes)
+	}
+}
@@ -0,0 +1,65 @@
+package graph
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/viveknathani/dbtree/database"
+)
+
+// Filter returns the subgraph of tables whose names match at least one include
+// pattern and no exclude pattern. Patterns use path.Match glob syntax, and an
+// empty include list matches every table. Relationships are kept when both of
+// their tables are.
+func Filter(g *SchemaGraph, include, exclude []string) (*SchemaGraph, error) {
+	if g == nil {
+		return nil, fmt.Errorf("graph is nil")
+	}
+
+	for _, pattern := range append(append([]string{}, include...), exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+
+	matchesAny := func(patterns []string, name TableName) bool {
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, string(name)); ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	nodes := make(map[TableName]*database.Table)
+	for name, table := range g.Nodes {
+		if len(include) > 0 && !matchesAny(include, name) {
+			continue
+		}
+		if matchesAny(exclude, name) {
+			continue
+		}
+		nodes[name] = table
+	}
+
+	edges := []ForeignKeyEdge{}
+	for _, edge := range g.Edges {
+		if nodes[edge.FromTable] != nil && nodes[edge.ToTable] != nil {
+			edges = append(edges, edge)
+		}
+	}
+
+	var dataFlows []DataFlowEdge
+	for _, flow := range g.DataFlows {
+		if nodes[flow.FromTable] != nil && nodes[flow.ToTable] != nil {
+			dataFlows = append(dataFlows, flow)
+		}
+	}
+
+	return &SchemaGraph{
+		DatabaseName: g.DatabaseName,
+		Nodes:        nodes,
+		Edges:        edges,
+		DataFlows:    dataFlows,
+	}, nil
+}
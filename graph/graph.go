@@ -15,10 +15,20 @@ type ForeignKeyEdge struct {
 	ReferenceColumns []string
 }
 
+// DataFlowEdge represents data moving from one table into another, such as a
+// materialized view reading from its source table or a Distributed table
+// fronting its local table. Unlike foreign keys, the edge points downstream.
+type DataFlowEdge struct {
+	FromTable TableName
+	ToTable   TableName
+	Kind      database.DataFlowKind
+}
+
 type SchemaGraph struct {
 	DatabaseName string
 	Nodes        map[TableName]*database.Table
 	Edges        []ForeignKeyEdge
+	DataFlows    []DataFlowEdge
 }
 
 func Build(db *database.Database) (*SchemaGraph, error) {
@@ -54,9 +64,26 @@ func Build(db *database.Database) (*SchemaGraph, error) {
 		}
 	}
 
+	// Third pass: create data flow edges
+	var dataFlows []DataFlowEdge
+	for i := range db.Tables {
+		table := &db.Tables[i]
+		for _, source := range table.DataSources {
+			sourceTable := TableName(source.Table)
+			if _, exists := nodes[sourceTable]; exists {
+				dataFlows = append(dataFlows, DataFlowEdge{
+					FromTable: sourceTable,
+					ToTable:   TableName(table.Name),
+					Kind:      source.Kind,
+				})
+			}
+		}
+	}
+
 	return &SchemaGraph{
 		DatabaseName: db.Name,
 		Nodes:        nodes,
 		Edges:        edges,
+		DataFlows:    dataFlows,
 	}, nil
 }
@@ -162,3 +162,186 @@ func TestBuild(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildDataFlows(t *testing.T) {
+	db := &database.Database{
+		Name: "analytics",
+		Tables: []database.Table{
+			{Name: "kafka_queue"},
+			{
+				Name: "mv_parse",
+				DataSources: []database.DataSource{
+					{Kind: database.MaterializedViewFlow, Table: "kafka_queue"},
+				},
+			},
+			{
+				Name: "events_local",
+				DataSources: []database.DataSource{
+					{Kind: database.MaterializedViewFlow, Table: "mv_parse"},
+				},
+			},
+			{
+				Name: "events",
+				DataSources: []database.DataSource{
+					{Kind: database.DistributedFlow, Table: "events_local"},
+					{Kind: database.DistributedFlow, Table: "missing_table"},
+				},
+			},
+		},
+	}
+
+	result, err := Build(db)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	expected := []DataFlowEdge{
+		{FromTable: "kafka_queue", ToTable: "mv_parse", Kind: database.MaterializedViewFlow},
+		{FromTable: "mv_parse", ToTable: "events_local", Kind: database.MaterializedViewFlow},
+		{FromTable: "events_local", ToTable: "events", Kind: database.DistributedFlow},
+	}
+
+	if !reflect.DeepEqual(result.DataFlows, expected) {
+		t.Errorf("DataFlows = %+v, want %+v", result.DataFlows, expected)
+	}
+
+	if len(result.Edges) != 0 {
+		t.Errorf("Edges length = %v, want 0", len(result.Edges))
+	}
+}
+
+// shopDatabase returns a small schema where order_items references orders and
+// products, orders references users, and audit_log has no relationships.
+func shopDatabase() *database.Database {
+	fk := func(column, table string) database.Constraint {
+		return database.Constraint{
+			Kind:             database.ForeignKey,
+			Columns:          []string{column},
+			ReferenceTable:   table,
+			ReferenceColumns: []string{"id"},
+		}
+	}
+
+	return &database.Database{
+		Name: "shop",
+		Tables: []database.Table{
+			{Name: "users"},
+			{Name: "products"},
+			{Name: "orders", Constraints: []database.Constraint{fk("user_id", "users")}},
+			{Name: "order_items", Constraints: []database.Constraint{fk("order_id", "orders"), fk("product_id", "products")}},
+			{Name: "audit_log"},
+		},
+	}
+}
+
+func TestFilter(t *testing.T) {
+	g, err := Build(shopDatabase())
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		include       []string
+		exclude       []string
+		expectedNodes []TableName
+		expectedEdges int
+	}{
+		{
+			name:          "no patterns",
+			expectedNodes: []TableName{"audit_log", "order_items", "orders", "products", "users"},
+			expectedEdges: 3,
+		},
+		{
+			name:          "include glob",
+			include:       []string{"order*"},
+			expectedNodes: []TableName{"order_items", "orders"},
+			expectedEdges: 1,
+		},
+		{
+			name:          "exclude glob",
+			exclude:       []string{"*_log", "products"},
+			expectedNodes: []TableName{"order_items", "orders", "users"},
+			expectedEdges: 2,
+		},
+		{
+			name:          "include and exclude",
+			include:       []string{"order*", "users"},
+			exclude:       []string{"orders"},
+			expectedNodes: []TableName{"order_items", "users"},
+			expectedEdges: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Filter(g, tt.include, tt.exclude)
+			if err != nil {
+				t.Fatalf("Filter failed: %v", err)
+			}
+
+			if len(result.Nodes) != len(tt.expectedNodes) {
+				t.Errorf("Nodes length = %v, want %v", len(result.Nodes), len(tt.expectedNodes))
+			}
+			for _, name := range tt.expectedNodes {
+				if _, exists := result.Nodes[name]; !exists {
+					t.Errorf("Expected node %s not found", name)
+				}
+			}
+
+			if len(result.Edges) != tt.expectedEdges {
+				t.Errorf("Edges length = %v, want %v", len(result.Edges), tt.expectedEdges)
+			}
+		})
+	}
+
+	if _, err := Filter(g, []string{"[orders"}, nil); err == nil {
+		t.Error("Expected error for invalid pattern")
+	}
+}
+
+func TestShortestPath(t *testing.T) {
+	g, err := Build(shopDatabase())
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	path, err := ShortestPath(g, "users", "products")
+	if err != nil {
+		t.Fatalf("ShortestPath failed: %v", err)
+	}
+
+	expected := []struct {
+		from, to TableName
+		forward  bool
+	}{
+		{"users", "orders", false},
+		{"orders", "order_items", false},
+		{"order_items", "products", true},
+	}
+
+	if len(path) != len(expected) {
+		t.Fatalf("Path length = %v, want %v: %+v", len(path), len(expected), path)
+	}
+	for i, step := range path {
+		if step.From != expected[i].from || step.To != expected[i].to || step.Forward != expected[i].forward {
+			t.Errorf("Step %d = %s → %s (forward %v), want %s → %s (forward %v)", i,
+				step.From, step.To, step.Forward, expected[i].from, expected[i].to, expected[i].forward)
+		}
+		if step.Edge == nil {
+			t.Errorf("Step %d has no foreign key edge", i)
+		}
+	}
+
+	if path, err := ShortestPath(g, "orders", "orders"); err != nil || len(path) != 0 {
+		t.Errorf("Expected empty path to the same table, got %+v, %v", path, err)
+	}
+
+	if _, err := ShortestPath(g, "users", "audit_log"); err == nil {
+		t.Error("Expected error for unconnected tables")
+	}
+
+	if _, err := ShortestPath(g, "users", "missing"); err == nil {
+		t.Error("Expected error for unknown table")
+	}
+}
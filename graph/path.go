@@ -0,0 +1,76 @@
+package graph
+
+import "fmt"
+
+// PathStep is a relationship crossed by a path between two tables. Forward is
+// true when the step follows the direction of the relationship: from the
+// referencing table to the referenced one, or downstream along a data flow.
+type PathStep struct {
+	From    TableName
+	To      TableName
+	Forward bool
+	// Edge is set for foreign key steps and Flow for data flow steps.
+	Edge *ForeignKeyEdge
+	Flow *DataFlowEdge
+}
+
+// ShortestPath finds the fewest relationships connecting two tables, crossing
+// foreign keys and data flows in either direction.
+func ShortestPath(g *SchemaGraph, from, to TableName) ([]PathStep, error) {
+	if g == nil {
+		return nil, fmt.Errorf("graph is nil")
+	}
+
+	for _, name := range []TableName{from, to} {
+		if _, exists := g.Nodes[name]; !exists {
+			return nil, fmt.Errorf("table %s not found", name)
+		}
+	}
+
+	neighbours := make(map[TableName][]PathStep)
+	for i := range g.Edges {
+		edge := &g.Edges[i]
+		neighbours[edge.FromTable] = append(neighbours[edge.FromTable],
+			PathStep{From: edge.FromTable, To: edge.ToTable, Forward: true, Edge: edge})
+		neighbours[edge.ToTable] = append(neighbours[edge.ToTable],
+			PathStep{From: edge.ToTable, To: edge.FromTable, Edge: edge})
+	}
+	for i := range g.DataFlows {
+		flow := &g.DataFlows[i]
+		neighbours[flow.FromTable] = append(neighbours[flow.FromTable],
+			PathStep{From: flow.FromTable, To: flow.ToTable, Forward: true, Flow: flow})
+		neighbours[flow.ToTable] = append(neighbours[flow.ToTable],
+			PathStep{From: flow.ToTable, To: flow.FromTable, Flow: flow})
+	}
+
+	// Breadth-first search, remembering the step that first reached each table
+	reachedBy := make(map[TableName]PathStep)
+	visited := map[TableName]bool{from: true}
+	queue := []TableName{from}
+	for len(queue) > 0 && !visited[to] {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, step := range neighbours[current] {
+			if visited[step.To] {
+				continue
+			}
+			visited[step.To] = true
+			reachedBy[step.To] = step
+			queue = append(queue, step.To)
+		}
+	}
+
+	if !visited[to] {
+		return nil, fmt.Errorf("no relationship path from %s to %s", from, to)
+	}
+
+	var path []PathStep
+	for table := to; table != from; {
+		step := reachedBy[table]
+		path = append([]PathStep{step}, path...)
+		table = step.From
+	}
+
+	return path, nil
+}
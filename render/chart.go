@@ -0,0 +1,365 @@
+package render
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/viveknathani/dbtree/database"
+	"github.com/viveknathani/dbtree/graph"
+)
+
+// Charset is the set of characters the chart shape draws with.
+type Charset string
+
+const (
+	CharsetASCII   Charset = "ascii"
+	CharsetUnicode Charset = "unicode"
+)
+
+// Charsets lists the character sets the chart shape supports.
+var Charsets = []Charset{CharsetASCII, CharsetUnicode}
+
+// ANSI colours of the chart shape: primary keys in bold yellow, and foreign
+// keys and the lines they draw in cyan.
+const (
+	chartPrimaryKeyColor = "\033[1;33m"
+	chartForeignKeyColor = "\033[36m"
+	chartEdgeColor       = "\033[36m"
+	chartResetColor      = "\033[0m"
+)
+
+// chartGap is the space between charts wrapped side by side.
+const chartGap = "    "
+
+// chartWidth returns the width of the widest line of a chart, ignoring
+// trailing spaces.
+func chartWidth(chart string) int {
+	width := 0
+	for line := range strings.Lines(chart) {
+		width = max(width, utf8.RuneCountInString(strings.TrimRight(line, " \n")))
+	}
+	return width
+}
+
+// fitChart redraws a chart that is wider than opts.Width. Tables are first
+// laid out from top to bottom; when that is still too wide, each group of
+// connected tables is drawn on its own and the groups are wrapped like words
+// in a paragraph. The narrowest attempt is returned when nothing fits.
+func fitChart(g *graph.SchemaGraph, chart string, opts Options) (string, error) {
+	down, err := drawChart(g, "down", opts.Charset)
+	if err != nil {
+		return "", err
+	}
+	if chartWidth(down) <= opts.Width {
+		return down, nil
+	}
+	best := chart
+	if chartWidth(down) < chartWidth(chart) {
+		best = down
+	}
+
+	groups := connectedTables(g)
+	if len(groups) < 2 {
+		return best, nil
+	}
+
+	blocks := make([]string, len(groups))
+	for i, group := range groups {
+		sub := subgraph(g, group)
+		if blocks[i], err = drawChart(sub, "right", opts.Charset); err != nil {
+			return "", err
+		}
+		if chartWidth(blocks[i]) <= opts.Width {
+			continue
+		}
+		down, err := drawChart(sub, "down", opts.Charset)
+		if err != nil {
+			return "", err
+		}
+		if chartWidth(down) < chartWidth(blocks[i]) {
+			blocks[i] = down
+		}
+	}
+
+	wrapped := wrapCharts(blocks, opts.Width)
+	if chartWidth(wrapped) > chartWidth(best) {
+		return best, nil
+	}
+	return wrapped, nil
+}
+
+// connectedTables splits the tables of a graph into groups connected by
+// foreign keys or data flow, ordered by their first table name.
+func connectedTables(g *graph.SchemaGraph) [][]graph.TableName {
+	parent := make(map[graph.TableName]graph.TableName)
+	var find func(graph.TableName) graph.TableName
+	find = func(name graph.TableName) graph.TableName {
+		if parent[name] == name {
+			return name
+		}
+		parent[name] = find(parent[name])
+		return parent[name]
+	}
+	union := func(a, b graph.TableName) {
+		if _, ok := parent[a]; !ok {
+			return
+		}
+		if _, ok := parent[b]; !ok {
+			return
+		}
+		parent[find(a)] = find(b)
+	}
+
+	names := getSortedTableNames(g)
+	for _, name := range names {
+		parent[name] = name
+	}
+	for _, edge := range g.Edges {
+		union(edge.FromTable, edge.ToTable)
+	}
+	for _, flow := range g.DataFlows {
+		union(flow.FromTable, flow.ToTable)
+	}
+
+	var groups [][]graph.TableName
+	index := make(map[graph.TableName]int)
+	for _, name := range names {
+		root := find(name)
+		i, ok := index[root]
+		if !ok {
+			i = len(groups)
+			index[root] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], name)
+	}
+	return groups
+}
+
+// subgraph returns the part of a graph made of the given tables and the
+// edges between them.
+func subgraph(g *graph.SchemaGraph, tables []graph.TableName) *graph.SchemaGraph {
+	sub := &graph.SchemaGraph{
+		DatabaseName: g.DatabaseName,
+		Nodes:        make(map[graph.TableName]*database.Table, len(tables)),
+	}
+	for _, name := range tables {
+		sub.Nodes[name] = g.Nodes[name]
+	}
+	for _, edge := range g.Edges {
+		if sub.Nodes[edge.FromTable] != nil && sub.Nodes[edge.ToTable] != nil {
+			sub.Edges = append(sub.Edges, edge)
+		}
+	}
+	for _, flow := range g.DataFlows {
+		if sub.Nodes[flow.FromTable] != nil && sub.Nodes[flow.ToTable] != nil {
+			sub.DataFlows = append(sub.DataFlows, flow)
+		}
+	}
+	return sub
+}
+
+// wrapCharts places charts side by side while they fit in width, starting a
+// new row of charts below when they do not.
+func wrapCharts(charts []string, width int) string {
+	var rows []string
+	var row [][]string
+	var widths []int
+	rowWidth := 0
+
+	flush := func() {
+		if len(row) == 0 {
+			return
+		}
+		height := 0
+		for _, lines := range row {
+			height = max(height, len(lines))
+		}
+		var sb strings.Builder
+		for y := range height {
+			var line strings.Builder
+			for i, lines := range row {
+				if i > 0 {
+					line.WriteString(chartGap)
+				}
+				text := ""
+				if y < len(lines) {
+					text = strings.TrimRight(lines[y], " ")
+				}
+				line.WriteString(text)
+				line.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(text)))
+			}
+			sb.WriteString(strings.TrimRight(line.String(), " "))
+			sb.WriteString("\n")
+		}
+		rows = append(rows, sb.String())
+		row, widths, rowWidth = nil, nil, 0
+	}
+
+	for _, chart := range charts {
+		w := chartWidth(chart)
+		if len(row) > 0 && rowWidth+len(chartGap)+w > width {
+			flush()
+		}
+		if len(row) > 0 {
+			rowWidth += len(chartGap)
+		}
+		row = append(row, strings.Split(strings.TrimRight(chart, " \n"), "\n"))
+		widths = append(widths, w)
+		rowWidth += w
+	}
+	flush()
+
+	return strings.Join(rows, "\n")
+}
+
+// chartBox is the rectangle a table is drawn in.
+type chartBox struct {
+	top, bottom, left, right int
+}
+
+// colorChart colours the rows of primary and foreign key columns in the
+// table boxes of a chart, and the lines drawn outside the boxes.
+func colorChart(g *graph.SchemaGraph, chart string) string {
+	lines := strings.Split(chart, "\n")
+	grid := make([][]rune, len(lines))
+	colors := make([][]string, len(lines))
+	for y, line := range lines {
+		grid[y] = []rune(line)
+		colors[y] = make([]string, len(grid[y]))
+	}
+	at := func(y, x int) rune {
+		if y < 0 || y >= len(grid) || x < 0 || x >= len(grid[y]) {
+			return ' '
+		}
+		return grid[y][x]
+	}
+
+	var boxes []chartBox
+	for _, tableName := range getSortedTableNames(g) {
+		table := g.Nodes[tableName]
+		if table == nil {
+			continue
+		}
+		box, ok := findChartBox(grid, at, string(tableName), len(table.Columns))
+		if !ok {
+			continue
+		}
+		boxes = append(boxes, box)
+
+		for y := box.top + 1; y < box.bottom; y++ {
+			row := string(grid[y][box.left+1 : box.right])
+			text := strings.TrimSpace(row)
+			col := chartColumn(table, text)
+			if col == nil {
+				continue
+			}
+			keys := columnKeys(table, col.Name)
+			color := ""
+			switch {
+			case slices.Contains(keys, "PK"):
+				color = chartPrimaryKeyColor
+			case slices.Contains(keys, "FK"):
+				color = chartForeignKeyColor
+			default:
+				continue
+			}
+			start := box.left + 1 + utf8.RuneCountInString(row[:strings.Index(row, text)])
+			for x := start; x < start+utf8.RuneCountInString(text); x++ {
+				colors[y][x] = color
+			}
+		}
+	}
+
+	inBox := func(y, x int) bool {
+		for _, box := range boxes {
+			if y >= box.top && y <= box.bottom && x >= box.left && x <= box.right {
+				return true
+			}
+		}
+		return false
+	}
+
+	var sb strings.Builder
+	for y := range grid {
+		if y > 0 {
+			sb.WriteString("\n")
+		}
+		current := ""
+		for x, r := range grid[y] {
+			color := colors[y][x]
+			if color == "" && r != ' ' && !inBox(y, x) {
+				color = chartEdgeColor
+			}
+			if color != current {
+				if current != "" {
+					sb.WriteString(chartResetColor)
+				}
+				sb.WriteString(color)
+				current = color
+			}
+			sb.WriteRune(r)
+		}
+		if current != "" {
+			sb.WriteString(chartResetColor)
+		}
+	}
+	return sb.String()
+}
+
+// findChartBox finds the box of a table in a chart grid by its header, the
+// table name between two vertical lines below the top border.
+func findChartBox(grid [][]rune, at func(y, x int) rune, name string, columns int) (chartBox, bool) {
+	for y := range grid {
+		for x, r := range grid[y] {
+			if !isChartVertical(r) || !isChartCorner(at(y-1, x)) {
+				continue
+			}
+			right := -1
+			for x2 := x + 1; x2 < len(grid[y]); x2++ {
+				if isChartVertical(grid[y][x2]) {
+					right = x2
+					break
+				}
+			}
+			if right < 0 || strings.TrimSpace(string(grid[y][x+1:right])) != name {
+				continue
+			}
+
+			// The box ends at the last border line below the header: a title,
+			// a blank line, a separator, the columns and a blank line
+			bottom := y
+			for y2 := y + 1; y2 < len(grid) && y2 <= y+columns+4; y2++ {
+				if at(y2, x) == ' ' || at(y2, right) == ' ' {
+					break
+				}
+				bottom = y2
+			}
+			return chartBox{top: y - 1, bottom: bottom, left: x, right: right}, true
+		}
+	}
+	return chartBox{}, false
+}
+
+// chartColumn returns the column of a table drawn as a row of text, its name
+// followed by its type.
+func chartColumn(table *database.Table, text string) *database.Column {
+	for i, col := range table.Columns {
+		name, typ := col.Name, string(col.Type)
+		if strings.HasPrefix(text, name+" ") && strings.HasSuffix(text, " "+typ) && len(text) >= len(name)+len(typ)+2 {
+			return &table.Columns[i]
+		}
+	}
+	return nil
+}
+
+// isChartVertical reports whether r is a vertical line of a box.
+func isChartVertical(r rune) bool {
+	return r == '|' || r == '│'
+}
+
+// isChartCorner reports whether r is a corner or junction of box borders.
+func isChartCorner(r rune) bool {
+	return strings.ContainsRune("+┌┐└┘╭╮╰╯├┤┬┴┼", r)
+}
@@ -0,0 +1,274 @@
+package render
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/viveknathani/dbtree/database"
+)
+
+var (
+	// checkWrappedToken matches an identifier or number in parentheses, as
+	// in PostgreSQL's ((price > (0)::numeric)) once casts are removed.
+	checkWrappedToken = regexp.MustCompile(`(^|[^A-Za-z0-9_])\(\s*([A-Za-z_][A-Za-z0-9_]*|-?[0-9]+(?:\.[0-9]+)?)\s*\)`)
+	// checkBetween matches "x BETWEEN a AND b" with numeric bounds.
+	checkBetween = regexp.MustCompile(`(?i)([A-Za-z_][A-Za-z0-9_]*)\s+BETWEEN\s+(-?[0-9]+(?:\.[0-9]+)?)\s+AND\s+(-?[0-9]+(?:\.[0-9]+)?)`)
+	// checkComparison matches "x > 0" and the other comparisons with a number.
+	checkComparison = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)\s*(>=|<=|>|<)\s*(-?[0-9]+(?:\.[0-9]+)?)$`)
+	// checkReversedComparison matches "0 < x" and the like.
+	checkReversedComparison = regexp.MustCompile(`^(-?[0-9]+(?:\.[0-9]+)?)\s*(>=|<=|>|<)\s*([A-Za-z_][A-Za-z0-9_]*)$`)
+	// checkLength matches "length(x) <= 20" and its char_length spellings.
+	checkLength = regexp.MustCompile(`(?i)^(?:length|char_length|character_length|len)\(\s*([A-Za-z_][A-Za-z0-9_]*)\s*\)\s*(>=|<=|>|<)\s*([0-9]+)$`)
+	// checkNotEmpty matches "x <> ''".
+	checkNotEmpty = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)\s*(?:<>|!=)\s*''$`)
+	// checkIn matches "x IN (...)".
+	checkIn = regexp.MustCompile(`(?i)^([A-Za-z_][A-Za-z0-9_]*)\s+IN\s*\((.*)\)$`)
+	// checkConjunction matches the AND joining the parts of a check.
+	checkConjunction = regexp.MustCompile(`(?i)\s+AND\s+`)
+	// checkDisjunction matches an OR, which makes a check too complex to read.
+	checkDisjunction = regexp.MustCompile(`(?i)\bOR\b`)
+)
+
+// columnRules are the limits on the values of a column that its CHECK
+// constraints declare. Bounds are numbers as written in the check, and empty
+// when absent.
+type columnRules struct {
+	Minimum          string
+	ExclusiveMinimum string
+	Maximum          string
+	ExclusiveMaximum string
+	MinLength        int
+	MaxLength        int // zero means no limit
+	// Enum holds the allowed values, strings or json.Number.
+	Enum []any
+}
+
+// checkRules reads the CHECK constraints of a table for rules simple enough
+// to carry over to types: comparisons of a column with a number, BETWEEN,
+// length limits, non-empty strings and IN lists, joined by AND. Anything else
+// is ignored.
+func checkRules(table *database.Table) map[string]*columnRules {
+	columns := make(map[string]string, len(table.Columns))
+	for _, col := range table.Columns {
+		columns[strings.ToLower(col.Name)] = col.Name
+	}
+
+	rules := make(map[string]*columnRules)
+	rulesFor := func(name string) *columnRules {
+		column, ok := columns[strings.ToLower(name)]
+		if !ok {
+			return nil
+		}
+		if rules[column] == nil {
+			rules[column] = &columnRules{}
+		}
+		return rules[column]
+	}
+
+	for _, constraint := range table.Constraints {
+		if constraint.Kind != database.Check {
+			continue
+		}
+		expr := normalizeCheck(constraint.CheckExpression)
+		if checkDisjunction.MatchString(stripQuoted(expr)) {
+			continue
+		}
+		expr = checkBetween.ReplaceAllString(expr, "$1 >= $2 AND $1 <= $3")
+
+		for _, part := range splitConjunction(expr) {
+			part = unwrapParentheses(part)
+			if m := checkComparison.FindStringSubmatch(part); m != nil {
+				if r := rulesFor(m[1]); r != nil {
+					r.setBound(m[2], m[3])
+				}
+			} else if m := checkReversedComparison.FindStringSubmatch(part); m != nil {
+				if r := rulesFor(m[3]); r != nil {
+					r.setBound(flipComparison(m[2]), m[1])
+				}
+			} else if m := checkLength.FindStringSubmatch(part); m != nil {
+				if r := rulesFor(m[1]); r != nil {
+					r.setLength(m[2], m[3])
+				}
+			} else if m := checkNotEmpty.FindStringSubmatch(part); m != nil {
+				if r := rulesFor(m[1]); r != nil && r.MinLength < 1 {
+					r.MinLength = 1
+				}
+			} else if m := checkIn.FindStringSubmatch(part); m != nil {
+				if values := parseLiteralList(m[2]); values != nil {
+					if r := rulesFor(m[1]); r != nil {
+						r.Enum = values
+					}
+				}
+			}
+		}
+	}
+	return rules
+}
+
+// setBound records "column op value".
+func (r *columnRules) setBound(op, value string) {
+	switch op {
+	case ">=":
+		r.Minimum = value
+	case ">":
+		r.ExclusiveMinimum = value
+	case "<=":
+		r.Maximum = value
+	case "<":
+		r.ExclusiveMaximum = value
+	}
+}
+
+// setLength records "length(column) op n".
+func (r *columnRules) setLength(op, value string) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return
+	}
+	switch op {
+	case ">=":
+		r.MinLength = n
+	case ">":
+		r.MinLength = n + 1
+	case "<=":
+		r.MaxLength = n
+	case "<":
+		r.MaxLength = max(n-1, 0)
+	}
+}
+
+// flipComparison returns the operator of a comparison with its sides
+// swapped, so 0 < x becomes x > 0.
+func flipComparison(op string) string {
+	switch op {
+	case ">":
+		return "<"
+	case ">=":
+		return "<="
+	case "<":
+		return ">"
+	default:
+		return ">="
+	}
+}
+
+// normalizeCheck rewrites a check expression as the inspectors report it
+// into plain SQL: casts, identifier quotes and the parentheses PostgreSQL
+// adds around single values are removed, and "= ANY (ARRAY[...])" becomes
+// IN.
+func normalizeCheck(expr string) string {
+	expr = sqlExpression(expr, DialectSQLite)
+	expr = strings.NewReplacer("`", "", `"`, "").Replace(expr)
+	for {
+		unwrapped := checkWrappedToken.ReplaceAllString(expr, "$1$2")
+		if unwrapped == expr {
+			break
+		}
+		expr = unwrapped
+	}
+	return unwrapParentheses(strings.TrimSpace(expr))
+}
+
+// unwrapParentheses removes parentheses around the whole of expr.
+func unwrapParentheses(expr string) string {
+	for strings.HasPrefix(expr, "(") && closingParenthesis(expr) == len(expr)-1 {
+		expr = strings.TrimSpace(expr[1 : len(expr)-1])
+	}
+	return expr
+}
+
+// closingParenthesis returns the index of the parenthesis closing the one
+// expr starts with, or -1.
+func closingParenthesis(expr string) int {
+	depth := 0
+	inString := false
+	for i := 0; i < len(expr); i++ {
+		switch {
+		case expr[i] == '\'':
+			inString = !inString
+		case inString:
+		case expr[i] == '(':
+			depth++
+		case expr[i] == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitConjunction splits expr at the ANDs outside parentheses and strings.
+func splitConjunction(expr string) []string {
+	masked := stripQuoted(expr)
+	var parts []string
+	start, depth := 0, 0
+	for i := 0; i < len(masked); i++ {
+		switch masked[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		default:
+			if depth != 0 {
+				continue
+			}
+			if loc := checkConjunction.FindStringIndex(masked[i:]); loc != nil && loc[0] == 0 {
+				parts = append(parts, strings.TrimSpace(expr[start:i]))
+				start = i + loc[1]
+				i = start - 1
+			}
+		}
+	}
+	return append(parts, strings.TrimSpace(expr[start:]))
+}
+
+// stripQuoted replaces the contents of string literals with spaces, keeping
+// the positions of everything else.
+func stripQuoted(expr string) string {
+	b := []byte(expr)
+	inString := false
+	for i := range b {
+		if b[i] == '\'' {
+			inString = !inString
+		} else if inString {
+			b[i] = ' '
+		}
+	}
+	return string(b)
+}
+
+// parseLiteralList reads a comma-separated list of string and number
+// literals, returning nil when it holds anything else.
+func parseLiteralList(s string) []any {
+	var values []any
+	for _, item := range splitLiterals(s) {
+		item = strings.TrimSpace(item)
+		switch {
+		case sqlString.MatchString(item):
+			values = append(values, strings.ReplaceAll(item[1:len(item)-1], "''", "'"))
+		case sqlNumber.MatchString(item):
+			values = append(values, json.Number(item))
+		default:
+			return nil
+		}
+	}
+	return values
+}
+
+// splitLiterals splits s at the commas outside string literals.
+func splitLiterals(s string) []string {
+	masked := stripQuoted(s)
+	var items []string
+	start := 0
+	for i := 0; i < len(masked); i++ {
+		if masked[i] == ',' {
+			items = append(items, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(items, s[start:])
+}
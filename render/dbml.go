@@ -0,0 +1,312 @@
+package render
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/viveknathani/dbtree/database"
+	"github.com/viveknathani/dbtree/graph"
+)
+
+var (
+	// dbmlBareName matches names DBML accepts without quotes.
+	dbmlBareName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	// dbmlBareType matches types DBML accepts without quotes, such as
+	// decimal(10,2) or int[].
+	dbmlBareType = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\([0-9, ]*\))?(\[\])*$`)
+	// dbmlLiteral matches default values DBML accepts as written: numbers,
+	// booleans and null.
+	dbmlLiteral = regexp.MustCompile(`^(-?[0-9]+(\.[0-9]+)?|(?i:true|false|null))$`)
+	// sqlString matches a single quoted SQL string literal.
+	sqlString = regexp.MustCompile(`^'(?:[^']|'')*'$`)
+	// dbmlEnumType matches the types that name an enum, such as status or
+	// public.status.
+	dbmlEnumType = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*\.)?[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// dbmlEnum is an Enum block, written before the tables.
+type dbmlEnum struct {
+	name   string
+	values []string
+}
+
+// renderDBML writes the schema graph in DBML, the language of dbdiagram.io:
+// the enums, then tables with their columns, keys, defaults, indexes, checks
+// and notes, followed by a ref for each foreign key.
+func renderDBML(g *graph.SchemaGraph) string {
+	var sb strings.Builder
+	if g.DatabaseName != "" {
+		fmt.Fprintf(&sb, "Project %s {\n}\n", dbmlName(g.DatabaseName))
+	}
+
+	tableNames := getSortedTableNames(g)
+	enumTypes, enums := dbmlEnums(g, tableNames)
+	for _, enum := range enums {
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "Enum %s {\n", dbmlQualifiedName(enum.name))
+		for _, value := range enum.values {
+			fmt.Fprintf(&sb, "  %s\n", dbmlName(value))
+		}
+		sb.WriteString("}\n")
+	}
+
+	for _, tableName := range tableNames {
+		table := g.Nodes[tableName]
+		if table == nil {
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		writeDBMLTable(&sb, table, enumTypes)
+	}
+
+	if len(g.Edges) > 0 {
+		sb.WriteString("\n")
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&sb, "Ref: %s > %s\n",
+			dbmlEndpoint(edge.FromTable, edge.Columns), dbmlEndpoint(edge.ToTable, edge.ReferenceColumns))
+	}
+
+	return sb.String()
+}
+
+// dbmlEnums returns the Enum blocks of the enum columns with known values.
+// Columns keep the enum type they are declared with. PostgreSQL enums the
+// inspector reports as USER-DEFINED get a type named after their table and
+// column, which is returned keyed by table.column.
+func dbmlEnums(g *graph.SchemaGraph, tableNames []graph.TableName) (map[string]string, []dbmlEnum) {
+	types := make(map[string]string)
+	var enums []dbmlEnum
+	seen := make(map[string]bool)
+
+	for _, tableName := range tableNames {
+		table := g.Nodes[tableName]
+		if table == nil {
+			continue
+		}
+		for _, col := range table.Columns {
+			if len(col.EnumValues) == 0 {
+				continue
+			}
+			var name string
+			switch {
+			case strings.EqualFold(string(col.Type), "USER-DEFINED"):
+				name = table.Name + "_" + col.Name
+				types[table.Name+"."+col.Name] = name
+			case dbmlEnumType.MatchString(string(col.Type)):
+				name = strings.TrimPrefix(string(col.Type), "public.")
+			default:
+				continue // declared inline, such as MySQL's enum('a','b')
+			}
+			if !seen[name] {
+				seen[name] = true
+				enums = append(enums, dbmlEnum{name: name, values: col.EnumValues})
+			}
+		}
+	}
+	return types, enums
+}
+
+// writeDBMLTable writes the definition of a table. Single-column primary keys
+// and unique constraints are column settings, while composite ones are
+// written as indexes. Columns drawing from a sequence are incrementing, with
+// the default left out when it is the one PostgreSQL creates.
+func writeDBMLTable(sb *strings.Builder, table *database.Table, enumTypes map[string]string) {
+	var primaryKey []string
+	var uniqueKeys [][]string
+	var checks []string
+	for _, constraint := range table.Constraints {
+		switch constraint.Kind {
+		case database.PrimaryKey:
+			primaryKey = constraint.Columns
+		case database.Unique:
+			uniqueKeys = append(uniqueKeys, constraint.Columns)
+		case database.Check:
+			checks = append(checks, constraint.CheckExpression)
+		}
+	}
+
+	fmt.Fprintf(sb, "Table %s {\n", dbmlName(table.Name))
+	for _, col := range table.Columns {
+		var settings []string
+		isPrimaryKey := len(primaryKey) == 1 && primaryKey[0] == col.Name
+		if isPrimaryKey {
+			settings = append(settings, "pk")
+		}
+		defaultValue := col.DefaultValue
+		if database.IsSerialType(col.Type) || strings.HasPrefix(strings.ToLower(defaultValue), "nextval(") {
+			settings = append(settings, "increment")
+			if defaultValue == database.SequenceDefault(table.Name, col.Name) {
+				defaultValue = ""
+			}
+		}
+		if !col.IsNullable && !isPrimaryKey {
+			settings = append(settings, "not null")
+		}
+		for _, columns := range uniqueKeys {
+			if len(columns) == 1 && columns[0] == col.Name {
+				settings = append(settings, "unique")
+				break
+			}
+		}
+		if defaultValue != "" {
+			settings = append(settings, "default: "+dbmlDefault(defaultValue))
+		}
+		if col.Comment != "" {
+			settings = append(settings, "note: "+dbmlString(col.Comment))
+		}
+
+		colType := dbmlType(string(col.Type))
+		if name, ok := enumTypes[table.Name+"."+col.Name]; ok {
+			colType = dbmlQualifiedName(name)
+		}
+		fmt.Fprintf(sb, "  %s %s", dbmlName(col.Name), colType)
+		if len(settings) > 0 {
+			fmt.Fprintf(sb, " [%s]", strings.Join(settings, ", "))
+		}
+		sb.WriteString("\n")
+	}
+
+	var indexes []string
+	if len(primaryKey) > 1 {
+		indexes = append(indexes, dbmlIndexColumns(table, primaryKey)+" [pk]")
+	}
+	for _, columns := range uniqueKeys {
+		if len(columns) > 1 {
+			indexes = append(indexes, dbmlIndexColumns(table, columns)+" [unique]")
+		}
+	}
+	for _, index := range table.Indexes {
+		if len(index.Columns) == 0 {
+			continue
+		}
+		var settings []string
+		if index.Unique {
+			settings = append(settings, "unique")
+		}
+		if index.Name != "" {
+			settings = append(settings, "name: "+dbmlString(index.Name))
+		}
+		entry := dbmlIndexColumns(table, index.Columns)
+		if len(settings) > 0 {
+			entry += " [" + strings.Join(settings, ", ") + "]"
+		}
+		indexes = append(indexes, entry)
+	}
+
+	if len(indexes) > 0 {
+		sb.WriteString("\n  indexes {\n")
+		for _, entry := range indexes {
+			sb.WriteString("    ")
+			sb.WriteString(entry)
+			sb.WriteString("\n")
+		}
+		sb.WriteString("  }\n")
+	}
+
+	if len(checks) > 0 {
+		sb.WriteString("\n  checks {\n")
+		for _, check := range checks {
+			sb.WriteString("    ")
+			sb.WriteString(dbmlExpression(check))
+			sb.WriteString("\n")
+		}
+		sb.WriteString("  }\n")
+	}
+
+	if table.Comment != "" {
+		sb.WriteString("\n  Note: ")
+		sb.WriteString(dbmlString(table.Comment))
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("}\n")
+}
+
+// dbmlIndexColumns writes the columns of an index, with entries that are not
+// columns of the table written as expressions.
+func dbmlIndexColumns(table *database.Table, columns []string) string {
+	entries := make([]string, len(columns))
+	for i, column := range columns {
+		if slices.ContainsFunc(table.Columns, func(c database.Column) bool { return c.Name == column }) {
+			entries[i] = dbmlName(column)
+		} else {
+			entries[i] = dbmlExpression(column)
+		}
+	}
+	if len(entries) == 1 {
+		return entries[0]
+	}
+	return "(" + strings.Join(entries, ", ") + ")"
+}
+
+// dbmlEndpoint writes one side of a ref, table.column or
+// table.(column, column).
+func dbmlEndpoint(tableName graph.TableName, columns []string) string {
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = dbmlName(column)
+	}
+	if len(names) == 1 {
+		return dbmlName(string(tableName)) + "." + names[0]
+	}
+	return dbmlName(string(tableName)) + ".(" + strings.Join(names, ", ") + ")"
+}
+
+// dbmlName quotes a table or column name unless it is a plain identifier.
+func dbmlName(s string) string {
+	if dbmlBareName.MatchString(s) {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// dbmlQualifiedName writes a name with an optional schema, such as
+// sales.status.
+func dbmlQualifiedName(s string) string {
+	if schema, name, ok := strings.Cut(s, "."); ok {
+		return dbmlName(schema) + "." + dbmlName(name)
+	}
+	return dbmlName(s)
+}
+
+// dbmlType quotes a column type unless DBML can read it as written.
+func dbmlType(s string) string {
+	if dbmlBareType.MatchString(s) {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// dbmlString writes s as a DBML string, using a multi-line string when s
+// spans lines.
+func dbmlString(s string) string {
+	if strings.Contains(s, "\n") {
+		return "'''\n" + strings.NewReplacer(`\`, `\\`, "'''", `\'''`).Replace(s) + "\n'''"
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
+
+// dbmlExpression writes s as a DBML `expression`.
+func dbmlExpression(s string) string {
+	return "`" + strings.ReplaceAll(s, "`", "'") + "`"
+}
+
+// dbmlDefault writes a column default: numbers, booleans and null as they
+// are, SQL string literals as strings, and anything else as an expression.
+func dbmlDefault(s string) string {
+	switch {
+	case dbmlLiteral.MatchString(s):
+		return s
+	case sqlString.MatchString(s):
+		return dbmlString(strings.ReplaceAll(s[1:len(s)-1], "''", "'"))
+	default:
+		return dbmlExpression(s)
+	}
+}
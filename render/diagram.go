@@ -0,0 +1,348 @@
+package render
+
+import (
+	"fmt"
+	"html"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/viveknathani/dbtree/database"
+	"github.com/viveknathani/dbtree/graph"
+)
+
+// renderMermaid draws the schema graph as a Mermaid entity relationship
+// diagram. Names Mermaid cannot parse have their other characters replaced
+// with underscores.
+func renderMermaid(g *graph.SchemaGraph) string {
+	var sb strings.Builder
+	sb.WriteString("erDiagram\n")
+
+	for _, tableName := range getSortedTableNames(g) {
+		table := g.Nodes[tableName]
+		if table == nil {
+			continue
+		}
+
+		sb.WriteString("    ")
+		sb.WriteString(mermaidName(string(tableName), ""))
+		if len(table.Columns) == 0 {
+			sb.WriteString("\n")
+			continue
+		}
+
+		sb.WriteString(" {\n")
+		for _, col := range table.Columns {
+			sb.WriteString("        ")
+			sb.WriteString(mermaidName(string(col.Type), "()[]"))
+			sb.WriteString(" ")
+			sb.WriteString(mermaidName(col.Name, ""))
+			if keys := columnKeys(table, col.Name); len(keys) > 0 {
+				sb.WriteString(" ")
+				sb.WriteString(strings.Join(keys, ", "))
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString("    }\n")
+	}
+
+	// Referenced tables are drawn on the left of the tables referencing them,
+	// with crow's feet on the referencing side
+	for _, edge := range g.Edges {
+		parent, child := edgeCardinality(g, edge)
+		fmt.Fprintf(&sb, "    %s %s--%s %s : %s\n",
+			mermaidName(string(edge.ToTable), ""), parent, child,
+			mermaidName(string(edge.FromTable), ""), strconv.Quote(strings.Join(edge.Columns, ", ")))
+	}
+
+	// Data flow is drawn with a dashed line so it is not mistaken for a
+	// foreign key
+	for _, flow := range g.DataFlows {
+		fmt.Fprintf(&sb, "    %s ||..o{ %s : %s\n",
+			mermaidName(string(flow.FromTable), ""), mermaidName(string(flow.ToTable), ""),
+			strconv.Quote(dataFlowLabel(flow.Kind)))
+	}
+
+	return sb.String()
+}
+
+// edgeCardinality returns the crow's foot ends of a foreign key, as Mermaid
+// and PlantUML write them: the referenced row is optional when a referencing
+// column is nullable, and the referencing side holds at most one row when its
+// columns are unique.
+func edgeCardinality(g *graph.SchemaGraph, edge graph.ForeignKeyEdge) (parent, child string) {
+	parent, child = "||", "o{"
+	if from := g.Nodes[edge.FromTable]; from != nil {
+		if hasNullableColumn(from, edge.Columns) {
+			parent = "|o"
+		}
+		if hasUniqueColumns(from, edge.Columns) {
+			child = "o|"
+		}
+	}
+	return parent, child
+}
+
+// mermaidName replaces the characters of s that Mermaid does not accept in
+// names with underscores. Letters, digits, "_", "-" and the characters in
+// extra are kept.
+func mermaidName(s, extra string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-' || strings.ContainsRune(extra, r) {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// renderPlantUML draws the schema graph as a PlantUML entity relationship
+// diagram in Information Engineering notation. Mandatory columns are marked
+// with *, and primary key columns are listed above the line.
+func renderPlantUML(g *graph.SchemaGraph) string {
+	var sb strings.Builder
+	sb.WriteString("@startuml\n")
+	sb.WriteString("hide circle\n")
+	sb.WriteString("skinparam linetype ortho\n")
+
+	aliases := make(map[graph.TableName]string)
+	taken := make(map[string]bool)
+	for _, tableName := range getSortedTableNames(g) {
+		table := g.Nodes[tableName]
+		if table == nil {
+			continue
+		}
+		alias := plantUMLAlias(string(tableName))
+		for i := 2; taken[alias]; i++ {
+			alias = plantUMLAlias(string(tableName)) + "_" + strconv.Itoa(i)
+		}
+		taken[alias] = true
+		aliases[tableName] = alias
+
+		fmt.Fprintf(&sb, "\nentity %s as %s {\n", strconv.Quote(string(tableName)), alias)
+		var keyColumns, otherColumns []database.Column
+		for _, col := range table.Columns {
+			if slices.Contains(columnKeys(table, col.Name), "PK") {
+				keyColumns = append(keyColumns, col)
+			} else {
+				otherColumns = append(otherColumns, col)
+			}
+		}
+		for _, col := range keyColumns {
+			writePlantUMLColumn(&sb, table, col)
+		}
+		if len(keyColumns) > 0 {
+			sb.WriteString("  --\n")
+		}
+		for _, col := range otherColumns {
+			writePlantUMLColumn(&sb, table, col)
+		}
+		sb.WriteString("}\n")
+	}
+
+	if len(g.Edges) > 0 || len(g.DataFlows) > 0 {
+		sb.WriteString("\n")
+	}
+
+	// As in Mermaid, referenced tables are on the left, with crow's feet on
+	// the referencing side
+	for _, edge := range g.Edges {
+		parent, child := edgeCardinality(g, edge)
+		fmt.Fprintf(&sb, "%s %s--%s %s : %s\n",
+			aliases[edge.ToTable], parent, child, aliases[edge.FromTable], strings.Join(edge.Columns, ", "))
+	}
+
+	for _, flow := range g.DataFlows {
+		if aliases[flow.FromTable] == "" || aliases[flow.ToTable] == "" {
+			continue
+		}
+		fmt.Fprintf(&sb, "%s ||..o{ %s : %s\n",
+			aliases[flow.FromTable], aliases[flow.ToTable], dataFlowLabel(flow.Kind))
+	}
+
+	sb.WriteString("@enduml\n")
+	return sb.String()
+}
+
+// writePlantUMLColumn writes a column of an entity as
+// "* name : type <<PK>> <<FK>>", with * for columns that cannot be NULL.
+func writePlantUMLColumn(sb *strings.Builder, table *database.Table, col database.Column) {
+	sb.WriteString("  ")
+	if !col.IsNullable {
+		sb.WriteString("* ")
+	}
+	sb.WriteString(col.Name)
+	if col.Type != "" {
+		sb.WriteString(" : ")
+		sb.WriteString(string(col.Type))
+	}
+	for _, key := range columnKeys(table, col.Name) {
+		sb.WriteString(" <<" + key + ">>")
+	}
+	sb.WriteString("\n")
+}
+
+// plantUMLAlias turns a table name into a PlantUML alias, replacing the
+// characters PlantUML does not accept with underscores.
+func plantUMLAlias(s string) string {
+	alias := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, s)
+	if alias == "" || alias[0] >= '0' && alias[0] <= '9' {
+		alias = "_" + alias
+	}
+	return alias
+}
+
+// renderDOT draws the schema graph in the Graphviz DOT language, with each
+// table as an HTML-like label whose rows are ports that foreign keys connect.
+func renderDOT(g *graph.SchemaGraph) string {
+	var sb strings.Builder
+	sb.WriteString("digraph ")
+	sb.WriteString(dotQuote(g.DatabaseName))
+	sb.WriteString(" {\n")
+	sb.WriteString("  rankdir=LR;\n")
+	sb.WriteString("  node [shape=plaintext, fontname=\"Helvetica\"];\n")
+	sb.WriteString("  edge [fontname=\"Helvetica\", fontsize=10];\n")
+
+	for _, tableName := range getSortedTableNames(g) {
+		table := g.Nodes[tableName]
+		if table == nil {
+			continue
+		}
+
+		sb.WriteString("\n  ")
+		sb.WriteString(dotQuote(string(tableName)))
+		sb.WriteString(" [label=<\n")
+		sb.WriteString("    <table border=\"0\" cellborder=\"1\" cellspacing=\"0\" cellpadding=\"4\">\n")
+		sb.WriteString("      <tr><td bgcolor=\"lightgrey\"><b>")
+		sb.WriteString(html.EscapeString(string(tableName)))
+		sb.WriteString("</b></td></tr>\n")
+
+		for i, col := range table.Columns {
+			fmt.Fprintf(&sb, "      <tr><td port=\"c%d\" align=\"left\">%s <i>%s</i>",
+				i, html.EscapeString(col.Name), html.EscapeString(string(col.Type)))
+			if keys := columnKeys(table, col.Name); len(keys) > 0 {
+				sb.WriteString(" ")
+				sb.WriteString(strings.Join(keys, ", "))
+			}
+			sb.WriteString("</td></tr>\n")
+		}
+
+		sb.WriteString("    </table>>];\n")
+	}
+
+	if len(g.Edges) > 0 || len(g.DataFlows) > 0 {
+		sb.WriteString("\n")
+	}
+
+	for _, edge := range g.Edges {
+		for i, col := range edge.Columns {
+			if i >= len(edge.ReferenceColumns) {
+				break
+			}
+			sb.WriteString("  ")
+			sb.WriteString(dotPort(g.Nodes[edge.FromTable], edge.FromTable, col))
+			sb.WriteString(" -> ")
+			sb.WriteString(dotPort(g.Nodes[edge.ToTable], edge.ToTable, edge.ReferenceColumns[i]))
+			sb.WriteString(";\n")
+		}
+	}
+
+	for _, flow := range g.DataFlows {
+		fmt.Fprintf(&sb, "  %s -> %s [style=dashed, label=%s];\n",
+			dotQuote(string(flow.FromTable)), dotQuote(string(flow.ToTable)), dotQuote(dataFlowLabel(flow.Kind)))
+	}
+
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// dotPort refers to the row of a column in the node of a table, or to the
+// whole node when the column is unknown.
+func dotPort(table *database.Table, tableName graph.TableName, column string) string {
+	if table != nil {
+		for i, col := range table.Columns {
+			if col.Name == column {
+				return fmt.Sprintf("%s:c%d", dotQuote(string(tableName)), i)
+			}
+		}
+	}
+	return dotQuote(string(tableName))
+}
+
+// dotQuote quotes a DOT identifier.
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// columnKeys returns the key markers of a column: PK, FK and UK for primary
+// key, foreign key and single-column unique constraints.
+func columnKeys(table *database.Table, column string) []string {
+	var pk, fk, uk bool
+	for _, constraint := range table.Constraints {
+		if !slices.Contains(constraint.Columns, column) {
+			continue
+		}
+		switch constraint.Kind {
+		case database.PrimaryKey:
+			pk = true
+		case database.ForeignKey:
+			fk = true
+		case database.Unique:
+			uk = uk || len(constraint.Columns) == 1
+		}
+	}
+
+	var keys []string
+	if pk {
+		keys = append(keys, "PK")
+	}
+	if fk {
+		keys = append(keys, "FK")
+	}
+	if uk {
+		keys = append(keys, "UK")
+	}
+	return keys
+}
+
+// hasNullableColumn reports whether any of the named columns of a table is nullable.
+func hasNullableColumn(table *database.Table, columns []string) bool {
+	for _, col := range table.Columns {
+		if col.IsNullable && slices.Contains(columns, col.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasUniqueColumns reports whether a primary key or unique constraint of a
+// table covers exactly the named columns, in which case each referenced row
+// is referenced at most once.
+func hasUniqueColumns(table *database.Table, columns []string) bool {
+	for _, constraint := range table.Constraints {
+		if constraint.Kind != database.PrimaryKey && constraint.Kind != database.Unique {
+			continue
+		}
+		if len(constraint.Columns) != len(columns) {
+			continue
+		}
+		covered := true
+		for _, col := range columns {
+			if !slices.Contains(constraint.Columns, col) {
+				covered = false
+				break
+			}
+		}
+		if covered {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,428 @@
+package render
+
+import (
+	"fmt"
+	"go/format"
+	"go/token"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/viveknathani/dbtree/database"
+	"github.com/viveknathani/dbtree/graph"
+)
+
+// NamingStyle is the case of the json tags written by the go format.
+type NamingStyle string
+
+// NullStyle is how the go format writes the types of nullable columns.
+type NullStyle string
+
+const (
+	NamingSnake  NamingStyle = "snake"
+	NamingCamel  NamingStyle = "camel"
+	NamingPascal NamingStyle = "pascal"
+
+	NullPointer NullStyle = "pointer"
+	NullSQL     NullStyle = "sql"
+)
+
+// NamingStyles lists the json tag styles the go format supports.
+var NamingStyles = []NamingStyle{NamingSnake, NamingCamel, NamingPascal}
+
+// NullStyles lists the nullable column styles the go format supports.
+var NullStyles = []NullStyle{NullPointer, NullSQL}
+
+// GoOptions holds the settings of the go format. The zero value writes
+// package models with snake_case json tags and pointers for nullable columns.
+type GoOptions struct {
+	// Package is the name in the package clause.
+	Package string
+	// Naming is the case of the json tags. The db tags always hold the
+	// column names.
+	Naming NamingStyle
+	// Nullable chooses between pointers and the database/sql Null types for
+	// nullable columns.
+	Nullable NullStyle
+}
+
+// goInitialisms lists the words Go names write in upper case, as in UserID.
+var goInitialisms = map[string]bool{
+	"ACL": true, "API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true,
+	"EOF": true, "GUID": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true,
+	"IP": true, "JSON": true, "QPS": true, "RAM": true, "RPC": true, "SLA": true,
+	"SMTP": true, "SQL": true, "SSH": true, "TCP": true, "TLS": true, "TTL": true,
+	"UDP": true, "UI": true, "UID": true, "UUID": true, "URI": true, "URL": true,
+	"UTF8": true, "VM": true, "XML": true, "XMPP": true, "XSRF": true, "XSS": true,
+}
+
+// goNullTypes maps Go types to their database/sql Null types. Other types
+// use the generic sql.Null.
+var goNullTypes = map[string]string{
+	"string":    "sql.NullString",
+	"int64":     "sql.NullInt64",
+	"int32":     "sql.NullInt32",
+	"int16":     "sql.NullInt16",
+	"uint8":     "sql.NullByte",
+	"float64":   "sql.NullFloat64",
+	"bool":      "sql.NullBool",
+	"time.Time": "sql.NullTime",
+}
+
+// goImports maps the qualifiers of generated types to their packages.
+var goImports = map[string]string{
+	"big.":  "math/big",
+	"json.": "encoding/json",
+	"sql.":  "database/sql",
+	"time.": "time",
+}
+
+// goField is a struct field generated for a column.
+type goField struct {
+	name     string
+	typ      string
+	tag      string
+	comments []string
+	refs     []string
+}
+
+// renderGo writes a Go struct for each table, with db and json tags and
+// comments on the foreign keys between tables.
+func renderGo(g *graph.SchemaGraph, opts GoOptions) (string, error) {
+	if opts.Package == "" {
+		opts.Package = "models"
+	}
+	if opts.Naming == "" {
+		opts.Naming = NamingSnake
+	}
+	if opts.Nullable == "" {
+		opts.Nullable = NullPointer
+	}
+	if !token.IsIdentifier(opts.Package) {
+		return "", fmt.Errorf("invalid go package name: %s", opts.Package)
+	}
+	if !slices.Contains(NamingStyles, opts.Naming) {
+		return "", fmt.Errorf("unsupported naming style: %s", opts.Naming)
+	}
+	if !slices.Contains(NullStyles, opts.Nullable) {
+		return "", fmt.Errorf("unsupported nullable style: %s", opts.Nullable)
+	}
+
+	var body strings.Builder
+	imports := make(map[string]bool)
+	structNames := make(map[string]bool)
+	for _, tableName := range getSortedTableNames(g) {
+		table := g.Nodes[tableName]
+		if table == nil {
+			continue
+		}
+		name := uniqueGoName(goIdentifier(string(tableName)), structNames)
+		fields := goFields(g, tableName, table, opts)
+		for _, field := range fields {
+			for qualifier, path := range goImports {
+				if strings.Contains(field.typ, qualifier) {
+					imports[path] = true
+				}
+			}
+		}
+		body.WriteString("\n")
+		writeGoStruct(&body, g, tableName, table, name, fields)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("// Code generated by dbtree. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&sb, "package %s\n", opts.Package)
+	if len(imports) > 0 {
+		sb.WriteString("\nimport (\n")
+		paths := make([]string, 0, len(imports))
+		for path := range imports {
+			paths = append(paths, path)
+		}
+		slices.Sort(paths)
+		for _, path := range paths {
+			fmt.Fprintf(&sb, "\t%q\n", path)
+		}
+		sb.WriteString(")\n")
+	}
+	sb.WriteString(body.String())
+
+	source, err := format.Source([]byte(sb.String()))
+	if err != nil {
+		return "", fmt.Errorf("failed to format go code: %w", err)
+	}
+	return string(source), nil
+}
+
+// goFields returns the fields of a table's struct, with the foreign keys
+// each column takes part in.
+func goFields(g *graph.SchemaGraph, tableName graph.TableName, table *database.Table, opts GoOptions) []goField {
+	refs := make(map[string][]string)
+	for _, edge := range g.Edges {
+		if edge.FromTable != tableName {
+			continue
+		}
+		for i, column := range edge.Columns {
+			if i >= len(edge.ReferenceColumns) {
+				break
+			}
+			ref := "references " + string(edge.ToTable) + "." + edge.ReferenceColumns[i]
+			if len(edge.Columns) > 1 {
+				ref += " as part of (" + strings.Join(edge.Columns, ", ") + ")"
+			}
+			refs[column] = append(refs[column], ref)
+		}
+	}
+
+	rules := checkRules(table)
+	names := make(map[string]bool)
+	fields := make([]goField, len(table.Columns))
+	for i, col := range table.Columns {
+		kind := parseSQLType(string(col.Type))
+		if kind.kind == "other" && isStringEnum(col, rules[col.Name]) {
+			// Enums named by their own type, such as in DBML, hold strings
+			kind.kind = "enum"
+		}
+		tag := "db:" + strconv.Quote(col.Name) + " json:" + strconv.Quote(jsonName(col.Name, opts.Naming))
+		fields[i] = goField{
+			name: uniqueGoName(goIdentifier(col.Name), names),
+			typ:  goType(kind, col.IsNullable, opts.Nullable),
+			tag:  tag,
+			refs: refs[col.Name],
+		}
+		if col.Comment != "" {
+			fields[i].comments = strings.Split(strings.TrimSpace(col.Comment), "\n")
+		}
+	}
+	return fields
+}
+
+// isStringEnum reports whether a column only holds strings from a known list,
+// its enum values or those of a CHECK ... IN check.
+func isStringEnum(col database.Column, rules *columnRules) bool {
+	if len(col.EnumValues) > 0 {
+		return true
+	}
+	if rules == nil || len(rules.Enum) == 0 {
+		return false
+	}
+	for _, value := range rules.Enum {
+		if _, ok := value.(string); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// writeGoStruct writes the struct of a table, documented with the table
+// comment and the tables referencing it.
+func writeGoStruct(sb *strings.Builder, g *graph.SchemaGraph, tableName graph.TableName, table *database.Table, name string, fields []goField) {
+	fmt.Fprintf(sb, "// %s is a row of the %s table.\n", name, tableName)
+	// The table comment continues the first paragraph, so gofmt does not
+	// take a short comment for a heading
+	if table.Comment != "" {
+		for _, line := range strings.Split(strings.TrimSpace(table.Comment), "\n") {
+			writeGoComment(sb, "", line)
+		}
+	}
+
+	var referencedBy []string
+	for _, edge := range g.Edges {
+		if edge.ToTable == tableName {
+			referencedBy = append(referencedBy, qualifiedColumns(edge.FromTable, edge.Columns))
+		}
+	}
+	if len(referencedBy) > 0 {
+		sb.WriteString("//\n// Referenced by:\n")
+		for _, ref := range referencedBy {
+			writeGoComment(sb, "", "  - "+ref)
+		}
+	}
+
+	fmt.Fprintf(sb, "type %s struct {\n", name)
+	for _, field := range fields {
+		for _, line := range field.comments {
+			writeGoComment(sb, "\t", line)
+		}
+		tag := "`" + field.tag + "`"
+		if strings.Contains(field.tag, "`") {
+			tag = strconv.Quote(field.tag)
+		}
+		fmt.Fprintf(sb, "\t%s %s %s", field.name, field.typ, tag)
+		if len(field.refs) > 0 {
+			sb.WriteString(" // " + goCommentText(strings.Join(field.refs, "; ")))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("}\n")
+}
+
+// writeGoComment writes a line comment, with nothing after the slashes for
+// a blank line.
+func writeGoComment(sb *strings.Builder, indent, line string) {
+	line = goCommentText(strings.TrimRight(line, " \t\r"))
+	if line == "" {
+		sb.WriteString(indent + "//\n")
+		return
+	}
+	sb.WriteString(indent + "// " + line + "\n")
+}
+
+// goCommentText keeps s from ending a line comment early.
+func goCommentText(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
+
+// goType maps a column type to a Go type. Slices, json.RawMessage and any
+// already hold NULL, so nullable columns only change the other types.
+func goType(t sqlType, nullable bool, style NullStyle) string {
+	typ := goBaseType(t)
+	if !nullable || strings.HasPrefix(typ, "[]") || strings.HasPrefix(typ, "*") || typ == "json.RawMessage" || typ == "any" {
+		return typ
+	}
+	if style == NullSQL {
+		if nullType, ok := goNullTypes[typ]; ok {
+			return nullType
+		}
+		return "sql.Null[" + typ + "]"
+	}
+	return "*" + typ
+}
+
+// goBaseType maps a column type to the Go type of its non-NULL values.
+func goBaseType(t sqlType) string {
+	unsigned := ""
+	if t.unsigned {
+		unsigned = "u"
+	}
+
+	switch t.kind {
+	case "smallint", "integer", "bigint", "serial":
+		// ClickHouse names its integers by size, such as UInt8 or Int128
+		if m := clickhouseInteger.FindStringSubmatch(t.original); m != nil {
+			if m[1] == "128" || m[1] == "256" {
+				return "*big.Int"
+			}
+			return strings.ToLower(strings.TrimSuffix(t.original, m[1])) + m[1]
+		}
+		switch {
+		case t.base == "tinyint":
+			return unsigned + "int8"
+		case t.kind == "smallint" || t.base == "smallserial" || t.base == "serial2":
+			return unsigned + "int16"
+		case t.kind == "integer" || t.base == "serial" || t.base == "serial4":
+			return unsigned + "int32"
+		}
+		return unsigned + "int64"
+	case "real":
+		return "float32"
+	case "double":
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "decimal", "varchar", "char", "text", "enum", "uuid", "time":
+		// Decimals are kept as strings to keep their precision
+		return "string"
+	case "date", "timestamp", "timestamptz":
+		return "time.Time"
+	case "json", "jsonb":
+		return "json.RawMessage"
+	case "binary":
+		return "[]byte"
+	case "array":
+		return "[]" + goBaseType(*t.array)
+	}
+	return "any"
+}
+
+// goIdentifier turns a table or column name into an exported Go name, such
+// as UserID for user_id.
+func goIdentifier(s string) string {
+	var sb strings.Builder
+	for _, word := range splitWords(s) {
+		upper := strings.ToUpper(word)
+		if goInitialisms[upper] {
+			sb.WriteString(upper)
+			continue
+		}
+		sb.WriteString(capitalize(word))
+	}
+	name := sb.String()
+	if name == "" || !unicode.IsLetter([]rune(name)[0]) {
+		name = "X" + name
+	}
+	return name
+}
+
+// uniqueGoName returns name, with a numeric suffix when it is already taken,
+// and marks the result as taken.
+func uniqueGoName(name string, taken map[string]bool) string {
+	unique := name
+	for i := 2; taken[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	taken[unique] = true
+	return unique
+}
+
+// jsonName writes a column name in a naming style.
+func jsonName(s string, style NamingStyle) string {
+	words := splitWords(s)
+	if len(words) == 0 {
+		return s
+	}
+	for i, word := range words {
+		words[i] = strings.ToLower(word)
+	}
+	switch style {
+	case NamingCamel:
+		for i := 1; i < len(words); i++ {
+			words[i] = capitalize(words[i])
+		}
+		return strings.Join(words, "")
+	case NamingPascal:
+		for i := range words {
+			words[i] = capitalize(words[i])
+		}
+		return strings.Join(words, "")
+	default:
+		return strings.Join(words, "_")
+	}
+}
+
+// splitWords splits a name into words at punctuation and case changes, so
+// user_id, userId and UserID all give user and id.
+func splitWords(s string) []string {
+	var words []string
+	var word []rune
+	runes := []rune(s)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(word) > 0 {
+				words = append(words, string(word))
+				word = nil
+			}
+			continue
+		}
+		if len(word) > 0 && unicode.IsUpper(r) {
+			prev := word[len(word)-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if !unicode.IsUpper(prev) || nextLower {
+				words = append(words, string(word))
+				word = nil
+			}
+		}
+		word = append(word, r)
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return words
+}
+
+// capitalize upper-cases the first letter of a word and lower-cases the rest.
+func capitalize(word string) string {
+	runes := []rune(strings.ToLower(word))
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
@@ -0,0 +1,103 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/viveknathani/dbtree/database"
+)
+
+// RenderIntegrity generates a string representation of foreign key integrity
+// check results in the specified format.
+func RenderIntegrity(results []database.IntegrityResult, format Format) (string, error) {
+	switch format {
+	case FormatText:
+		return renderIntegrityAsText(results), nil
+	case FormatJSON:
+		return renderIntegrityAsJSON(results)
+	default:
+		return "", fmt.Errorf("unsupported integrity format: %s", format)
+	}
+}
+
+func renderIntegrityAsText(results []database.IntegrityResult) string {
+	var sb strings.Builder
+
+	if len(results) == 0 {
+		sb.WriteString("No relationships to check.\n")
+		return sb.String()
+	}
+
+	violations := 0
+	for _, result := range results {
+		check := result.Check
+		sb.WriteString(fmt.Sprintf("%s(%s) → %s(%s): ",
+			check.Table, strings.Join(check.Columns, ", "),
+			check.ReferenceTable, strings.Join(check.ReferenceColumns, ", ")))
+
+		scanned := fmt.Sprintf("%d rows scanned", result.ScannedRows)
+		if result.Capped {
+			scanned = fmt.Sprintf("first %d rows scanned", result.ScannedRows)
+		}
+
+		if result.OrphanCount == 0 {
+			sb.WriteString(fmt.Sprintf("OK (%s)\n", scanned))
+			continue
+		}
+
+		violations++
+		sb.WriteString(fmt.Sprintf("%d orphaned rows (%s)\n", result.OrphanCount, scanned))
+
+		if len(result.SampleKeys) > 0 {
+			keys := make([]string, len(result.SampleKeys))
+			for i, key := range result.SampleKeys {
+				keys[i] = "(" + strings.Join(key, ", ") + ")"
+			}
+			sb.WriteString("  e.g. ")
+			sb.WriteString(strings.Join(keys, ", "))
+			sb.WriteString("\n")
+		}
+	}
+
+	sb.WriteString(fmt.Sprintf("\n%d of %d relationships have orphaned rows\n", violations, len(results)))
+	return sb.String()
+}
+
+func renderIntegrityAsJSON(results []database.IntegrityResult) (string, error) {
+	type Relationship struct {
+		Table            string     `json:"table"`
+		Columns          []string   `json:"columns"`
+		ReferenceTable   string     `json:"referenceTable"`
+		ReferenceColumns []string   `json:"referenceColumns"`
+		ScannedRows      int64      `json:"scannedRows"`
+		Capped           bool       `json:"capped"`
+		OrphanCount      int64      `json:"orphanCount"`
+		SampleKeys       [][]string `json:"sampleKeys,omitempty"`
+	}
+
+	type Result struct {
+		Relationships []Relationship `json:"relationships"`
+	}
+
+	result := Result{Relationships: []Relationship{}}
+	for _, r := range results {
+		result.Relationships = append(result.Relationships, Relationship{
+			Table:            r.Check.Table,
+			Columns:          r.Check.Columns,
+			ReferenceTable:   r.Check.ReferenceTable,
+			ReferenceColumns: r.Check.ReferenceColumns,
+			ScannedRows:      r.ScannedRows,
+			Capped:           r.Capped,
+			OrphanCount:      r.OrphanCount,
+			SampleKeys:       r.SampleKeys,
+		})
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+
+	return string(data), nil
+}
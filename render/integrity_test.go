@@ -0,0 +1,76 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/viveknathani/dbtree/database"
+)
+
+func TestRenderIntegrity(t *testing.T) {
+	results := []database.IntegrityResult{
+		{
+			Check: database.ForeignKeyCheck{
+				Table:            "orders",
+				Columns:          []string{"user_id"},
+				ReferenceTable:   "users",
+				ReferenceColumns: []string{"id"},
+			},
+			ScannedRows: 100,
+			Capped:      true,
+			OrphanCount: 2,
+			SampleKeys:  [][]string{{"42"}, {"57"}},
+		},
+		{
+			Check: database.ForeignKeyCheck{
+				Table:            "order_items",
+				Columns:          []string{"order_id"},
+				ReferenceTable:   "orders",
+				ReferenceColumns: []string{"id"},
+			},
+			ScannedRows: 10,
+		},
+	}
+
+	tests := []struct {
+		name     string
+		format   Format
+		contains []string
+	}{
+		{
+			name:   "text",
+			format: FormatText,
+			contains: []string{
+				"orders(user_id) → users(id): 2 orphaned rows (first 100 rows scanned)",
+				"e.g. (42), (57)",
+				"order_items(order_id) → orders(id): OK (10 rows scanned)",
+				"1 of 2 relationships have orphaned rows",
+			},
+		},
+		{
+			name:   "json",
+			format: FormatJSON,
+			contains: []string{
+				`"table": "orders"`,
+				`"orphanCount": 2`,
+				`"capped": true`,
+				`"sampleKeys"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderIntegrity(results, tt.format)
+			if err != nil {
+				t.Fatalf("RenderIntegrity() error = %v", err)
+			}
+
+			for _, want := range tt.contains {
+				if !strings.Contains(got, want) {
+					t.Errorf("RenderIntegrity() output missing %q\nGot:\n%s", want, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/viveknathani/dbtree/graph"
+)
+
+// jsonSchemaDialect is the JSON Schema version the jsonschema format writes.
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// jsonObject is a JSON object that keeps its members in order, so schemas
+// list columns as the table does.
+type jsonObject []jsonMember
+
+type jsonMember struct {
+	key   string
+	value any
+}
+
+// MarshalJSON writes the members in order.
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	for i, member := range o {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		key, err := marshalJSON(member.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := marshalJSON(member.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteString(":")
+		buf.Write(value)
+	}
+	buf.WriteString("}")
+	return buf.Bytes(), nil
+}
+
+// get returns the value of a member, or nil.
+func (o jsonObject) get(key string) any {
+	for _, member := range o {
+		if member.key == key {
+			return member.value
+		}
+	}
+	return nil
+}
+
+// set replaces the value of a member, or adds it.
+func (o *jsonObject) set(key string, value any) {
+	for i := range *o {
+		if (*o)[i].key == key {
+			(*o)[i].value = value
+			return
+		}
+	}
+	*o = append(*o, jsonMember{key, value})
+}
+
+// marshalJSON encodes v without escaping HTML characters, which appear in
+// descriptions of checks such as "x > 0".
+func marshalJSON(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// renderJSONSchema writes a JSON Schema with a definition for each table,
+// describing its rows as an API returns them. Foreign keys are $refs to the
+// column they reference, and enums and check limits become validation
+// keywords.
+func renderJSONSchema(g *graph.SchemaGraph) (string, error) {
+	defs := jsonObject{}
+	for _, tableName := range getSortedTableNames(g) {
+		table := g.Nodes[tableName]
+		if table == nil {
+			continue
+		}
+
+		properties := jsonObject{}
+		required := []string{}
+		for _, col := range typedColumns(g, tableName, table) {
+			properties = append(properties, jsonMember{col.Name, jsonSchemaColumn(col)})
+			required = append(required, col.Name)
+		}
+
+		def := jsonObject{{"type", "object"}}
+		if table.Comment != "" {
+			def = append(def, jsonMember{"description", strings.TrimSpace(table.Comment)})
+		}
+		def = append(def,
+			jsonMember{"properties", properties},
+			jsonMember{"required", required},
+			jsonMember{"additionalProperties", false},
+		)
+		defs = append(defs, jsonMember{string(tableName), def})
+	}
+
+	schema := jsonObject{{"$schema", jsonSchemaDialect}}
+	if g.DatabaseName != "" {
+		schema = append(schema, jsonMember{"title", g.DatabaseName})
+	}
+	schema = append(schema, jsonMember{"$defs", defs})
+
+	data, err := marshalJSON(schema)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// jsonSchemaColumn returns the schema of a column's values.
+func jsonSchemaColumn(col typedColumn) jsonObject {
+	var schema jsonObject
+	if col.refTable != "" {
+		schema = jsonObject{{"$ref", jsonSchemaRef(col.refTable, col.refColumn)}}
+	} else {
+		schema = jsonSchemaType(col.sqlType)
+		if len(col.rules.Enum) > 0 && col.sqlType.kind != "array" {
+			schema.set("enum", append([]any{}, col.rules.Enum...))
+		}
+		for _, keyword := range []struct{ name, value string }{
+			{"minimum", col.rules.Minimum},
+			{"exclusiveMinimum", col.rules.ExclusiveMinimum},
+			{"maximum", col.rules.Maximum},
+			{"exclusiveMaximum", col.rules.ExclusiveMaximum},
+		} {
+			if keyword.value != "" {
+				schema.set(keyword.name, json.Number(keyword.value))
+			}
+		}
+		if col.rules.MinLength > 0 {
+			schema.set("minLength", col.rules.MinLength)
+		}
+		if col.rules.MaxLength > 0 {
+			schema.set("maxLength", col.rules.MaxLength)
+		}
+	}
+
+	if col.IsNullable {
+		schema = jsonSchemaNullable(schema)
+	}
+
+	var description []string
+	if col.Comment != "" {
+		description = append(description, strings.TrimSpace(col.Comment))
+	}
+	for _, ref := range col.references {
+		description = append(description, "References "+ref+".")
+	}
+	if len(description) > 0 {
+		schema = append(schema, jsonMember{"description", strings.Join(description, "\n")})
+	}
+	return schema
+}
+
+// jsonSchemaNullable extends a schema to accept null: a "type" gains null
+// as an alternative, along with the enum values if any, and other schemas
+// are wrapped in anyOf. A schema accepting anything is left as is.
+func jsonSchemaNullable(schema jsonObject) jsonObject {
+	if len(schema) == 0 {
+		return schema
+	}
+	if typ, ok := schema.get("type").(string); ok {
+		schema.set("type", []string{typ, "null"})
+		if enum, ok := schema.get("enum").([]any); ok {
+			schema.set("enum", append(enum, nil))
+		}
+		return schema
+	}
+	return jsonObject{{"anyOf", []any{schema, jsonObject{{"type", "null"}}}}}
+}
+
+// jsonSchemaRef returns the $ref of a table column's schema, a JSON pointer
+// in a URI fragment.
+func jsonSchemaRef(tableName graph.TableName, column string) string {
+	escape := strings.NewReplacer("~", "~0", "/", "~1").Replace
+	pointer := "/$defs/" + escape(string(tableName)) + "/properties/" + escape(column)
+	return "#" + (&url.URL{Fragment: pointer}).EscapedFragment()
+}
+
+// jsonSchemaType maps a column type to a schema of its values as they appear
+// in JSON.
+func jsonSchemaType(t sqlType) jsonObject {
+	switch t.kind {
+	case "smallint", "integer", "bigint", "serial":
+		// ClickHouse's widest integers do not fit in a number
+		if m := clickhouseInteger.FindStringSubmatch(t.original); m != nil && (m[1] == "128" || m[1] == "256") {
+			return jsonObject{{"type", "string"}, {"pattern", "^-?[0-9]+$"}}
+		}
+		return jsonObject{{"type", "integer"}}
+	case "real", "double":
+		return jsonObject{{"type", "number"}}
+	case "decimal":
+		// Decimals are strings to keep their precision
+		return jsonObject{{"type", "string"}, {"pattern", `^-?[0-9]+(\.[0-9]+)?$`}}
+	case "boolean":
+		return jsonObject{{"type", "boolean"}}
+	case "varchar", "char", "text", "enum":
+		return jsonObject{{"type", "string"}}
+	case "uuid":
+		return jsonObject{{"type", "string"}, {"format", "uuid"}}
+	case "date":
+		return jsonObject{{"type", "string"}, {"format", "date"}}
+	case "time":
+		return jsonObject{{"type", "string"}, {"format", "time"}}
+	case "timestamp", "timestamptz":
+		return jsonObject{{"type", "string"}, {"format", "date-time"}}
+	case "binary":
+		return jsonObject{{"type", "string"}, {"contentEncoding", "base64"}}
+	case "array":
+		return jsonObject{{"type", "array"}, {"items", jsonSchemaType(*t.array)}}
+	}
+	return jsonObject{}
+}
@@ -0,0 +1,243 @@
+package render
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/viveknathani/dbtree/database"
+	"github.com/viveknathani/dbtree/graph"
+)
+
+// renderMarkdown writes a Markdown data dictionary of the schema: a table of
+// contents, then a section per table with its columns, the tables it
+// references and is referenced by, and a Mermaid diagram of its neighborhood.
+func renderMarkdown(g *graph.SchemaGraph) string {
+	tableNames := getSortedTableNames(g)
+
+	// Anchors follow the heading slugs of GitHub and most documentation
+	// generators, in the order the headings appear
+	slugs := newSlugger()
+	slugs.slug(g.DatabaseName)
+	slugs.slug("Tables")
+	anchors := make(map[graph.TableName]string, len(tableNames))
+	for _, tableName := range tableNames {
+		anchors[tableName] = slugs.slug(string(tableName))
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# ")
+	sb.WriteString(g.DatabaseName)
+	sb.WriteString("\n\n## Tables\n\n")
+	if len(tableNames) == 0 {
+		sb.WriteString("No tables.\n")
+	}
+	for _, tableName := range tableNames {
+		sb.WriteString("- ")
+		sb.WriteString(markdownLink(tableName, anchors))
+		if comment := firstLine(g.Nodes[tableName].Comment); comment != "" {
+			sb.WriteString(" — ")
+			sb.WriteString(comment)
+		}
+		sb.WriteString("\n")
+	}
+
+	for _, tableName := range tableNames {
+		sb.WriteString("\n")
+		writeMarkdownTable(&sb, g, tableName, anchors)
+	}
+
+	return sb.String()
+}
+
+// writeMarkdownTable writes the data dictionary section of a table.
+func writeMarkdownTable(sb *strings.Builder, g *graph.SchemaGraph, tableName graph.TableName, anchors map[graph.TableName]string) {
+	table := g.Nodes[tableName]
+
+	sb.WriteString("## ")
+	sb.WriteString(string(tableName))
+	sb.WriteString("\n\n")
+	if comment := strings.TrimSpace(table.Comment); comment != "" {
+		sb.WriteString(comment)
+		sb.WriteString("\n\n")
+	}
+
+	if len(table.Columns) == 0 {
+		sb.WriteString("No columns.\n")
+	} else {
+		sb.WriteString("| Column | Type | Nullable | Default | Keys | Comment |\n")
+		sb.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+		for _, col := range table.Columns {
+			nullable := "no"
+			if col.IsNullable {
+				nullable = "yes"
+			}
+			defaultValue := ""
+			if col.DefaultValue != "" {
+				defaultValue = markdownCode(col.DefaultValue)
+			}
+			fmt.Fprintf(sb, "| %s | %s | %s | %s | %s | %s |\n",
+				markdownCell(col.Name), markdownCode(string(col.Type)), nullable, defaultValue,
+				strings.Join(columnKeys(table, col.Name), ", "), markdownCell(col.Comment))
+		}
+	}
+
+	var references, referencedBy []string
+	for _, edge := range g.Edges {
+		if edge.FromTable == tableName {
+			references = append(references, fmt.Sprintf("%s via %s → %s",
+				markdownLink(edge.ToTable, anchors), markdownCode(strings.Join(edge.Columns, ", ")),
+				markdownCode(qualifiedColumns(edge.ToTable, edge.ReferenceColumns))))
+		}
+		if edge.ToTable == tableName {
+			referencedBy = append(referencedBy, fmt.Sprintf("%s via %s → %s",
+				markdownLink(edge.FromTable, anchors), markdownCode(qualifiedColumns(edge.FromTable, edge.Columns)),
+				markdownCode(strings.Join(edge.ReferenceColumns, ", "))))
+		}
+	}
+	for _, flow := range g.DataFlows {
+		if flow.ToTable == tableName {
+			references = append(references, fmt.Sprintf("%s (%s)",
+				markdownLink(flow.FromTable, anchors), dataFlowLabel(flow.Kind)))
+		}
+		if flow.FromTable == tableName {
+			referencedBy = append(referencedBy, fmt.Sprintf("%s (%s)",
+				markdownLink(flow.ToTable, anchors), dataFlowLabel(flow.Kind)))
+		}
+	}
+
+	writeMarkdownList(sb, "References", references)
+	writeMarkdownList(sb, "Referenced by", referencedBy)
+
+	sb.WriteString("\n```mermaid\n")
+	sb.WriteString(renderMermaid(neighborhood(g, tableName)))
+	sb.WriteString("```\n")
+}
+
+// writeMarkdownList writes a bold title followed by a bullet list, or nothing
+// when there are no items.
+func writeMarkdownList(sb *strings.Builder, title string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	sb.WriteString("\n**")
+	sb.WriteString(title)
+	sb.WriteString("**\n\n")
+	for _, item := range items {
+		sb.WriteString("- ")
+		sb.WriteString(item)
+		sb.WriteString("\n")
+	}
+}
+
+// neighborhood returns the subgraph of a table and the tables directly
+// connected to it by foreign keys or data flow, with only the edges that
+// touch the table.
+func neighborhood(g *graph.SchemaGraph, tableName graph.TableName) *graph.SchemaGraph {
+	sub := &graph.SchemaGraph{
+		DatabaseName: g.DatabaseName,
+		Nodes:        map[graph.TableName]*database.Table{tableName: g.Nodes[tableName]},
+	}
+
+	for _, edge := range g.Edges {
+		if edge.FromTable != tableName && edge.ToTable != tableName {
+			continue
+		}
+		sub.Edges = append(sub.Edges, edge)
+		sub.Nodes[edge.FromTable] = g.Nodes[edge.FromTable]
+		sub.Nodes[edge.ToTable] = g.Nodes[edge.ToTable]
+	}
+	for _, flow := range g.DataFlows {
+		if flow.FromTable != tableName && flow.ToTable != tableName {
+			continue
+		}
+		sub.DataFlows = append(sub.DataFlows, flow)
+		sub.Nodes[flow.FromTable] = g.Nodes[flow.FromTable]
+		sub.Nodes[flow.ToTable] = g.Nodes[flow.ToTable]
+	}
+
+	return sub
+}
+
+// qualifiedColumns returns the columns as "table.column", joined by commas.
+func qualifiedColumns(tableName graph.TableName, columns []string) string {
+	qualified := make([]string, len(columns))
+	for i, col := range columns {
+		qualified[i] = string(tableName) + "." + col
+	}
+	return strings.Join(qualified, ", ")
+}
+
+// markdownLink links to the section of a table.
+func markdownLink(tableName graph.TableName, anchors map[graph.TableName]string) string {
+	label := strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`).Replace(string(tableName))
+	return "[" + label + "](#" + anchors[tableName] + ")"
+}
+
+// markdownCode formats s as inline code inside a table cell.
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	s = strings.ReplaceAll(singleLine(s), "|", `\|`)
+	fence := "`"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		return fence + " " + s + " " + fence
+	}
+	return fence + s + fence
+}
+
+// markdownCell escapes s for use as plain text inside a table cell.
+func markdownCell(s string) string {
+	return strings.ReplaceAll(singleLine(s), "|", `\|`)
+}
+
+// singleLine joins the lines of s with <br> so it fits in a table cell.
+func singleLine(s string) string {
+	return strings.Join(strings.Fields(strings.ReplaceAll(strings.TrimSpace(s), "\n", " <br> ")), " ")
+}
+
+// firstLine returns the first non-empty line of s.
+func firstLine(s string) string {
+	for line := range strings.Lines(s) {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// slugger generates unique heading anchors the way GitHub does: lowercased,
+// with punctuation removed, spaces turned into hyphens, and a numeric suffix
+// for repeated headings.
+type slugger struct {
+	used map[string]bool
+}
+
+func newSlugger() *slugger {
+	return &slugger{used: make(map[string]bool)}
+}
+
+func (s *slugger) slug(heading string) string {
+	base := strings.Map(func(r rune) rune {
+		switch {
+		case r == ' ':
+			return '-'
+		case r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			return unicode.ToLower(r)
+		default:
+			return -1
+		}
+	}, strings.TrimSpace(heading))
+
+	slug := base
+	for n := 1; s.used[slug]; n++ {
+		slug = base + "-" + strconv.Itoa(n)
+	}
+	s.used[slug] = true
+	return slug
+}
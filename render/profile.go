@@ -0,0 +1,158 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/viveknathani/dbtree/database"
+)
+
+// maxProfileValueLength bounds how much of a value is shown in text output
+const maxProfileValueLength = 40
+
+// RenderProfile generates a string representation of a table profile in the
+// specified format.
+func RenderProfile(p *database.TableProfile, format Format) (string, error) {
+	if p == nil {
+		return "", fmt.Errorf("profile cannot be nil")
+	}
+
+	switch format {
+	case FormatText:
+		return renderProfileAsText(p), nil
+	case FormatJSON:
+		return renderProfileAsJSON(p)
+	default:
+		return "", fmt.Errorf("unsupported profile format: %s", format)
+	}
+}
+
+func renderProfileAsText(p *database.TableProfile) string {
+	var sb strings.Builder
+
+	method := "first rows"
+	if p.Sampled {
+		method = "random sample"
+	}
+	sb.WriteString(fmt.Sprintf("Table: %s (%d rows, %s)\n\n", p.Table, p.SampleSize, method))
+
+	for _, col := range p.Columns {
+		sb.WriteString(col.Name)
+		sb.WriteString(" (")
+		sb.WriteString(string(col.Type))
+		sb.WriteString(")\n")
+
+		sb.WriteString(fmt.Sprintf("  nulls: %.1f%%  distinct: %d", col.NullFraction*100, col.DistinctCount))
+		if col.DistinctCount > 0 {
+			sb.WriteString("  min: ")
+			sb.WriteString(truncateValue(col.Min))
+			sb.WriteString("  max: ")
+			sb.WriteString(truncateValue(col.Max))
+		}
+		sb.WriteString("\n")
+
+		if col.Lengths != nil {
+			sb.WriteString(fmt.Sprintf("  length: min %d, p50 %d, p90 %d, max %d, mean %.1f\n",
+				col.Lengths.Min, col.Lengths.P50, col.Lengths.P90, col.Lengths.Max, col.Lengths.Mean))
+		}
+
+		if len(col.TopValues) > 0 {
+			values := make([]string, len(col.TopValues))
+			for i, v := range col.TopValues {
+				values[i] = fmt.Sprintf("%s (%d)", strconv.Quote(truncateValue(v.Value)), v.Count)
+			}
+			sb.WriteString("  top: ")
+			sb.WriteString(strings.Join(values, ", "))
+			sb.WriteString("\n")
+		}
+
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+func renderProfileAsJSON(p *database.TableProfile) (string, error) {
+	type ValueCount struct {
+		Value string `json:"value"`
+		Count int    `json:"count"`
+	}
+
+	type Lengths struct {
+		Min  int     `json:"min"`
+		P50  int     `json:"p50"`
+		P90  int     `json:"p90"`
+		Max  int     `json:"max"`
+		Mean float64 `json:"mean"`
+	}
+
+	type Column struct {
+		Name          string       `json:"name"`
+		Type          string       `json:"type"`
+		NullFraction  float64      `json:"nullFraction"`
+		DistinctCount int          `json:"distinctCount"`
+		Min           string       `json:"min,omitempty"`
+		Max           string       `json:"max,omitempty"`
+		TopValues     []ValueCount `json:"topValues,omitempty"`
+		Lengths       *Lengths     `json:"lengths,omitempty"`
+	}
+
+	type Result struct {
+		Table      string   `json:"table"`
+		SampleSize int      `json:"sampleSize"`
+		Sampled    bool     `json:"sampled"`
+		Columns    []Column `json:"columns"`
+	}
+
+	result := Result{
+		Table:      p.Table,
+		SampleSize: p.SampleSize,
+		Sampled:    p.Sampled,
+		Columns:    []Column{},
+	}
+
+	for _, col := range p.Columns {
+		column := Column{
+			Name:          col.Name,
+			Type:          string(col.Type),
+			NullFraction:  col.NullFraction,
+			DistinctCount: col.DistinctCount,
+			Min:           col.Min,
+			Max:           col.Max,
+		}
+
+		for _, v := range col.TopValues {
+			column.TopValues = append(column.TopValues, ValueCount{Value: v.Value, Count: v.Count})
+		}
+
+		if col.Lengths != nil {
+			column.Lengths = &Lengths{
+				Min:  col.Lengths.Min,
+				P50:  col.Lengths.P50,
+				P90:  col.Lengths.P90,
+				Max:  col.Lengths.Max,
+				Mean: col.Lengths.Mean,
+			}
+		}
+
+		result.Columns = append(result.Columns, column)
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// truncateValue shortens long values for display
+func truncateValue(s string) string {
+	runes := []rune(s)
+	if len(runes) <= maxProfileValueLength {
+		return s
+	}
+	return string(runes[:maxProfileValueLength-1]) + "…"
+}
@@ -0,0 +1,74 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/viveknathani/dbtree/database"
+)
+
+func TestRenderProfile(t *testing.T) {
+	profile := &database.TableProfile{
+		Table:      "orders",
+		SampleSize: 4,
+		Sampled:    true,
+		Columns: []database.ColumnProfile{
+			{
+				Name:          "status",
+				Type:          "text",
+				NullFraction:  0.25,
+				DistinctCount: 2,
+				Min:           "paid",
+				Max:           "shipped",
+				TopValues:     []database.ValueCount{{Value: "paid", Count: 2}},
+				Lengths:       &database.LengthDistribution{Min: 4, P50: 4, P90: 7, Max: 7, Mean: 5},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		format   Format
+		contains []string
+	}{
+		{
+			name:   "text",
+			format: FormatText,
+			contains: []string{
+				"Table: orders (4 rows, random sample)",
+				"nulls: 25.0%  distinct: 2  min: paid  max: shipped",
+				"length: min 4, p50 4, p90 7, max 7, mean 5.0",
+				`top: "paid" (2)`,
+			},
+		},
+		{
+			name:   "json",
+			format: FormatJSON,
+			contains: []string{
+				`"table": "orders"`,
+				`"nullFraction": 0.25`,
+				`"topValues"`,
+				`"p90": 7`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderProfile(profile, tt.format)
+			if err != nil {
+				t.Fatalf("RenderProfile() error = %v", err)
+			}
+
+			for _, want := range tt.contains {
+				if !strings.Contains(got, want) {
+					t.Errorf("RenderProfile() output missing %q\nGot:\n%s", want, got)
+				}
+			}
+		})
+	}
+
+	if _, err := RenderProfile(nil, FormatText); err == nil {
+		t.Error("expected error for nil profile")
+	}
+}
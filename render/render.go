@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
@@ -16,6 +17,7 @@ import (
 	"github.com/viveknathani/d2/d2renderers/d2ascii"
 	"github.com/viveknathani/d2/d2renderers/d2ascii/charset"
 	"github.com/viveknathani/d2/d2renderers/d2svg"
+	"github.com/viveknathani/d2/d2target"
 	"github.com/viveknathani/d2/lib/log"
 	"github.com/viveknathani/d2/lib/textmeasure"
 	"github.com/viveknathani/dbtree/database"
@@ -29,34 +31,166 @@ type Format string
 // Shape represents how the schema relationships are structured
 type Shape string
 
+// SortOrder represents how tables are ordered in the flat shape
+type SortOrder string
+
 const (
-	FormatText Format = "text"
-	FormatJSON Format = "json"
-	ShapeTree  Shape  = "tree"
-	ShapeFlat  Shape  = "flat"
-	ShapeChart Shape  = "chart"
+	FormatText       Format = "text"
+	FormatJSON       Format = "json"
+	FormatMarkdown   Format = "markdown"
+	FormatMermaid    Format = "mermaid"
+	FormatPlantUML   Format = "plantuml"
+	FormatDOT        Format = "dot"
+	FormatSVG        Format = "svg"
+	FormatDBML       Format = "dbml"
+	FormatSQL        Format = "sql"
+	FormatGo         Format = "go"
+	FormatTypeScript Format = "typescript"
+	FormatJSONSchema Format = "jsonschema"
+	ShapeTree        Shape  = "tree"
+	ShapeFlat        Shape  = "flat"
+	ShapeChart       Shape  = "chart"
+
+	SortByName SortOrder = "name"
+	SortByRows SortOrder = "rows"
+	SortBySize SortOrder = "size"
 )
 
+// Formats lists the formats a schema graph can be rendered in.
+var Formats = []Format{FormatText, FormatJSON, FormatMarkdown, FormatMermaid, FormatPlantUML, FormatDOT, FormatSVG, FormatDBML, FormatSQL, FormatGo, FormatTypeScript, FormatJSONSchema}
+
+// formatExtensions maps output file extensions to the format written to them.
+var formatExtensions = map[string]Format{
+	".txt":  FormatText,
+	".json": FormatJSON,
+	".md":   FormatMarkdown,
+	".mmd":  FormatMermaid,
+	".puml": FormatPlantUML,
+	".dot":  FormatDOT,
+	".svg":  FormatSVG,
+	".dbml": FormatDBML,
+	".sql":  FormatSQL,
+	".go":   FormatGo,
+	".ts":   FormatTypeScript,
+	// Longer extensions win, so schema.json is not read as plain JSON
+	".schema.json": FormatJSONSchema,
+}
+
+// FormatForPath infers the format of an output file from its extension.
+func FormatForPath(path string) (Format, error) {
+	name := strings.ToLower(filepath.Base(path))
+	var format Format
+	var matched string
+	for ext, f := range formatExtensions {
+		if strings.HasSuffix(name, ext) && len(ext) > len(matched) {
+			format, matched = f, ext
+		}
+	}
+	if format == "" {
+		return "", fmt.Errorf("cannot infer the format of %s (use .txt, .json, .md, .mmd, .puml, .dot, .svg, .dbml, .sql, .go, .ts or .schema.json)", path)
+	}
+	return format, nil
+}
+
+// Extension returns the file extension of the format, including the dot.
+func (f Format) Extension() string {
+	for ext, format := range formatExtensions {
+		if format == f {
+			return ext
+		}
+	}
+	return ".txt"
+}
+
+// Options holds optional rendering settings. The zero value renders the
+// same output as Render.
+type Options struct {
+	// SortBy orders the tables of the flat shape. Sorting by rows or size
+	// needs table statistics and puts the largest tables first.
+	SortBy SortOrder
+	// MaxDepth limits how many levels of tables the tree shape shows below
+	// the root tables. Zero shows all levels.
+	MaxDepth int
+	// Dialect is the SQL dialect of the sql format, PostgreSQL by default.
+	Dialect Dialect
+	// Charset is the characters the chart shape draws with, ASCII by default.
+	Charset Charset
+	// Width is the number of columns the chart shape tries to fit in, by
+	// laying tables out from top to bottom or wrapping unconnected groups of
+	// tables. Zero leaves the width unlimited.
+	Width int
+	// Color highlights primary and foreign key columns and the lines between
+	// tables of the chart shape with ANSI colours.
+	Color bool
+	// Go holds the package, json tag style and nullable style of the go
+	// format.
+	Go GoOptions
+	// Changed lists tables, and columns as "table.column", whose names are
+	// passed through Highlight in text output, for example to mark what
+	// changed since a previous render.
+	Changed map[string]bool
+	// Highlight decorates the names listed in Changed. Nil leaves them as is.
+	Highlight func(string) string
+}
+
+// highlight returns s decorated by Highlight when key is listed in Changed.
+func (o Options) highlight(key, s string) string {
+	if o.Highlight == nil || !o.Changed[key] {
+		return s
+	}
+	return o.Highlight(s)
+}
+
 // Render generates a string representation of the schema graph
 // based on the specified format and shape.
 func Render(g *graph.SchemaGraph, format Format, shape Shape) (string, error) {
+	return RenderWithOptions(g, format, shape, Options{})
+}
+
+// RenderWithOptions generates a string representation of the schema graph
+// based on the specified format, shape and options.
+func RenderWithOptions(g *graph.SchemaGraph, format Format, shape Shape, opts Options) (string, error) {
 	if g == nil {
 		return "", fmt.Errorf("schema graph cannot be nil")
 	}
 
+	// Diagram formats always draw the whole schema, whatever the shape
+	switch format {
+	case FormatMarkdown:
+		return renderMarkdown(g), nil
+	case FormatMermaid:
+		return renderMermaid(g), nil
+	case FormatPlantUML:
+		return renderPlantUML(g), nil
+	case FormatDOT:
+		return renderDOT(g), nil
+	case FormatSVG:
+		return renderSVG(g)
+	case FormatDBML:
+		return renderDBML(g), nil
+	case FormatSQL:
+		return renderSQL(g, opts.Dialect)
+	case FormatGo:
+		return renderGo(g, opts.Go)
+	case FormatTypeScript:
+		return renderTypeScript(g), nil
+	case FormatJSONSchema:
+		return renderJSONSchema(g)
+	}
+
 	switch {
 	case format == FormatText && shape == ShapeTree:
-		tree := buildTree(g)
-		return renderTreeAsText(tree, g.DatabaseName), nil
+		tree := buildTree(g, opts.MaxDepth)
+		return renderTreeAsText(tree, g.DatabaseName, opts), nil
 	case format == FormatJSON && shape == ShapeTree:
-		tree := buildTree(g)
+		tree := buildTree(g, opts.MaxDepth)
 		return renderTreeAsJSON(tree, g.DatabaseName)
 	case format == FormatText && shape == ShapeFlat:
-		return renderFlatAsText(g)
+		return renderFlatAsText(g, opts)
 	case format == FormatJSON && shape == ShapeFlat:
-		return renderFlatAsJSON(g)
+		return renderFlatAsJSON(g, opts)
 	case format == FormatText && shape == ShapeChart:
-		return renderGraphAsText(g)
+		return renderGraphAsText(g, opts)
 	case format == FormatJSON && shape == ShapeChart:
 		return "", fmt.Errorf("chart shape is only supported with text format")
 	default:
@@ -71,10 +205,23 @@ type TreeNode struct {
 	Children     []*TreeNode
 	IsCircular   bool
 	AlreadyShown bool
+	// DataFlow is set when the node is linked to its parent by data flow
+	// (the parent feeds the node) rather than by a foreign key.
+	DataFlow database.DataFlowKind
+	// HiddenChildren counts the child tables left out by a depth limit.
+	HiddenChildren int
+}
+
+// treeLink connects a parent table to a child table in the tree. An empty
+// DataFlow means the child references the parent through a foreign key.
+type treeLink struct {
+	Table    graph.TableName
+	DataFlow database.DataFlowKind
 }
 
-// buildTree creates a tree structure from the schema graph
-func buildTree(g *graph.SchemaGraph) *TreeNode {
+// buildTree creates a tree structure from the schema graph, showing at most
+// maxDepth levels of tables when maxDepth is positive
+func buildTree(g *graph.SchemaGraph, maxDepth int) *TreeNode {
 	// Build adjacency list
 	childrenMap := buildAdjacencyList(g)
 
@@ -96,7 +243,7 @@ func buildTree(g *graph.SchemaGraph) *TreeNode {
 				firstTable = tableName
 			}
 		}
-		rootNode = buildTreeNode(g, firstTable, childrenMap, visited, processing)
+		rootNode = buildTreeNode(g, treeLink{Table: firstTable}, childrenMap, visited, processing, 1, maxDepth)
 	} else {
 		// Create virtual root to hold all actual roots
 		rootNode = &TreeNode{
@@ -105,7 +252,7 @@ func buildTree(g *graph.SchemaGraph) *TreeNode {
 		}
 
 		for _, root := range roots {
-			if child := buildTreeNode(g, root, childrenMap, visited, processing); child != nil {
+			if child := buildTreeNode(g, treeLink{Table: root}, childrenMap, visited, processing, 1, maxDepth); child != nil {
 				rootNode.Children = append(rootNode.Children, child)
 			}
 		}
@@ -133,9 +280,11 @@ func buildTree(g *graph.SchemaGraph) *TreeNode {
 	return rootNode
 }
 
-func buildTreeNode(g *graph.SchemaGraph, tableName graph.TableName,
-	childrenMap map[graph.TableName][]graph.TableName,
-	visited, processing map[graph.TableName]bool) *TreeNode {
+func buildTreeNode(g *graph.SchemaGraph, link treeLink,
+	childrenMap map[graph.TableName][]treeLink,
+	visited, processing map[graph.TableName]bool, depth, maxDepth int) *TreeNode {
+
+	tableName := link.Table
 
 	// Handle cycles
 	if processing[tableName] {
@@ -144,6 +293,7 @@ func buildTreeNode(g *graph.SchemaGraph, tableName graph.TableName,
 			Table:      g.Nodes[tableName],
 			IsCircular: true,
 			Children:   []*TreeNode{},
+			DataFlow:   link.DataFlow,
 		}
 	}
 
@@ -154,6 +304,7 @@ func buildTreeNode(g *graph.SchemaGraph, tableName graph.TableName,
 			Table:        g.Nodes[tableName],
 			AlreadyShown: true,
 			Children:     []*TreeNode{},
+			DataFlow:     link.DataFlow,
 		}
 	}
 
@@ -164,11 +315,17 @@ func buildTreeNode(g *graph.SchemaGraph, tableName graph.TableName,
 		TableName: tableName,
 		Table:     g.Nodes[tableName],
 		Children:  []*TreeNode{},
+		DataFlow:  link.DataFlow,
 	}
 
-	// Add children
+	// Add children. Below the depth limit they are only counted and are not
+	// marked as visited, so they can still be shown at a shallower level.
 	for _, child := range childrenMap[tableName] {
-		if childNode := buildTreeNode(g, child, childrenMap, visited, processing); childNode != nil {
+		if maxDepth > 0 && depth >= maxDepth {
+			node.HiddenChildren++
+			continue
+		}
+		if childNode := buildTreeNode(g, child, childrenMap, visited, processing, depth+1, maxDepth); childNode != nil {
 			node.Children = append(node.Children, childNode)
 		}
 	}
@@ -177,28 +334,33 @@ func buildTreeNode(g *graph.SchemaGraph, tableName graph.TableName,
 	return node
 }
 
-func renderTreeAsText(root *TreeNode, databaseName string) string {
+func renderTreeAsText(root *TreeNode, databaseName string, opts Options) string {
 	var sb strings.Builder
 	sb.WriteString(databaseName)
 	sb.WriteString("\n")
 
 	for i, child := range root.Children {
 		isLast := i == len(root.Children)-1
-		renderTextNode(&sb, child, "", isLast)
+		renderTextNode(&sb, child, "", isLast, opts)
 	}
 
 	return sb.String()
 }
 
-func renderTextNode(sb *strings.Builder, node *TreeNode, prefix string, isLast bool) {
+func renderTextNode(sb *strings.Builder, node *TreeNode, prefix string, isLast bool, opts Options) {
 	if node.TableName == "orphan_tables" {
 		sb.WriteString("\nOrphan tables:\n")
 		for _, child := range node.Children {
 			sb.WriteString("• ")
-			sb.WriteString(string(child.TableName))
+			sb.WriteString(opts.highlight(string(child.TableName), string(child.TableName)))
+			if child.Table != nil && child.Table.Stats != nil {
+				sb.WriteString(" [")
+				sb.WriteString(formatStats(child.Table.Stats))
+				sb.WriteString("]")
+			}
 			sb.WriteString("\n")
 			if child.Table != nil {
-				renderTableColumns(sb, child.Table, "  ")
+				renderTableColumns(sb, child.Table, "  ", opts)
 			}
 		}
 		return
@@ -211,12 +373,26 @@ func renderTextNode(sb *strings.Builder, node *TreeNode, prefix string, isLast b
 		connector = "└── "
 	}
 	sb.WriteString(connector)
-	sb.WriteString(string(node.TableName))
+	sb.WriteString(opts.highlight(string(node.TableName), string(node.TableName)))
+
+	if node.Table != nil && node.Table.Stats != nil && !node.IsCircular && !node.AlreadyShown {
+		sb.WriteString(" [")
+		sb.WriteString(formatStats(node.Table.Stats))
+		sb.WriteString("]")
+	}
+
+	if node.DataFlow != "" {
+		sb.WriteString(" (data flow: ")
+		sb.WriteString(dataFlowLabel(node.DataFlow))
+		sb.WriteString(")")
+	}
 
 	if node.IsCircular {
 		sb.WriteString(" (circular reference)")
 	} else if node.AlreadyShown {
 		sb.WriteString(" (see above)")
+	} else if node.HiddenChildren > 0 {
+		sb.WriteString(fmt.Sprintf(" (+%d more)", node.HiddenChildren))
 	}
 	sb.WriteString("\n")
 
@@ -228,7 +404,7 @@ func renderTextNode(sb *strings.Builder, node *TreeNode, prefix string, isLast b
 		} else {
 			newPrefix += "│   "
 		}
-		renderTableColumns(sb, node.Table, newPrefix)
+		renderTableColumns(sb, node.Table, newPrefix, opts)
 	}
 
 	// Render children
@@ -242,12 +418,12 @@ func renderTextNode(sb *strings.Builder, node *TreeNode, prefix string, isLast b
 
 		for i, child := range node.Children {
 			childIsLast := i == len(node.Children)-1
-			renderTextNode(sb, child, childPrefix, childIsLast)
+			renderTextNode(sb, child, childPrefix, childIsLast, opts)
 		}
 	}
 }
 
-func renderTableColumns(sb *strings.Builder, table *database.Table, prefix string) {
+func renderTableColumns(sb *strings.Builder, table *database.Table, prefix string, opts Options) {
 	if table == nil {
 		return
 	}
@@ -261,10 +437,7 @@ func renderTableColumns(sb *strings.Builder, table *database.Table, prefix strin
 		}
 		sb.WriteString(connector)
 
-		sb.WriteString(col.Name)
-		sb.WriteString(" (")
-		sb.WriteString(strconv.Quote(string(col.Type)))
-		sb.WriteString(")")
+		sb.WriteString(opts.highlight(table.Name+"."+col.Name, col.Name+" ("+strconv.Quote(string(col.Type))+")"))
 
 		// Add constraints
 		appendConstraints(sb, table, col.Name)
@@ -303,12 +476,17 @@ func renderTreeAsJSON(root *TreeNode, databaseName string) (string, error) {
 		Type       string `json:"type"`
 		Constraint string `json:"constraint,omitempty"`
 		Reference  string `json:"reference,omitempty"`
+		Comment    string `json:"comment,omitempty"`
 	}
 
 	type Table struct {
-		Name     string   `json:"name"`
-		Columns  []Column `json:"columns"`
-		Children []Table  `json:"children,omitempty"`
+		Name           string     `json:"name"`
+		DataFlow       string     `json:"dataFlow,omitempty"`
+		Comment        string     `json:"comment,omitempty"`
+		Stats          *jsonStats `json:"stats,omitempty"`
+		Columns        []Column   `json:"columns"`
+		Children       []Table    `json:"children,omitempty"`
+		HiddenChildren int        `json:"hiddenChildren,omitempty"`
 	}
 
 	type Result struct {
@@ -320,16 +498,24 @@ func renderTreeAsJSON(root *TreeNode, databaseName string) (string, error) {
 	var convertNode func(*TreeNode) *Table
 	convertNode = func(node *TreeNode) *Table {
 		table := &Table{
-			Name:    string(node.TableName),
-			Columns: []Column{},
+			Name:           string(node.TableName),
+			Columns:        []Column{},
+			HiddenChildren: node.HiddenChildren,
+		}
+
+		if node.DataFlow != "" {
+			table.DataFlow = dataFlowLabel(node.DataFlow)
 		}
 
 		// Only add details and children if not circular/already shown
 		if node.Table != nil && !node.IsCircular && !node.AlreadyShown {
+			table.Stats = newJSONStats(node.Table.Stats)
+			table.Comment = node.Table.Comment
 			for _, col := range node.Table.Columns {
 				column := Column{
-					Name: col.Name,
-					Type: string(col.Type),
+					Name:    col.Name,
+					Type:    string(col.Type),
+					Comment: col.Comment,
 				}
 
 				// Find constraints
@@ -399,7 +585,7 @@ func renderTreeAsJSON(root *TreeNode, databaseName string) (string, error) {
 	return string(data), nil
 }
 
-func renderFlatAsText(g *graph.SchemaGraph) (string, error) {
+func renderFlatAsText(g *graph.SchemaGraph, opts Options) (string, error) {
 	var sb strings.Builder
 
 	sb.WriteString("Database: ")
@@ -408,19 +594,21 @@ func renderFlatAsText(g *graph.SchemaGraph) (string, error) {
 	sb.WriteString(fmt.Sprintf("Tables: %d\n\n", len(g.Nodes)))
 
 	// Sort table names
-	tableNames := getSortedTableNames(g)
+	tableNames := getOrderedTableNames(g, opts.SortBy)
 
 	for _, tableName := range tableNames {
 		table := g.Nodes[tableName]
-		sb.WriteString(string(tableName))
+		sb.WriteString(opts.highlight(string(tableName), string(tableName)))
+		if table.Stats != nil {
+			sb.WriteString(" [")
+			sb.WriteString(formatStats(table.Stats))
+			sb.WriteString("]")
+		}
 		sb.WriteString("\n")
 
 		for _, col := range table.Columns {
 			sb.WriteString("  - ")
-			sb.WriteString(col.Name)
-			sb.WriteString(" (")
-			sb.WriteString(string(col.Type))
-			sb.WriteString(")")
+			sb.WriteString(opts.highlight(table.Name+"."+col.Name, col.Name+" ("+string(col.Type)+")"))
 
 			appendConstraints(&sb, table, col.Name)
 			sb.WriteString("\n")
@@ -429,20 +617,37 @@ func renderFlatAsText(g *graph.SchemaGraph) (string, error) {
 		sb.WriteString("\n")
 	}
 
+	if len(g.DataFlows) > 0 {
+		sb.WriteString("Data flow:\n")
+		for _, flow := range g.DataFlows {
+			sb.WriteString("  - ")
+			sb.WriteString(string(flow.FromTable))
+			sb.WriteString(" ⇢ ")
+			sb.WriteString(string(flow.ToTable))
+			sb.WriteString(" (")
+			sb.WriteString(dataFlowLabel(flow.Kind))
+			sb.WriteString(")\n")
+		}
+		sb.WriteString("\n")
+	}
+
 	return sb.String(), nil
 }
 
-func renderFlatAsJSON(g *graph.SchemaGraph) (string, error) {
+func renderFlatAsJSON(g *graph.SchemaGraph, opts Options) (string, error) {
 	type Column struct {
 		Name       string `json:"name"`
 		Type       string `json:"type"`
 		Constraint string `json:"constraint,omitempty"`
 		Reference  string `json:"reference,omitempty"`
+		Comment    string `json:"comment,omitempty"`
 	}
 
 	type Table struct {
-		Name    string   `json:"name"`
-		Columns []Column `json:"columns"`
+		Name    string     `json:"name"`
+		Comment string     `json:"comment,omitempty"`
+		Stats   *jsonStats `json:"stats,omitempty"`
+		Columns []Column   `json:"columns"`
 	}
 
 	type Edge struct {
@@ -452,10 +657,17 @@ func renderFlatAsJSON(g *graph.SchemaGraph) (string, error) {
 		ReferenceColumns []string `json:"referenceColumns"`
 	}
 
+	type DataFlow struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+		Kind string `json:"kind"`
+	}
+
 	type Result struct {
-		Database string  `json:"database"`
-		Tables   []Table `json:"tables"`
-		Edges    []Edge  `json:"edges"`
+		Database  string     `json:"database"`
+		Tables    []Table    `json:"tables"`
+		Edges     []Edge     `json:"edges"`
+		DataFlows []DataFlow `json:"dataFlows,omitempty"`
 	}
 
 	result := Result{
@@ -465,18 +677,21 @@ func renderFlatAsJSON(g *graph.SchemaGraph) (string, error) {
 	}
 
 	// Build tables
-	tableNames := getSortedTableNames(g)
+	tableNames := getOrderedTableNames(g, opts.SortBy)
 	for _, tableName := range tableNames {
 		t := g.Nodes[tableName]
 		table := Table{
 			Name:    string(tableName),
+			Comment: t.Comment,
+			Stats:   newJSONStats(t.Stats),
 			Columns: []Column{},
 		}
 
 		for _, col := range t.Columns {
 			column := Column{
-				Name: col.Name,
-				Type: string(col.Type),
+				Name:    col.Name,
+				Type:    string(col.Type),
+				Comment: col.Comment,
 			}
 
 			// Find constraints
@@ -518,6 +733,15 @@ func renderFlatAsJSON(g *graph.SchemaGraph) (string, error) {
 		})
 	}
 
+	// Build data flows
+	for _, flow := range g.DataFlows {
+		result.DataFlows = append(result.DataFlows, DataFlow{
+			From: string(flow.FromTable),
+			To:   string(flow.ToTable),
+			Kind: dataFlowLabel(flow.Kind),
+		})
+	}
+
 	data, err := json.MarshalIndent(result, "", "  ")
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal JSON: %w", err)
@@ -526,40 +750,42 @@ func renderFlatAsJSON(g *graph.SchemaGraph) (string, error) {
 	return string(data), nil
 }
 
-func renderGraphAsText(g *graph.SchemaGraph) (string, error) {
-	d2Source := generateD2Diagram(g)
-
-	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
-	ctx := log.With(context.Background(), logger)
-
-	ruler, err := textmeasure.NewRuler()
+func renderGraphAsText(g *graph.SchemaGraph, opts Options) (string, error) {
+	chart, err := drawChart(g, "right", opts.Charset)
 	if err != nil {
-		return "", fmt.Errorf("failed to create text ruler: %w", err)
+		return "", err
 	}
 
-	compileOpts := &d2lib.CompileOptions{
-		Ruler:  ruler,
-		Layout: go2.Pointer("elk"),
-		LayoutResolver: func(engine string) (d2graph.LayoutGraph, error) {
-			return d2elklayout.DefaultLayout, nil
-		},
+	if opts.Width > 0 && chartWidth(chart) > opts.Width {
+		if chart, err = fitChart(g, chart, opts); err != nil {
+			return "", err
+		}
 	}
 
-	themeId := int64(0)
-	renderOpts := &d2svg.RenderOpts{
-		Pad:     go2.Pointer(int64(0)),
-		ThemeID: &themeId,
+	if opts.Color {
+		chart = colorChart(g, chart)
 	}
+	return chart, nil
+}
 
-	diagram, _, err := d2lib.Compile(ctx, d2Source, compileOpts, renderOpts)
+// drawChart lays out the schema graph in a direction, right or down, and
+// draws it with the characters of a charset.
+func drawChart(g *graph.SchemaGraph, direction string, chars Charset) (string, error) {
+	ctx := d2Context()
+	diagram, err := compileD2Diagram(ctx, g, direction)
 	if err != nil {
-		return "", fmt.Errorf("failed to compile D2 diagram: %w", err)
+		return "", err
+	}
+
+	set := charset.ASCII
+	if chars == CharsetUnicode {
+		set = charset.Unicode
 	}
 
 	artist := d2ascii.NewASCIIartist()
 	asciiBytes, err := artist.Render(ctx, diagram, &d2ascii.RenderOpts{
 		Scale:   go2.Pointer(1.0),
-		Charset: charset.ASCII,
+		Charset: set,
 	})
 	if err != nil {
 		return "", fmt.Errorf("failed to render ASCII: %w", err)
@@ -568,16 +794,71 @@ func renderGraphAsText(g *graph.SchemaGraph) (string, error) {
 	return string(asciiBytes), nil
 }
 
+// renderSVG draws the schema graph as an SVG image, laid out like the chart shape.
+func renderSVG(g *graph.SchemaGraph) (string, error) {
+	diagram, err := compileD2Diagram(d2Context(), g, "right")
+	if err != nil {
+		return "", err
+	}
+
+	svg, err := d2svg.Render(diagram, d2RenderOpts())
+	if err != nil {
+		return "", fmt.Errorf("failed to render SVG: %w", err)
+	}
+
+	return string(svg), nil
+}
+
+// d2Context returns a context for the D2 compiler that discards its logs.
+func d2Context() context.Context {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return log.With(context.Background(), logger)
+}
+
+func d2RenderOpts() *d2svg.RenderOpts {
+	themeId := int64(0)
+	return &d2svg.RenderOpts{
+		Pad:     go2.Pointer(int64(0)),
+		ThemeID: &themeId,
+	}
+}
+
+// compileD2Diagram lays out the D2 diagram of the schema graph, with tables
+// following each other in a direction: right or down.
+func compileD2Diagram(ctx context.Context, g *graph.SchemaGraph, direction string) (*d2target.Diagram, error) {
+	d2Source := generateD2Diagram(g, direction)
+
+	ruler, err := textmeasure.NewRuler()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create text ruler: %w", err)
+	}
+
+	compileOpts := &d2lib.CompileOptions{
+		Ruler:  ruler,
+		Layout: go2.Pointer("elk"),
+		LayoutResolver: func(engine string) (d2graph.LayoutGraph, error) {
+			return d2elklayout.DefaultLayout, nil
+		},
+	}
+
+	diagram, _, err := d2lib.Compile(ctx, d2Source, compileOpts, d2RenderOpts())
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile D2 diagram: %w", err)
+	}
+
+	return diagram, nil
+}
+
 // d2Ident properly quotes D2 identifiers to handle special characters,
 // spaces, dashes, dots, and reserved words
 func d2Ident(s string) string {
 	return strconv.Quote(s)
 }
 
-func generateD2Diagram(g *graph.SchemaGraph) string {
+func generateD2Diagram(g *graph.SchemaGraph, direction string) string {
 	var sb strings.Builder
 
-	sb.WriteString("direction: right\n\n")
+	sb.WriteString("direction: " + direction + "\n\n")
 
 	tableNames := getSortedTableNames(g)
 
@@ -595,7 +876,7 @@ func generateD2Diagram(g *graph.SchemaGraph) string {
 			sb.WriteString("  ")
 			sb.WriteString(d2Ident(col.Name))
 			sb.WriteString(": ")
-			sb.WriteString(string(col.Type))
+			sb.WriteString(d2Ident(string(col.Type)))
 
 			// Add constraints as metadata
 			var constraints []string
@@ -633,6 +914,15 @@ func generateD2Diagram(g *graph.SchemaGraph) string {
 	}
 
 	for _, edge := range g.Edges {
+		// Edges between columns leave a table's side, which turns a top to
+		// bottom layout into a staircase, so they join whole tables there
+		if direction == "down" {
+			sb.WriteString(d2Ident(string(edge.FromTable)))
+			sb.WriteString(" -> ")
+			sb.WriteString(d2Ident(string(edge.ToTable)))
+			sb.WriteString("\n")
+			continue
+		}
 		for i, col := range edge.Columns {
 			if i < len(edge.ReferenceColumns) {
 				sb.WriteString(d2Ident(string(edge.FromTable)))
@@ -647,18 +937,49 @@ func generateD2Diagram(g *graph.SchemaGraph) string {
 		}
 	}
 
+	// Data flow is drawn between whole tables with a dashed line so it is
+	// not mistaken for a foreign key
+	for _, flow := range g.DataFlows {
+		sb.WriteString(d2Ident(string(flow.FromTable)))
+		sb.WriteString(" -> ")
+		sb.WriteString(d2Ident(string(flow.ToTable)))
+		sb.WriteString(": ")
+		sb.WriteString(strconv.Quote(dataFlowLabel(flow.Kind)))
+		sb.WriteString(" {style.stroke-dash: 3}\n")
+	}
+
 	return sb.String()
 }
 
-func buildAdjacencyList(g *graph.SchemaGraph) map[graph.TableName][]graph.TableName {
-	childrenMap := make(map[graph.TableName][]graph.TableName)
+// dataFlowLabel returns a human-readable label for a data flow kind.
+func dataFlowLabel(kind database.DataFlowKind) string {
+	switch kind {
+	case database.MaterializedViewFlow:
+		return "materialized view"
+	case database.DistributedFlow:
+		return "distributed"
+	default:
+		return strings.ToLower(string(kind))
+	}
+}
+
+func buildAdjacencyList(g *graph.SchemaGraph) map[graph.TableName][]treeLink {
+	childrenMap := make(map[graph.TableName][]treeLink)
 
 	for tableName := range g.Nodes {
-		childrenMap[tableName] = []graph.TableName{}
+		childrenMap[tableName] = []treeLink{}
 	}
 
 	for _, edge := range g.Edges {
-		childrenMap[edge.ToTable] = append(childrenMap[edge.ToTable], edge.FromTable)
+		childrenMap[edge.ToTable] = append(childrenMap[edge.ToTable], treeLink{Table: edge.FromTable})
+	}
+
+	// Downstream tables hang below the table that feeds them
+	for _, flow := range g.DataFlows {
+		childrenMap[flow.FromTable] = append(childrenMap[flow.FromTable], treeLink{
+			Table:    flow.ToTable,
+			DataFlow: flow.Kind,
+		})
 	}
 
 	return childrenMap
@@ -671,6 +992,10 @@ func findRootTables(g *graph.SchemaGraph) []graph.TableName {
 		hasIncomingEdge[edge.FromTable] = true
 	}
 
+	for _, flow := range g.DataFlows {
+		hasIncomingEdge[flow.ToTable] = true
+	}
+
 	var roots []graph.TableName
 	for tableName := range g.Nodes {
 		if !hasIncomingEdge[tableName] {
@@ -697,6 +1022,12 @@ func findOrphanTables(g *graph.SchemaGraph, visited map[graph.TableName]bool) []
 					break
 				}
 			}
+			for _, flow := range g.DataFlows {
+				if flow.FromTable == tableName || flow.ToTable == tableName {
+					hasRelationship = true
+					break
+				}
+			}
 			if !hasRelationship {
 				orphans = append(orphans, tableName)
 			}
@@ -710,6 +1041,104 @@ func findOrphanTables(g *graph.SchemaGraph, visited map[graph.TableName]bool) []
 	return orphans
 }
 
+// getOrderedTableNames returns the table names in the given sort order.
+// Tables without statistics sort after those with statistics, and ties are
+// broken by name.
+func getOrderedTableNames(g *graph.SchemaGraph, sortBy SortOrder) []graph.TableName {
+	tableNames := getSortedTableNames(g)
+
+	var metric func(*database.TableStats) int64
+	switch sortBy {
+	case SortByRows:
+		metric = func(stats *database.TableStats) int64 { return stats.RowCount }
+	case SortBySize:
+		metric = func(stats *database.TableStats) int64 { return stats.SizeBytes }
+	default:
+		return tableNames
+	}
+
+	sort.SliceStable(tableNames, func(i, j int) bool {
+		a, b := g.Nodes[tableNames[i]].Stats, g.Nodes[tableNames[j]].Stats
+		if a == nil || b == nil {
+			return a != nil && b == nil
+		}
+		return metric(a) > metric(b)
+	})
+
+	return tableNames
+}
+
+// jsonStats is the JSON representation of table statistics
+type jsonStats struct {
+	Rows       int64 `json:"rows"`
+	RowsExact  bool  `json:"rowsExact,omitempty"`
+	RowsCapped bool  `json:"rowsCapped,omitempty"`
+	SizeBytes  int64 `json:"sizeBytes,omitempty"`
+}
+
+func newJSONStats(stats *database.TableStats) *jsonStats {
+	if stats == nil {
+		return nil
+	}
+	return &jsonStats{
+		Rows:       stats.RowCount,
+		RowsExact:  stats.RowCountExact,
+		RowsCapped: stats.RowCountCapped,
+		SizeBytes:  stats.SizeBytes,
+	}
+}
+
+// formatStats renders table statistics as e.g. "~12.3k rows, 4.5 MB", where
+// "~" marks an estimate and ">" a capped count
+func formatStats(stats *database.TableStats) string {
+	var sb strings.Builder
+
+	if stats.RowCountCapped {
+		sb.WriteString(">")
+	} else if !stats.RowCountExact {
+		sb.WriteString("~")
+	}
+	sb.WriteString(formatCount(stats.RowCount))
+	sb.WriteString(" rows")
+
+	if stats.SizeBytes > 0 {
+		sb.WriteString(", ")
+		sb.WriteString(formatBytes(stats.SizeBytes))
+	}
+
+	return sb.String()
+}
+
+// formatCount abbreviates a row count, such as 2.5M. Counts that would round
+// up to 1000 of a unit are written in the next one.
+func formatCount(n int64) string {
+	switch {
+	case n < 1000:
+		return strconv.FormatInt(n, 10)
+	case n < 999_950:
+		return fmt.Sprintf("%.1fk", float64(n)/1e3)
+	case n < 999_950_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	default:
+		return fmt.Sprintf("%.1fB", float64(n)/1e9)
+	}
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
 func getSortedTableNames(g *graph.SchemaGraph) []graph.TableName {
 	var tableNames []graph.TableName
 	for name := range g.Nodes {
@@ -1,6 +1,9 @@
 package render
 
 import (
+	"bytes"
+	"encoding/json"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -469,3 +472,1287 @@ func TestRenderWithSpecialCharacters(t *testing.T) {
 		t.Errorf("Expected output to contain table names, got:\n%s", output)
 	}
 }
+
+func TestRenderDataFlow(t *testing.T) {
+	// ClickHouse style lineage: kafka_queue -> mv_parse -> events_local -> events
+	db := &database.Database{
+		Name: "analytics",
+		Tables: []database.Table{
+			{
+				Name:    "kafka_queue",
+				Columns: []database.Column{{Name: "raw", Type: "String"}},
+			},
+			{
+				Name:    "mv_parse",
+				Columns: []database.Column{{Name: "id", Type: "UInt64"}},
+				DataSources: []database.DataSource{
+					{Kind: database.MaterializedViewFlow, Table: "kafka_queue"},
+				},
+			},
+			{
+				Name:    "events_local",
+				Columns: []database.Column{{Name: "id", Type: "UInt64"}},
+				DataSources: []database.DataSource{
+					{Kind: database.MaterializedViewFlow, Table: "mv_parse"},
+				},
+			},
+			{
+				Name:    "events",
+				Columns: []database.Column{{Name: "id", Type: "UInt64"}},
+				DataSources: []database.DataSource{
+					{Kind: database.DistributedFlow, Table: "events_local"},
+				},
+			},
+		},
+	}
+
+	g, err := graph.Build(db)
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+
+	t.Run("text tree", func(t *testing.T) {
+		output, err := Render(g, FormatText, ShapeTree)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		for _, want := range []string{
+			"└── kafka_queue\n",
+			"mv_parse (data flow: materialized view)",
+			"events_local (data flow: materialized view)",
+			"events (data flow: distributed)",
+		} {
+			if !strings.Contains(output, want) {
+				t.Errorf("expected %q in output:\n%s", want, output)
+			}
+		}
+
+		if strings.Contains(output, "Orphan tables") {
+			t.Errorf("tables linked by data flow should not be orphans:\n%s", output)
+		}
+	})
+
+	t.Run("json tree", func(t *testing.T) {
+		output, err := Render(g, FormatJSON, ShapeTree)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !strings.Contains(output, `"dataFlow": "distributed"`) {
+			t.Errorf("expected data flow in JSON tree output:\n%s", output)
+		}
+	})
+
+	t.Run("json flat", func(t *testing.T) {
+		output, err := Render(g, FormatJSON, ShapeFlat)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !strings.Contains(output, `"dataFlows"`) || !strings.Contains(output, `"kind": "materialized view"`) {
+			t.Errorf("expected data flows in JSON flat output:\n%s", output)
+		}
+	})
+
+	t.Run("chart", func(t *testing.T) {
+		output, err := Render(g, FormatText, ShapeChart)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !strings.Contains(output, "kafka_queue") || !strings.Contains(output, "events_local") {
+			t.Errorf("expected tables in chart output:\n%s", output)
+		}
+	})
+}
+
+func TestFormatCount(t *testing.T) {
+	tests := []struct {
+		n        int64
+		expected string
+	}{
+		{0, "0"},
+		{999, "999"},
+		{1000, "1.0k"},
+		{999_949, "999.9k"},
+		{999_950, "1.0M"},
+		{999_999, "1.0M"},
+		{1_000_000, "1.0M"},
+		{999_949_999, "999.9M"},
+		{999_950_000, "1.0B"},
+		{999_999_999, "1.0B"},
+		{2_500_000_000, "2.5B"},
+	}
+
+	for _, tt := range tests {
+		if got := formatCount(tt.n); got != tt.expected {
+			t.Errorf("formatCount(%d) = %q, expected %q", tt.n, got, tt.expected)
+		}
+	}
+}
+
+func TestRenderStats(t *testing.T) {
+	db := &database.Database{
+		Name: "stats_db",
+		Tables: []database.Table{
+			{
+				Name:    "events",
+				Columns: []database.Column{{Name: "id", Type: "int"}},
+				Stats:   &database.TableStats{RowCount: 2_500_000, SizeBytes: 3 * 1024 * 1024},
+			},
+			{
+				Name:    "accounts",
+				Columns: []database.Column{{Name: "id", Type: "int"}},
+				Stats:   &database.TableStats{RowCount: 12, SizeBytes: 64 * 1024 * 1024},
+			},
+			{
+				Name:    "audit",
+				Columns: []database.Column{{Name: "id", Type: "int"}},
+			},
+		},
+	}
+
+	g, err := graph.Build(db)
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		format Format
+		sortBy SortOrder
+		order  []string
+	}{
+		{name: "by name", format: FormatText, sortBy: SortByName, order: []string{"accounts", "audit", "events"}},
+		{name: "by rows", format: FormatText, sortBy: SortByRows, order: []string{"events", "accounts", "audit"}},
+		{name: "by size", format: FormatText, sortBy: SortBySize, order: []string{"accounts", "events", "audit"}},
+		{name: "json by rows", format: FormatJSON, sortBy: SortByRows, order: []string{`"events"`, `"accounts"`, `"audit"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := RenderWithOptions(g, tt.format, ShapeFlat, Options{SortBy: tt.sortBy})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			last := -1
+			for _, name := range tt.order {
+				index := strings.Index(output, name)
+				if index <= last {
+					t.Fatalf("expected table order %v in output:\n%s", tt.order, output)
+				}
+				last = index
+			}
+		})
+	}
+
+	t.Run("text shows stats", func(t *testing.T) {
+		output, err := Render(g, FormatText, ShapeFlat)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		for _, want := range []string{"events [~2.5M rows, 3.0 MB]", "accounts [~12 rows, 64.0 MB]", "audit\n"} {
+			if !strings.Contains(output, want) {
+				t.Errorf("expected %q in output:\n%s", want, output)
+			}
+		}
+	})
+
+	t.Run("json shows stats", func(t *testing.T) {
+		output, err := Render(g, FormatJSON, ShapeTree)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !strings.Contains(output, `"rows": 2500000`) || !strings.Contains(output, `"sizeBytes": 3145728`) {
+			t.Errorf("expected stats in JSON output:\n%s", output)
+		}
+	})
+}
+
+func TestRenderMaxDepth(t *testing.T) {
+	// users <- orders <- order_items
+	db := &database.Database{
+		Name: "shop",
+		Tables: []database.Table{
+			{Name: "users", Columns: []database.Column{{Name: "id", Type: "integer"}}},
+			{
+				Name:    "orders",
+				Columns: []database.Column{{Name: "user_id", Type: "integer"}},
+				Constraints: []database.Constraint{
+					{Kind: database.ForeignKey, Columns: []string{"user_id"}, ReferenceTable: "users", ReferenceColumns: []string{"id"}},
+				},
+			},
+			{
+				Name:    "order_items",
+				Columns: []database.Column{{Name: "order_id", Type: "integer"}},
+				Constraints: []database.Constraint{
+					{Kind: database.ForeignKey, Columns: []string{"order_id"}, ReferenceTable: "orders", ReferenceColumns: []string{"id"}},
+				},
+			},
+		},
+	}
+
+	g, err := graph.Build(db)
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+
+	output, err := RenderWithOptions(g, FormatText, ShapeTree, Options{MaxDepth: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(output, "└── orders (+1 more)\n") {
+		t.Errorf("expected orders to report its hidden child:\n%s", output)
+	}
+	if strings.Contains(output, "order_items") {
+		t.Errorf("expected order_items to be hidden by the depth limit:\n%s", output)
+	}
+
+	output, err = RenderWithOptions(g, FormatJSON, ShapeTree, Options{MaxDepth: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(output, `"hiddenChildren": 1`) || strings.Contains(output, `"orders"`) {
+		t.Errorf("expected only users with a hidden child in JSON output:\n%s", output)
+	}
+}
+
+func TestRenderHighlightsChanges(t *testing.T) {
+	db := &database.Database{
+		Name: "shop",
+		Tables: []database.Table{
+			{Name: "users", Columns: []database.Column{{Name: "id", Type: "integer"}, {Name: "email", Type: "text"}}},
+			{Name: "orders", Columns: []database.Column{{Name: "id", Type: "integer"}}},
+		},
+	}
+
+	g, err := graph.Build(db)
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+
+	opts := Options{
+		Changed:   map[string]bool{"users": true, "users.email": true},
+		Highlight: func(s string) string { return "<" + s + ">" },
+	}
+
+	output, err := RenderWithOptions(g, FormatText, ShapeTree, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, expected := range []string{"└── <users>\n", "└── <email (\"text\")>\n", "├── orders\n", "├── id (\"integer\")\n"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected %q in tree output:\n%s", expected, output)
+		}
+	}
+
+	output, err = RenderWithOptions(g, FormatText, ShapeFlat, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, expected := range []string{"<users>\n", "  - <email (text)>\n", "orders\n", "  - id (integer)\n"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected %q in flat output:\n%s", expected, output)
+		}
+	}
+}
+
+func TestRenderChartOptions(t *testing.T) {
+	db := &database.Database{
+		Name: "shop",
+		Tables: []database.Table{
+			{
+				Name:        "users",
+				Columns:     []database.Column{{Name: "id", Type: "integer"}, {Name: "email", Type: "text"}},
+				Constraints: []database.Constraint{{Kind: database.PrimaryKey, Columns: []string{"id"}}},
+			},
+			{
+				Name:    "orders",
+				Columns: []database.Column{{Name: "id", Type: "integer"}, {Name: "user_id", Type: "integer"}},
+				Constraints: []database.Constraint{
+					{Kind: database.PrimaryKey, Columns: []string{"id"}},
+					{Kind: database.ForeignKey, Columns: []string{"user_id"}, ReferenceTable: "users", ReferenceColumns: []string{"id"}},
+				},
+			},
+			{
+				Name:    "shipments",
+				Columns: []database.Column{{Name: "id", Type: "integer"}, {Name: "order_id", Type: "integer"}},
+				Constraints: []database.Constraint{
+					{Kind: database.PrimaryKey, Columns: []string{"id"}},
+					{Kind: database.ForeignKey, Columns: []string{"order_id"}, ReferenceTable: "orders", ReferenceColumns: []string{"id"}},
+				},
+			},
+			{Name: "audit_log", Columns: []database.Column{{Name: "message", Type: "text"}}},
+		},
+	}
+
+	g, err := graph.Build(db)
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+
+	ascii, err := RenderWithOptions(g, FormatText, ShapeChart, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.ContainsAny(ascii, "┌│\033") {
+		t.Errorf("expected plain ascii chart, got:\n%s", ascii)
+	}
+
+	unicode, err := RenderWithOptions(g, FormatText, ShapeChart, Options{Charset: CharsetUnicode})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, expected := range []string{"┌", "│", "orders", "user_id"} {
+		if !strings.Contains(unicode, expected) {
+			t.Errorf("expected %q in unicode chart:\n%s", expected, unicode)
+		}
+	}
+
+	width := chartWidth(ascii) * 3 / 4
+	fitted, err := RenderWithOptions(g, FormatText, ShapeChart, Options{Width: width})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := chartWidth(fitted); got > width {
+		t.Errorf("expected chart at most %d columns wide, got %d:\n%s", width, got, fitted)
+	}
+	for _, table := range []string{"users", "orders", "shipments", "audit_log"} {
+		if !strings.Contains(fitted, table) {
+			t.Errorf("expected %q in fitted chart:\n%s", table, fitted)
+		}
+	}
+
+	for _, charset := range Charsets {
+		colored, err := RenderWithOptions(g, FormatText, ShapeChart, Options{Charset: charset, Color: true})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, expected := range []string{
+			chartPrimaryKeyColor + "id",
+			chartForeignKeyColor + "user_id",
+			chartEdgeColor,
+		} {
+			if !strings.Contains(colored, expected) {
+				t.Errorf("expected %q in %s coloured chart:\n%s", expected, charset, colored)
+			}
+		}
+		if strings.Contains(colored, chartPrimaryKeyColor+"email") || strings.Contains(colored, chartForeignKeyColor+"message") {
+			t.Errorf("expected only key columns coloured:\n%s", colored)
+		}
+	}
+}
+
+func TestRenderDiagrams(t *testing.T) {
+	db := &database.Database{
+		Name: "shop",
+		Tables: []database.Table{
+			{
+				Name:        "users",
+				Columns:     []database.Column{{Name: "id", Type: "integer"}, {Name: "email", Type: "varchar(255)"}},
+				Constraints: []database.Constraint{{Kind: database.PrimaryKey, Columns: []string{"id"}}, {Kind: database.Unique, Columns: []string{"email"}}},
+			},
+			{
+				Name:    "order items",
+				Columns: []database.Column{{Name: "user_id", Type: "numeric(10,2)", IsNullable: true}},
+				Constraints: []database.Constraint{
+					{Kind: database.ForeignKey, Columns: []string{"user_id"}, ReferenceTable: "users", ReferenceColumns: []string{"id"}},
+				},
+			},
+			{
+				Name:    "orders",
+				Columns: []database.Column{{Name: "buyer_id", Type: "integer"}},
+				Constraints: []database.Constraint{
+					{Kind: database.ForeignKey, Columns: []string{"buyer_id"}, ReferenceTable: "users", ReferenceColumns: []string{"id"}},
+				},
+			},
+			{
+				Name:    "profiles",
+				Columns: []database.Column{{Name: "owner_id", Type: "integer"}},
+				Constraints: []database.Constraint{
+					{Kind: database.ForeignKey, Columns: []string{"owner_id"}, ReferenceTable: "users", ReferenceColumns: []string{"id"}},
+					{Kind: database.Unique, Columns: []string{"owner_id"}},
+				},
+			},
+		},
+	}
+
+	g, err := graph.Build(db)
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+
+	tests := []struct {
+		format   Format
+		expected []string
+	}{
+		{FormatMermaid, []string{
+			"erDiagram\n",
+			"    users {\n        integer id PK\n        varchar(255) email UK\n    }\n",
+			"        numeric(10_2) user_id FK\n",
+			`    users |o--o{ order_items : "user_id"` + "\n",
+			`    users ||--o{ orders : "buyer_id"` + "\n",
+			`    users ||--o| profiles : "owner_id"` + "\n",
+		}},
+		{FormatPlantUML, []string{
+			"@startuml\nhide circle\n",
+			"entity \"users\" as users {\n  * id : integer <<PK>>\n  --\n  * email : varchar(255) <<UK>>\n}\n",
+			"entity \"order items\" as order_items {\n  user_id : numeric(10,2) <<FK>>\n}\n",
+			// A nullable, a NOT NULL and a unique foreign key
+			"users |o--o{ order_items : user_id\n",
+			"users ||--o{ orders : buyer_id\n",
+			"users ||--o| profiles : owner_id\n",
+			"@enduml\n",
+		}},
+		{FormatDOT, []string{
+			"digraph \"shop\" {\n",
+			`<tr><td port="c1" align="left">email <i>varchar(255)</i> UK</td></tr>`,
+			"  \"order items\":c0 -> \"users\":c0;\n",
+		}},
+		{FormatMarkdown, []string{"# shop\n\n## Tables\n\n- [order items](#order-items)\n- [orders](#orders)\n- [profiles](#profiles)\n- [users](#users)\n"}},
+		{FormatSVG, []string{"<svg", "users"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			// Diagram formats ignore the shape
+			output, err := RenderWithOptions(g, tt.format, ShapeTree, Options{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, expected := range tt.expected {
+				if !strings.Contains(output, expected) {
+					t.Errorf("expected %q in output:\n%s", expected, output)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderMarkdownDataDictionary(t *testing.T) {
+	db := &database.Database{
+		Name: "shop",
+		Tables: []database.Table{
+			{
+				Name:    "users",
+				Comment: "Registered customers",
+				Columns: []database.Column{
+					{Name: "id", Type: "integer"},
+					{Name: "email", Type: "varchar(255)", Comment: "Login | contact address"},
+					{Name: "created_at", Type: "timestamp", DefaultValue: "now()"},
+				},
+				Constraints: []database.Constraint{{Kind: database.PrimaryKey, Columns: []string{"id"}}},
+			},
+			{
+				Name:    "orders",
+				Columns: []database.Column{{Name: "id", Type: "integer"}, {Name: "user_id", Type: "integer", IsNullable: true}},
+				Constraints: []database.Constraint{
+					{Kind: database.PrimaryKey, Columns: []string{"id"}},
+					{Kind: database.ForeignKey, Columns: []string{"user_id"}, ReferenceTable: "users", ReferenceColumns: []string{"id"}},
+				},
+			},
+			{
+				Name:    "Tables",
+				Columns: []database.Column{{Name: "id", Type: "integer"}},
+			},
+		},
+	}
+
+	g, err := graph.Build(db)
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+
+	output, err := Render(g, FormatMarkdown, ShapeTree)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, expected := range []string{
+		// Table of contents, with the first line of table comments, and an
+		// anchor that does not clash with the contents heading
+		"## Tables\n\n- [Tables](#tables-1)\n- [orders](#orders)\n- [users](#users) — Registered customers\n",
+		"## users\n\nRegistered customers\n\n| Column | Type | Nullable | Default | Keys | Comment |\n",
+		"| id | `integer` | no |  | PK |  |\n",
+		"| email | `varchar(255)` | no |  |  | Login \\| contact address |\n",
+		"| created_at | `timestamp` | no | `now()` |  |  |\n",
+		"**Referenced by**\n\n- [orders](#orders) via `orders.user_id` → `id`\n",
+		"| user_id | `integer` | yes |  | FK |  |\n",
+		"**References**\n\n- [users](#users) via `user_id` → `users.id`\n",
+		"    users |o--o{ orders : \"user_id\"\n",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected %q in output:\n%s", expected, output)
+		}
+	}
+
+	// The neighborhood diagram of an unrelated table only draws that table
+	section := output[strings.Index(output, "## Tables\n\n| Column"):]
+	if strings.Contains(section[:strings.Index(section, "## orders")], "users") {
+		t.Errorf("expected the diagram of Tables to leave out other tables:\n%s", section)
+	}
+}
+
+func TestRenderDBML(t *testing.T) {
+	db := &database.Database{
+		Name: "shop",
+		Tables: []database.Table{
+			{
+				Name:    "order items",
+				Comment: "Lines of an order\nwith their quantity",
+				Columns: []database.Column{
+					{Name: "order_id", Type: "integer"},
+					{Name: "line", Type: "integer", DefaultValue: "nextval('item_lines'::regclass)"},
+					{Name: "batch", Type: "bigserial"},
+					{Name: "product_code", Type: "character varying(20)", IsNullable: true},
+					{Name: "quantity", Type: "numeric(10,2)", DefaultValue: "1"},
+				},
+				Constraints: []database.Constraint{
+					{Kind: database.PrimaryKey, Columns: []string{"order_id", "line"}},
+					{Kind: database.Check, CheckExpression: "quantity > 0"},
+					{Kind: database.ForeignKey, Columns: []string{"order_id"}, ReferenceTable: "orders", ReferenceColumns: []string{"id"}},
+				},
+				Indexes: []database.Index{{Name: "items_product_idx", Columns: []string{"lower(product_code)"}}},
+			},
+			{
+				Name: "orders",
+				Columns: []database.Column{
+					{Name: "id", Type: "integer", DefaultValue: "nextval('orders_id_seq'::regclass)"},
+					{Name: "status", Type: "varchar(20)", DefaultValue: "'it''s new'", Comment: "Order's status"},
+					{Name: "state", Type: "order_state", IsNullable: true, EnumValues: []string{"new", "it's shipped"}},
+					{Name: "created_at", Type: "timestamp", IsNullable: true, DefaultValue: "now()"},
+					{Name: "code", Type: "text"},
+				},
+				Constraints: []database.Constraint{
+					{Kind: database.PrimaryKey, Columns: []string{"id"}},
+					{Kind: database.Unique, Columns: []string{"code"}},
+				},
+			},
+		},
+	}
+
+	g, err := graph.Build(db)
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+
+	output, err := Render(g, FormatDBML, ShapeTree)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, expected := range []string{
+		"Project shop {\n}\n\nEnum order_state {\n  new\n  \"it's shipped\"\n}\n",
+		"Table \"order items\" {\n",
+		"  line integer [increment, not null, default: `nextval('item_lines'::regclass)`]\n",
+		"  batch bigserial [increment, not null]\n",
+		"  product_code \"character varying(20)\"\n",
+		"  quantity numeric(10,2) [not null, default: 1]\n",
+		"    (order_id, line) [pk]\n    `lower(product_code)` [name: 'items_product_idx']\n",
+		"  checks {\n    `quantity > 0`\n  }\n",
+		"  Note: '''\nLines of an order\nwith their quantity\n'''\n",
+		"  id integer [pk, increment]\n",
+		"  state order_state\n",
+		"  status varchar(20) [not null, default: 'it\\'s new', note: 'Order\\'s status']\n",
+		"  created_at timestamp [default: `now()`]\n",
+		"  code text [not null, unique]\n",
+		"Ref: \"order items\".order_id > orders.id\n",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected %q in output:\n%s", expected, output)
+		}
+	}
+
+	// The output reads back as the same schema
+	parsed, err := database.ParseDBML(output)
+	if err != nil {
+		t.Fatalf("failed to parse the output: %v\n%s", err, output)
+	}
+	if !reflect.DeepEqual(parsed, db) {
+		t.Errorf("expected %+v, got %+v", db, parsed)
+	}
+
+	// PostgreSQL enums inspected as USER-DEFINED are named after their column
+	g, err = graph.Build(&database.Database{Tables: []database.Table{{
+		Name:    "payments",
+		Columns: []database.Column{{Name: "state", Type: "USER-DEFINED", EnumValues: []string{"new", "done"}}},
+	}}})
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+	output, err = Render(g, FormatDBML, ShapeTree)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "Enum payments_state {\n  new\n  done\n}\n\nTable payments {\n  state payments_state [not null]\n}\n"
+	if output != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, output)
+	}
+}
+
+func TestRenderSQL(t *testing.T) {
+	// A schema as the PostgreSQL inspector reports it, with a cycle between
+	// departments and employees
+	db := &database.Database{
+		Name: "hr",
+		Tables: []database.Table{
+			{
+				Name: "employees",
+				Columns: []database.Column{
+					{Name: "id", Type: "bigint", DefaultValue: "nextval('employees_id_seq'::regclass)"},
+					{Name: "department_id", Type: "integer", IsNullable: true},
+					{Name: "manager_id", Type: "bigint", IsNullable: true},
+					{Name: "status", Type: "varchar(20)", DefaultValue: "'active'::character varying"},
+					{Name: "hired_at", Type: "timestamptz", IsNullable: true, DefaultValue: "now()"},
+					{Name: "order", Type: "ARRAY", IsNullable: true},
+				},
+				Constraints: []database.Constraint{
+					{Kind: database.ForeignKey, Columns: []string{"department_id"}, ReferenceTable: "departments", ReferenceColumns: []string{"id"}},
+					{Kind: database.ForeignKey, Columns: []string{"manager_id"}, ReferenceTable: "employees", ReferenceColumns: []string{"id"}},
+					{Kind: database.Check, CheckExpression: "((status)::text = ANY ((ARRAY['active'::character varying, 'gone'::character varying])::text[]))"},
+					{Kind: database.PrimaryKey, Columns: []string{"id"}},
+				},
+			},
+			{
+				Name: "departments",
+				Columns: []database.Column{
+					{Name: "id", Type: "integer"},
+					{Name: "head_id", Type: "bigint", IsNullable: true},
+					{Name: "budget", Type: "numeric(12,2)", DefaultValue: "0"},
+				},
+				Constraints: []database.Constraint{
+					{Kind: database.PrimaryKey, Columns: []string{"id"}},
+					{Kind: database.ForeignKey, Columns: []string{"head_id"}, ReferenceTable: "employees", ReferenceColumns: []string{"id"}},
+				},
+			},
+			{
+				Name: "Badges",
+				Columns: []database.Column{
+					{Name: "employee_id", Type: "bigint"},
+					{Name: "active", Type: "tinyint(1)", DefaultValue: "1"},
+					{Name: "payload", Type: "bytea", IsNullable: true},
+				},
+				Constraints: []database.Constraint{
+					{Kind: database.ForeignKey, Columns: []string{"employee_id"}, ReferenceTable: "employees", ReferenceColumns: []string{"id"}},
+					{Kind: database.Unique, Columns: []string{"employee_id"}},
+				},
+			},
+		},
+	}
+
+	g, err := graph.Build(db)
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+
+	tests := []struct {
+		dialect  Dialect
+		expected string
+	}{
+		{DialectPostgres, `CREATE TABLE departments (
+    id integer NOT NULL,
+    head_id bigint,
+    budget numeric(12,2) NOT NULL DEFAULT 0,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE employees (
+    id bigserial NOT NULL,
+    department_id integer,
+    manager_id bigint,
+    status varchar(20) NOT NULL DEFAULT 'active',
+    hired_at timestamptz DEFAULT now(),
+    "order" text[],
+    PRIMARY KEY (id),
+    CHECK (((status)::text = ANY ((ARRAY['active'::character varying, 'gone'::character varying])::text[]))),
+    FOREIGN KEY (department_id) REFERENCES departments (id),
+    FOREIGN KEY (manager_id) REFERENCES employees (id)
+);
+
+CREATE TABLE "Badges" (
+    employee_id bigint NOT NULL,
+    active boolean NOT NULL DEFAULT true,
+    payload bytea,
+    UNIQUE (employee_id),
+    FOREIGN KEY (employee_id) REFERENCES employees (id)
+);
+
+ALTER TABLE departments ADD FOREIGN KEY (head_id) REFERENCES employees (id);
+`},
+		{DialectMySQL, `CREATE TABLE departments (
+    id int NOT NULL,
+    head_id bigint,
+    budget decimal(12,2) NOT NULL DEFAULT 0,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE employees (
+    id bigint NOT NULL AUTO_INCREMENT,
+    department_id int,
+    manager_id bigint,
+    status varchar(20) NOT NULL DEFAULT 'active',
+    hired_at datetime DEFAULT CURRENT_TIMESTAMP,
+    ` + "`order`" + ` json,
+    PRIMARY KEY (id),
+    CHECK (((status) IN ('active', 'gone'))),
+    FOREIGN KEY (department_id) REFERENCES departments (id),
+    FOREIGN KEY (manager_id) REFERENCES employees (id)
+);
+
+CREATE TABLE ` + "`Badges`" + ` (
+    employee_id bigint NOT NULL,
+    active boolean NOT NULL DEFAULT 1,
+    payload longblob,
+    UNIQUE (employee_id),
+    FOREIGN KEY (employee_id) REFERENCES employees (id)
+);
+
+ALTER TABLE departments ADD FOREIGN KEY (head_id) REFERENCES employees (id);
+`},
+		// SQLite cannot add foreign keys later, but accepts forward references
+		{DialectSQLite, `CREATE TABLE departments (
+    id INTEGER NOT NULL,
+    head_id INTEGER,
+    budget NUMERIC NOT NULL DEFAULT 0,
+    PRIMARY KEY (id),
+    FOREIGN KEY (head_id) REFERENCES employees (id)
+);
+
+CREATE TABLE employees (
+    id INTEGER NOT NULL,
+    department_id INTEGER,
+    manager_id INTEGER,
+    status TEXT NOT NULL DEFAULT 'active',
+    hired_at TEXT DEFAULT CURRENT_TIMESTAMP,
+    "order" TEXT,
+    PRIMARY KEY (id),
+    CHECK (((status) IN ('active', 'gone'))),
+    FOREIGN KEY (department_id) REFERENCES departments (id),
+    FOREIGN KEY (manager_id) REFERENCES employees (id)
+);
+
+CREATE TABLE "Badges" (
+    employee_id INTEGER NOT NULL,
+    active INTEGER NOT NULL DEFAULT 1,
+    payload BLOB,
+    UNIQUE (employee_id),
+    FOREIGN KEY (employee_id) REFERENCES employees (id)
+);
+`},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.dialect), func(t *testing.T) {
+			output, err := RenderWithOptions(g, FormatSQL, ShapeTree, Options{Dialect: tt.dialect})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if output != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, output)
+			}
+		})
+	}
+
+	if _, err := RenderWithOptions(g, FormatSQL, ShapeTree, Options{Dialect: "oracle"}); err == nil {
+		t.Error("expected an error for an unsupported dialect")
+	}
+}
+
+func TestRenderSQLEnums(t *testing.T) {
+	// Enums as the DBML parser, the PostgreSQL inspector and the MySQL
+	// inspector report them
+	db := &database.Database{
+		Name: "shop",
+		Tables: []database.Table{
+			{
+				Name: "orders",
+				Columns: []database.Column{
+					{Name: "status", Type: "order_status", DefaultValue: "'pending'", EnumValues: []string{"pending", "shipped"}},
+					{Name: "channel", Type: "USER-DEFINED", IsNullable: true, EnumValues: []string{"web", "shop's"}},
+					{Name: "size", Type: "enum('s','m')", EnumValues: []string{"s", "m"}},
+					{Name: "flags", Type: "set('a','b')", IsNullable: true, EnumValues: []string{"a", "b"}},
+				},
+				Constraints: []database.Constraint{
+					{Kind: database.Check, CheckExpression: "size IN ('s', 'm')"},
+				},
+			},
+			{
+				Name: "returns",
+				Columns: []database.Column{
+					{Name: "status", Type: "order_status", EnumValues: []string{"pending", "shipped"}},
+				},
+			},
+		},
+	}
+
+	g, err := graph.Build(db)
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+
+	tests := []struct {
+		dialect  Dialect
+		expected string
+	}{
+		{DialectPostgres, `CREATE TYPE order_status AS ENUM ('pending', 'shipped');
+CREATE TYPE orders_channel AS ENUM ('web', 'shop''s');
+CREATE TYPE orders_size AS ENUM ('s', 'm');
+
+CREATE TABLE orders (
+    status order_status NOT NULL DEFAULT 'pending',
+    channel orders_channel,
+    size orders_size NOT NULL,
+    flags text,
+    CHECK (size IN ('s', 'm'))
+);
+
+CREATE TABLE returns (
+    status order_status NOT NULL
+);
+`},
+		{DialectMySQL, `CREATE TABLE orders (
+    status enum('pending', 'shipped') NOT NULL DEFAULT 'pending',
+    channel enum('web', 'shop''s'),
+    size enum('s', 'm') NOT NULL,
+    flags set('a','b'),
+    CHECK (size IN ('s', 'm'))
+);
+
+CREATE TABLE returns (
+    status enum('pending', 'shipped') NOT NULL
+);
+`},
+		// A check on the table already lists the values of size
+		{DialectSQLite, `CREATE TABLE orders (
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'shipped')),
+    channel TEXT CHECK (channel IN ('web', 'shop''s')),
+    size TEXT NOT NULL,
+    flags TEXT,
+    CHECK (size IN ('s', 'm'))
+);
+
+CREATE TABLE returns (
+    status TEXT NOT NULL CHECK (status IN ('pending', 'shipped'))
+);
+`},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.dialect), func(t *testing.T) {
+			output, err := RenderWithOptions(g, FormatSQL, ShapeTree, Options{Dialect: tt.dialect})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if output != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestSQLDefault(t *testing.T) {
+	tests := []struct {
+		value    string
+		dialect  Dialect
+		expected string
+	}{
+		{"active", DialectPostgres, "'active'"}, // MySQL reports strings without quotes
+		{"it's", DialectSQLite, "'it''s'"},
+		{"CURRENT_TIMESTAMP(6)", DialectMySQL, "CURRENT_TIMESTAMP(6)"},
+		{"CURRENT_TIMESTAMP(6)", DialectSQLite, "CURRENT_TIMESTAMP"},
+		{"(datetime('now'))", DialectPostgres, "(datetime('now'))"},
+		{"gen_random_uuid()", DialectMySQL, "(gen_random_uuid())"},
+		{"'{}'::jsonb", DialectSQLite, "'{}'"},
+		{"DEFAULT: now()", DialectPostgres, "now()"},
+		{"MATERIALIZED: now()", DialectPostgres, ""},
+		{"-1.5", DialectMySQL, "-1.5"},
+		{"null", DialectSQLite, "NULL"},
+	}
+
+	for _, tt := range tests {
+		got, serial := sqlDefault(tt.value, parseSQLType("text"), tt.dialect)
+		if got != tt.expected || serial {
+			t.Errorf("sqlDefault(%q, %s) = %q, %v; expected %q", tt.value, tt.dialect, got, serial, tt.expected)
+		}
+	}
+}
+
+func TestRenderGo(t *testing.T) {
+	db := &database.Database{
+		Name: "shop",
+		Tables: []database.Table{
+			{
+				Name:    "users",
+				Comment: "Registered customers",
+				Columns: []database.Column{
+					{Name: "id", Type: "bigint"},
+					{Name: "email", Type: "character varying(255)", Comment: "Login address"},
+					{Name: "avatarURL", Type: "text", IsNullable: true},
+					{Name: "created_at", Type: "timestamp with time zone"},
+				},
+				Constraints: []database.Constraint{
+					{Kind: database.PrimaryKey, Columns: []string{"id"}},
+				},
+			},
+			{
+				Name: "order_items",
+				Columns: []database.Column{
+					{Name: "user_id", Type: "bigint", IsNullable: true},
+					{Name: "quantity", Type: "UInt16"},
+					{Name: "price", Type: "decimal(10,2)", IsNullable: true},
+					{Name: "weight", Type: "real", IsNullable: true},
+					{Name: "tags", Type: "text[]", IsNullable: true},
+					{Name: "attrs", Type: "jsonb", IsNullable: true},
+				},
+				Constraints: []database.Constraint{
+					{Kind: database.ForeignKey, Columns: []string{"user_id"}, ReferenceTable: "users", ReferenceColumns: []string{"id"}},
+				},
+			},
+		},
+	}
+
+	g, err := graph.Build(db)
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		opts     GoOptions
+		expected string
+	}{
+		{"defaults", GoOptions{}, `// Code generated by dbtree. DO NOT EDIT.
+
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// OrderItems is a row of the order_items table.
+type OrderItems struct {
+	UserID   *int64          ` + "`db:\"user_id\" json:\"user_id\"`" + ` // references users.id
+	Quantity uint16          ` + "`db:\"quantity\" json:\"quantity\"`" + `
+	Price    *string         ` + "`db:\"price\" json:\"price\"`" + `
+	Weight   *float32        ` + "`db:\"weight\" json:\"weight\"`" + `
+	Tags     []string        ` + "`db:\"tags\" json:\"tags\"`" + `
+	Attrs    json.RawMessage ` + "`db:\"attrs\" json:\"attrs\"`" + `
+}
+
+// Users is a row of the users table.
+// Registered customers
+//
+// Referenced by:
+//   - order_items.user_id
+type Users struct {
+	ID int64 ` + "`db:\"id\" json:\"id\"`" + `
+	// Login address
+	Email     string    ` + "`db:\"email\" json:\"email\"`" + `
+	AvatarURL *string   ` + "`db:\"avatarURL\" json:\"avatar_url\"`" + `
+	CreatedAt time.Time ` + "`db:\"created_at\" json:\"created_at\"`" + `
+}
+`},
+		{"sql null types", GoOptions{Package: "schema", Naming: NamingCamel, Nullable: NullSQL}, `// Code generated by dbtree. DO NOT EDIT.
+
+package schema
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// OrderItems is a row of the order_items table.
+type OrderItems struct {
+	UserID   sql.NullInt64     ` + "`db:\"user_id\" json:\"userId\"`" + ` // references users.id
+	Quantity uint16            ` + "`db:\"quantity\" json:\"quantity\"`" + `
+	Price    sql.NullString    ` + "`db:\"price\" json:\"price\"`" + `
+	Weight   sql.Null[float32] ` + "`db:\"weight\" json:\"weight\"`" + `
+	Tags     []string          ` + "`db:\"tags\" json:\"tags\"`" + `
+	Attrs    json.RawMessage   ` + "`db:\"attrs\" json:\"attrs\"`" + `
+}
+
+// Users is a row of the users table.
+// Registered customers
+//
+// Referenced by:
+//   - order_items.user_id
+type Users struct {
+	ID int64 ` + "`db:\"id\" json:\"id\"`" + `
+	// Login address
+	Email     string         ` + "`db:\"email\" json:\"email\"`" + `
+	AvatarURL sql.NullString ` + "`db:\"avatarURL\" json:\"avatarUrl\"`" + `
+	CreatedAt time.Time      ` + "`db:\"created_at\" json:\"createdAt\"`" + `
+}
+`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := RenderWithOptions(g, FormatGo, ShapeTree, Options{Go: tt.opts})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if output != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, output)
+			}
+		})
+	}
+
+	if _, err := RenderWithOptions(g, FormatGo, ShapeTree, Options{Go: GoOptions{Package: "my-models"}}); err == nil {
+		t.Error("expected an error for an invalid package name")
+	}
+}
+
+func TestRenderGoEnums(t *testing.T) {
+	// Enums declared as their own type, as the DBML parser reports them, or
+	// constrained by a check
+	db := &database.Database{
+		Tables: []database.Table{{
+			Name: "orders",
+			Columns: []database.Column{
+				{Name: "status", Type: "order_status", EnumValues: []string{"pending", "shipped"}},
+				{Name: "mood", Type: "mood", IsNullable: true},
+				{Name: "level", Type: "level"},
+				{Name: "shape", Type: "geometry"},
+			},
+			Constraints: []database.Constraint{
+				{Kind: database.Check, CheckExpression: "mood IN ('happy', 'sad')"},
+				{Kind: database.Check, CheckExpression: "level IN (1, 2)"},
+			},
+		}},
+	}
+
+	g, err := graph.Build(db)
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+
+	output, err := RenderWithOptions(g, FormatGo, ShapeTree, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, expected := range []string{
+		"\tStatus string  ",
+		"\tMood   *string ",
+		// Only lists of strings make a string
+		"\tLevel  any     ",
+		"\tShape  any     ",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected %q in output:\n%s", expected, output)
+		}
+	}
+}
+
+func TestGoIdentifier(t *testing.T) {
+	for name, expected := range map[string]string{
+		"user_id":      "UserID",
+		"userId":       "UserID",
+		"HTTPServer":   "HTTPServer",
+		"api-key":      "APIKey",
+		"ORDER_TOTAL":  "OrderTotal",
+		"2fa_enabled":  "X2faEnabled",
+		"sales.orders": "SalesOrders",
+		"":             "X",
+	} {
+		if got := goIdentifier(name); got != expected {
+			t.Errorf("goIdentifier(%q) = %q; expected %q", name, got, expected)
+		}
+	}
+}
+
+// typesTestGraph is a schema as the PostgreSQL inspector reports it, with an
+// enum, checks and a foreign key.
+func typesTestGraph(t *testing.T) *graph.SchemaGraph {
+	db := &database.Database{
+		Name: "shop",
+		Tables: []database.Table{
+			{
+				Name:    "users",
+				Comment: "Registered customers",
+				Columns: []database.Column{
+					{Name: "id", Type: "bigint"},
+					{Name: "email", Type: "character varying(255)", Comment: "Login address"},
+					{Name: "age", Type: "integer", IsNullable: true},
+				},
+				Constraints: []database.Constraint{
+					{Kind: database.PrimaryKey, Columns: []string{"id"}},
+					{Kind: database.Check, CheckExpression: "((age >= 18) AND (age < 150))"},
+				},
+			},
+			{
+				Name: "orders",
+				Columns: []database.Column{
+					{Name: "user_id", Type: "bigint", IsNullable: true},
+					{Name: "status", Type: "USER-DEFINED", EnumValues: []string{"pending", "shipped"}},
+					{Name: "kind", Type: "character varying(10)", IsNullable: true},
+					{Name: "price", Type: "numeric(10,2)"},
+					{Name: "placed at", Type: "timestamp with time zone"},
+					{Name: "tags", Type: "ARRAY", IsNullable: true},
+				},
+				Constraints: []database.Constraint{
+					{Kind: database.ForeignKey, Columns: []string{"user_id"}, ReferenceTable: "users", ReferenceColumns: []string{"id"}},
+					{Kind: database.Check, CheckExpression: "((kind)::text = ANY ((ARRAY['a'::character varying, 'b'::character varying])::text[]))"},
+					{Kind: database.Check, CheckExpression: "(price > (0)::numeric)"},
+				},
+			},
+		},
+	}
+
+	g, err := graph.Build(db)
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+	return g
+}
+
+func TestRenderTypeScript(t *testing.T) {
+	output, err := Render(typesTestGraph(t), FormatTypeScript, ShapeTree)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `// Code generated by dbtree. DO NOT EDIT.
+
+export interface Orders {
+  /** References users.id */
+  user_id: Users["id"] | null;
+  status: "pending" | "shipped";
+  /** @maxLength 10 */
+  kind: "a" | "b" | null;
+  price: string;
+  "placed at": string;
+  tags: string[] | null;
+}
+
+/** Registered customers */
+export interface Users {
+  id: number;
+  /**
+   * Login address
+   * @maxLength 255
+   */
+  email: string;
+  /**
+   * @minimum 18
+   * @exclusiveMaximum 150
+   */
+  age: number | null;
+}
+`
+	if output != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, output)
+	}
+}
+
+func TestRenderJSONSchema(t *testing.T) {
+	output, err := Render(typesTestGraph(t), FormatJSONSchema, ShapeTree)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var schema struct {
+		Schema string `json:"$schema"`
+		Title  string `json:"title"`
+		Defs   map[string]struct {
+			Type        string                     `json:"type"`
+			Description string                     `json:"description"`
+			Properties  map[string]json.RawMessage `json:"properties"`
+			Required    []string                   `json:"required"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal([]byte(output), &schema); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, output)
+	}
+
+	if schema.Schema != jsonSchemaDialect || schema.Title != "shop" {
+		t.Errorf("unexpected header: %s, %s", schema.Schema, schema.Title)
+	}
+	if users := schema.Defs["users"]; users.Type != "object" || users.Description != "Registered customers" {
+		t.Errorf("unexpected users definition: %+v", users)
+	}
+	if required := schema.Defs["orders"].Required; !reflect.DeepEqual(required, []string{"user_id", "status", "kind", "price", "placed at", "tags"}) {
+		t.Errorf("unexpected required columns: %v", required)
+	}
+
+	for column, expected := range map[string]string{
+		"users.email":      `{"type":"string","maxLength":255,"description":"Login address"}`,
+		"users.age":        `{"type":["integer","null"],"minimum":18,"exclusiveMaximum":150}`,
+		"orders.user_id":   `{"anyOf":[{"$ref":"#/$defs/users/properties/id"},{"type":"null"}],"description":"References users.id."}`,
+		"orders.status":    `{"type":"string","enum":["pending","shipped"]}`,
+		"orders.kind":      `{"type":["string","null"],"enum":["a","b",null],"maxLength":10}`,
+		"orders.price":     `{"type":"string","pattern":"^-?[0-9]+(\\.[0-9]+)?$"}`,
+		"orders.placed at": `{"type":"string","format":"date-time"}`,
+		"orders.tags":      `{"type":["array","null"],"items":{"type":"string"}}`,
+	} {
+		table, name, _ := strings.Cut(column, ".")
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, schema.Defs[table].Properties[name]); err != nil {
+			t.Fatalf("invalid schema of %s: %v", column, err)
+		}
+		if compact.String() != expected {
+			t.Errorf("expected %s to be %s, got %s", column, expected, compact.String())
+		}
+	}
+}
+
+func TestCheckRules(t *testing.T) {
+	table := &database.Table{
+		Columns: []database.Column{{Name: "qty"}, {Name: "code"}, {Name: "rank"}, {Name: "note"}},
+		Constraints: []database.Constraint{
+			{Kind: database.Check, CheckExpression: "(`qty` between 1 and 99)"},
+			{Kind: database.Check, CheckExpression: "char_length(code) <= 8 AND code <> ''"},
+			{Kind: database.Check, CheckExpression: "0 < rank"},
+			{Kind: database.Check, CheckExpression: "rank IN (1, 2, 3)"},
+			{Kind: database.Check, CheckExpression: "note IS NULL OR length(note) < 10"},
+			{Kind: database.Check, CheckExpression: "missing > 0"},
+		},
+	}
+
+	expected := map[string]*columnRules{
+		"qty":  {Minimum: "1", Maximum: "99"},
+		"code": {MinLength: 1, MaxLength: 8},
+		"rank": {ExclusiveMinimum: "0", Enum: []any{json.Number("1"), json.Number("2"), json.Number("3")}},
+	}
+	if got := checkRules(table); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+}
+
+func TestFormatForPath(t *testing.T) {
+	for path, expected := range map[string]Format{
+		"schema.txt":       FormatText,
+		"out/schema.JSON":  FormatJSON,
+		"README.md":        FormatMarkdown,
+		"schema.mmd":       FormatMermaid,
+		"docs/er.puml":     FormatPlantUML,
+		"schema.dot":       FormatDOT,
+		"schema.svg":       FormatSVG,
+		"design.dbml":      FormatDBML,
+		"fixtures.sql":     FormatSQL,
+		"models.go":        FormatGo,
+		"api/rows.ts":      FormatTypeScript,
+		"rows.schema.json": FormatJSONSchema,
+		"schema.json":      FormatJSON,
+	} {
+		format, err := FormatForPath(path)
+		if err != nil || format != expected {
+			t.Errorf("expected %s for %s, got %s (err %v)", expected, path, format, err)
+		}
+		if ext := format.Extension(); !strings.HasSuffix(strings.ToLower(path), ext) {
+			t.Errorf("expected extension of %s to match %s, got %s", format, path, ext)
+		}
+	}
+
+	if _, err := FormatForPath("schema.png"); err == nil {
+		t.Error("expected an error for an unknown extension")
+	}
+}
@@ -0,0 +1,719 @@
+package render
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/viveknathani/dbtree/database"
+	"github.com/viveknathani/dbtree/graph"
+)
+
+// Dialect is the SQL dialect the sql format writes DDL in.
+type Dialect string
+
+const (
+	DialectPostgres Dialect = "postgres"
+	DialectMySQL    Dialect = "mysql"
+	DialectSQLite   Dialect = "sqlite"
+)
+
+// Dialects lists the dialects the sql format supports.
+var Dialects = []Dialect{DialectPostgres, DialectMySQL, DialectSQLite}
+
+var (
+	// sqlBareIdentifier matches identifiers that need no quoting.
+	sqlBareIdentifier = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+	// sqlNumber matches numeric literals.
+	sqlNumber = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+	// sqlCast matches PostgreSQL casts such as ::text, ::character varying(20)
+	// or ::text[].
+	sqlCast = regexp.MustCompile(`::[a-z_][a-z0-9_]*( [a-z_][a-z0-9_]*)*(\([0-9, ]+\))?(\[\])*`)
+	// sqlAnyArray matches "= ANY (ARRAY[...])", which PostgreSQL writes for
+	// IN lists, once casts are removed.
+	sqlAnyArray = regexp.MustCompile(`= ANY \(\(?ARRAY\[(.*?)\]\)?\)`)
+	// sqlSimpleType matches type names SQLite can parse.
+	sqlSimpleType = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_ ]*(\([0-9, ]+\))?$`)
+	// clickhouseInteger matches ClickHouse integer types such as UInt32.
+	clickhouseInteger = regexp.MustCompile(`^U?Int(8|16|32|64|128|256)$`)
+)
+
+// sqlReserved lists common reserved words of the three dialects, which are
+// quoted when used as names.
+var sqlReserved = map[string]bool{
+	"all": true, "alter": true, "and": true, "any": true, "as": true, "asc": true,
+	"between": true, "by": true, "case": true, "check": true, "column": true,
+	"constraint": true, "create": true, "cross": true, "current_date": true,
+	"current_time": true, "current_timestamp": true, "current_user": true,
+	"default": true, "delete": true, "desc": true, "distinct": true, "drop": true,
+	"else": true, "end": true, "except": true, "exists": true, "false": true,
+	"fetch": true, "for": true, "foreign": true, "from": true, "full": true,
+	"grant": true, "group": true, "having": true, "in": true, "index": true,
+	"inner": true, "insert": true, "intersect": true, "interval": true, "into": true,
+	"is": true, "join": true, "key": true, "left": true, "like": true, "limit": true,
+	"natural": true, "not": true, "null": true, "offset": true, "on": true,
+	"or": true, "order": true, "outer": true, "primary": true, "range": true,
+	"read": true, "references": true, "right": true, "rows": true, "select": true,
+	"set": true, "table": true, "then": true, "to": true, "true": true,
+	"union": true, "unique": true, "update": true, "user": true, "using": true,
+	"values": true, "when": true, "where": true, "window": true, "with": true,
+}
+
+// renderSQL writes CREATE TABLE statements for the schema graph in a dialect,
+// with referenced tables created before the tables referencing them. Foreign
+// keys that close a cycle are added afterwards with ALTER TABLE, except on
+// SQLite, which cannot add constraints to existing tables but accepts
+// references to tables that do not exist yet. Enums are created as types on
+// PostgreSQL, declared inline on MySQL and checked on SQLite.
+func renderSQL(g *graph.SchemaGraph, dialect Dialect) (string, error) {
+	if dialect == "" {
+		dialect = DialectPostgres
+	}
+	if dialect != DialectPostgres && dialect != DialectMySQL && dialect != DialectSQLite {
+		return "", fmt.Errorf("unsupported SQL dialect: %s", dialect)
+	}
+
+	order, deferred := sqlTableOrder(g)
+	if dialect == DialectSQLite {
+		deferred = nil
+	}
+
+	var sb strings.Builder
+	var enumTypes map[string]string
+	if dialect == DialectPostgres {
+		var enums []sqlEnum
+		enumTypes, enums = sqlEnumTypes(g, order)
+		for _, enum := range enums {
+			fmt.Fprintf(&sb, "CREATE TYPE %s AS ENUM (%s);\n", sqlIdentifier(enum.name, dialect), sqlStrings(enum.values))
+		}
+		if len(enums) > 0 {
+			sb.WriteString("\n")
+		}
+	}
+
+	for i, tableName := range order {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		writeCreateTable(&sb, g, g.Nodes[tableName], dialect, deferred[tableName], enumTypes)
+	}
+
+	first := true
+	for _, tableName := range order {
+		for _, constraint := range deferred[tableName] {
+			if first {
+				sb.WriteString("\n")
+				first = false
+			}
+			fmt.Fprintf(&sb, "ALTER TABLE %s ADD %s;\n", sqlIdentifier(string(tableName), dialect), sqlForeignKey(constraint, dialect))
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// sqlTableOrder orders the tables so that referenced tables come first. When
+// the remaining tables all wait on each other, the table on a cycle with the
+// fewest references to tables not created yet is taken, and those references
+// are returned as deferred foreign keys. Self-references never need deferring.
+func sqlTableOrder(g *graph.SchemaGraph) ([]graph.TableName, map[graph.TableName][]database.Constraint) {
+	created := make(map[graph.TableName]bool, len(g.Nodes))
+	remaining := getSortedTableNames(g)
+	var order []graph.TableName
+	deferred := make(map[graph.TableName][]database.Constraint)
+
+	// pending returns the foreign keys of a table to tables not created yet
+	pending := func(tableName graph.TableName) []database.Constraint {
+		var constraints []database.Constraint
+		for _, constraint := range g.Nodes[tableName].Constraints {
+			target := graph.TableName(constraint.ReferenceTable)
+			if constraint.Kind == database.ForeignKey && target != tableName && g.Nodes[target] != nil && !created[target] {
+				constraints = append(constraints, constraint)
+			}
+		}
+		return constraints
+	}
+
+	// onCycle reports whether a table can reach itself through references to
+	// tables not created yet
+	onCycle := func(start graph.TableName) bool {
+		visited := make(map[graph.TableName]bool)
+		stack := []graph.TableName{start}
+		for len(stack) > 0 {
+			tableName := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			for _, constraint := range pending(tableName) {
+				target := graph.TableName(constraint.ReferenceTable)
+				if target == start {
+					return true
+				}
+				if !visited[target] {
+					visited[target] = true
+					stack = append(stack, target)
+				}
+			}
+		}
+		return false
+	}
+
+	for len(remaining) > 0 {
+		next := -1
+		for i, tableName := range remaining {
+			if len(pending(tableName)) == 0 {
+				next = i
+				break
+			}
+		}
+
+		// Every remaining table waits on another, so there is a cycle: break
+		// it at the table with the fewest pending references
+		if next < 0 {
+			for i, tableName := range remaining {
+				if onCycle(tableName) && (next < 0 || len(pending(tableName)) < len(pending(remaining[next]))) {
+					next = i
+				}
+			}
+			deferred[remaining[next]] = pending(remaining[next])
+		}
+
+		tableName := remaining[next]
+		order = append(order, tableName)
+		created[tableName] = true
+		remaining = append(remaining[:next], remaining[next+1:]...)
+	}
+
+	return order, deferred
+}
+
+// writeCreateTable writes the CREATE TABLE statement of a table, leaving out
+// the deferred foreign keys and those referencing tables outside the graph.
+// enumTypes holds the PostgreSQL types of enum columns, as sqlEnumTypes
+// returns them.
+func writeCreateTable(sb *strings.Builder, g *graph.SchemaGraph, table *database.Table, dialect Dialect, deferred []database.Constraint, enumTypes map[string]string) {
+	rules := checkRules(table)
+	var lines []string
+	for _, col := range table.Columns {
+		kind := parseSQLType(string(col.Type))
+		defaultValue, serial := sqlDefault(col.DefaultValue, kind, dialect)
+
+		typ, check := kind.render(dialect, serial), ""
+		if isSQLEnum(col, kind) {
+			switch dialect {
+			case DialectPostgres:
+				typ = sqlIdentifier(enumTypes[table.Name+"."+col.Name], dialect)
+			case DialectMySQL:
+				typ = "enum(" + sqlStrings(col.EnumValues) + ")"
+			case DialectSQLite:
+				typ = "TEXT"
+				// A check on the table may already list the values
+				if r := rules[col.Name]; r == nil || len(r.Enum) == 0 {
+					check = "CHECK (" + sqlIdentifier(col.Name, dialect) + " IN (" + sqlStrings(col.EnumValues) + "))"
+				}
+			}
+		}
+
+		line := sqlIdentifier(col.Name, dialect) + " " + typ
+		if !col.IsNullable {
+			line += " NOT NULL"
+		}
+		if defaultValue != "" {
+			line += " DEFAULT " + defaultValue
+		}
+		if serial && dialect == DialectMySQL {
+			line += " AUTO_INCREMENT"
+		}
+		if check != "" {
+			line += " " + check
+		}
+		lines = append(lines, line)
+	}
+
+	var constraints []database.Constraint
+	for _, kind := range []database.ConstraintKind{database.PrimaryKey, database.Unique, database.Check, database.ForeignKey} {
+		for _, constraint := range table.Constraints {
+			if constraint.Kind == kind {
+				constraints = append(constraints, constraint)
+			}
+		}
+	}
+
+	for _, constraint := range constraints {
+		switch constraint.Kind {
+		case database.PrimaryKey:
+			lines = append(lines, "PRIMARY KEY ("+sqlIdentifiers(constraint.Columns, dialect)+")")
+		case database.Unique:
+			lines = append(lines, "UNIQUE ("+sqlIdentifiers(constraint.Columns, dialect)+")")
+		case database.Check:
+			lines = append(lines, "CHECK ("+sqlExpression(constraint.CheckExpression, dialect)+")")
+		case database.ForeignKey:
+			if g.Nodes[graph.TableName(constraint.ReferenceTable)] == nil || containsConstraint(deferred, constraint) {
+				continue
+			}
+			lines = append(lines, sqlForeignKey(constraint, dialect))
+		}
+	}
+
+	if len(lines) == 0 {
+		fmt.Fprintf(sb, "CREATE TABLE %s ();\n", sqlIdentifier(table.Name, dialect))
+		return
+	}
+	fmt.Fprintf(sb, "CREATE TABLE %s (\n    %s\n);\n", sqlIdentifier(table.Name, dialect), strings.Join(lines, ",\n    "))
+}
+
+// sqlEnum is an enum type created before the tables on PostgreSQL.
+type sqlEnum struct {
+	name   string
+	values []string
+}
+
+// isSQLEnum reports whether a column is an enum with known values, such as a
+// DBML or PostgreSQL enum or a MySQL ENUM. MySQL SETs hold several values and
+// are left as they are.
+func isSQLEnum(col database.Column, kind sqlType) bool {
+	return len(col.EnumValues) > 0 && (kind.kind == "enum" || kind.kind == "other") && kind.base != "set"
+}
+
+// sqlEnumTypes names the PostgreSQL types of the enum columns, keyed by
+// table.column, and returns the types to create. A column keeps the name of
+// its type, unless it has none, as with MySQL's inline ENUMs, or the name is
+// taken by other values; then the type is named after the table and column.
+func sqlEnumTypes(g *graph.SchemaGraph, order []graph.TableName) (map[string]string, []sqlEnum) {
+	types := make(map[string]string)
+	var enums []sqlEnum
+	created := make(map[string][]string)
+
+	for _, tableName := range order {
+		table := g.Nodes[tableName]
+		for _, col := range table.Columns {
+			kind := parseSQLType(string(col.Type))
+			if !isSQLEnum(col, kind) {
+				continue
+			}
+
+			name := table.Name + "_" + col.Name
+			if kind.kind == "other" {
+				name = strings.TrimPrefix(kind.original, "public.")
+			}
+			if values, ok := created[name]; ok && !slices.Equal(values, col.EnumValues) {
+				name = table.Name + "_" + col.Name
+			}
+			if _, ok := created[name]; !ok {
+				created[name] = col.EnumValues
+				enums = append(enums, sqlEnum{name: name, values: col.EnumValues})
+			}
+			types[table.Name+"."+col.Name] = name
+		}
+	}
+	return types, enums
+}
+
+// sqlStrings writes values as a comma-separated list of string literals.
+func sqlStrings(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = "'" + strings.ReplaceAll(value, "'", "''") + "'"
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// sqlForeignKey writes a FOREIGN KEY constraint.
+func sqlForeignKey(constraint database.Constraint, dialect Dialect) string {
+	return fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s (%s)",
+		sqlIdentifiers(constraint.Columns, dialect),
+		sqlIdentifier(constraint.ReferenceTable, dialect),
+		sqlIdentifiers(constraint.ReferenceColumns, dialect))
+}
+
+// containsConstraint reports whether constraints holds a constraint with the
+// same kind, columns and reference.
+func containsConstraint(constraints []database.Constraint, constraint database.Constraint) bool {
+	for _, c := range constraints {
+		if c.Kind == constraint.Kind && c.ReferenceTable == constraint.ReferenceTable &&
+			strings.Join(c.Columns, ",") == strings.Join(constraint.Columns, ",") &&
+			strings.Join(c.ReferenceColumns, ",") == strings.Join(constraint.ReferenceColumns, ",") {
+			return true
+		}
+	}
+	return false
+}
+
+// sqlIdentifier quotes a name when it is not a lowercase identifier or is a
+// reserved word, with double quotes, or backticks on MySQL.
+func sqlIdentifier(name string, dialect Dialect) string {
+	if sqlBareIdentifier.MatchString(name) && !sqlReserved[name] {
+		return name
+	}
+	if dialect == DialectMySQL {
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// sqlIdentifiers quotes a list of names and joins them with commas.
+func sqlIdentifiers(names []string, dialect Dialect) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = sqlIdentifier(name, dialect)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// sqlExpression adapts a check or default expression written for another
+// engine: PostgreSQL casts are removed and "= ANY (ARRAY[...])" becomes an
+// IN list for MySQL and SQLite.
+func sqlExpression(expr string, dialect Dialect) string {
+	if dialect == DialectPostgres {
+		return expr
+	}
+	expr = sqlCast.ReplaceAllString(expr, "")
+	return sqlAnyArray.ReplaceAllString(expr, "IN ($1)")
+}
+
+// sqlDefault translates a column default to a dialect. Strings, numbers,
+// booleans, NULL and the current date and time keywords are literals. MySQL
+// reports string defaults without quotes, so any other value without
+// parentheses that does not start with a quote is taken as a string.
+// Remaining values are expressions, which MySQL and SQLite need in
+// parentheses. serial is true for defaults drawn from a PostgreSQL sequence,
+// which become auto-incrementing columns instead.
+func sqlDefault(value string, kind sqlType, dialect Dialect) (expr string, serial bool) {
+	value = strings.TrimSpace(value)
+
+	// ClickHouse reports the kind of default before the expression, and only
+	// plain defaults are stored
+	if kindName, expression, ok := strings.Cut(value, ": "); ok {
+		switch kindName {
+		case "DEFAULT":
+			value = expression
+		case "MATERIALIZED", "ALIAS", "EPHEMERAL":
+			return "", false
+		}
+	}
+
+	switch {
+	case kind.kind == "serial" || strings.HasPrefix(strings.ToLower(value), "nextval("):
+		return "", true
+	case value == "":
+		return "", false
+	}
+
+	literal := value
+	if dialect != DialectPostgres || sqlString.MatchString(sqlCast.ReplaceAllString(value, "")) {
+		literal = sqlCast.ReplaceAllString(value, "")
+	}
+	upper := strings.ToUpper(literal)
+	keyword := strings.TrimRight(strings.TrimSuffix(upper, "()"), "(0123456789)")
+
+	switch {
+	case sqlNumber.MatchString(literal):
+		// MySQL stores booleans as tinyint(1)
+		if kind.kind == "boolean" && dialect == DialectPostgres && literal == "0" {
+			return "false", false
+		}
+		if kind.kind == "boolean" && dialect == DialectPostgres && literal == "1" {
+			return "true", false
+		}
+		return literal, false
+	case upper == "NULL" || upper == "TRUE" || upper == "FALSE":
+		return upper, false
+	case sqlString.MatchString(literal):
+		return literal, false
+	case keyword == "CURRENT_TIMESTAMP" || keyword == "CURRENT_DATE" || keyword == "CURRENT_TIME" || keyword == "NOW" || keyword == "LOCALTIMESTAMP":
+		if dialect == DialectPostgres {
+			return value, false
+		}
+		// MySQL accepts a precision, as in CURRENT_TIMESTAMP(6)
+		if dialect == DialectMySQL && strings.HasPrefix(upper, "CURRENT_") {
+			return upper, false
+		}
+		if keyword == "NOW" || keyword == "LOCALTIMESTAMP" {
+			return "CURRENT_TIMESTAMP", false
+		}
+		return keyword, false
+	case !strings.Contains(value, "(") && !strings.HasPrefix(value, "'"):
+		return "'" + strings.ReplaceAll(value, "'", "''") + "'", false
+	}
+
+	if dialect == DialectPostgres {
+		return value, false
+	}
+	expr = sqlExpression(value, dialect)
+	if !strings.HasPrefix(expr, "(") || !strings.HasSuffix(expr, ")") {
+		expr = "(" + expr + ")"
+	}
+	return expr, false
+}
+
+// sqlType is a column type classified so it can be written in another
+// dialect.
+type sqlType struct {
+	// kind is a portable type name such as integer, varchar or timestamptz,
+	// or "other" for types kept as written.
+	kind     string
+	args     string // arguments such as the length, without parentheses
+	unsigned bool
+	array    *sqlType // element type of arrays
+	original string
+	// base is the lowercase type name, used to keep MySQL specific types
+	// such as mediumtext when writing MySQL.
+	base string
+}
+
+// parseSQLType classifies a column type reported by any of the engines.
+func parseSQLType(t string) sqlType {
+	t = strings.TrimSpace(t)
+	for _, wrapper := range []string{"Nullable(", "LowCardinality("} {
+		for strings.HasPrefix(t, wrapper) && strings.HasSuffix(t, ")") {
+			t = t[len(wrapper) : len(t)-1]
+		}
+	}
+
+	result := sqlType{original: t}
+	if element, ok := strings.CutSuffix(t, "[]"); ok {
+		inner := parseSQLType(element)
+		result.kind, result.array = "array", &inner
+		return result
+	}
+	if strings.HasPrefix(t, "Array(") && strings.HasSuffix(t, ")") {
+		inner := parseSQLType(t[len("Array(") : len(t)-1])
+		result.kind, result.array = "array", &inner
+		return result
+	}
+
+	// ClickHouse type names are case-sensitive, and Int8 is not PostgreSQL's int8
+	if m := clickhouseInteger.FindStringSubmatch(t); m != nil {
+		switch {
+		case m[1] == "8" || m[1] == "16":
+			result.kind = "smallint"
+		case m[1] == "32" && strings.HasPrefix(t, "Int"):
+			result.kind = "integer"
+		default:
+			result.kind = "bigint"
+		}
+		return result
+	}
+
+	lower := strings.ToLower(t)
+	if rest, ok := strings.CutSuffix(lower, " zerofill"); ok {
+		lower = rest
+	}
+	if rest, ok := strings.CutSuffix(lower, " unsigned"); ok {
+		lower, result.unsigned = rest, true
+	}
+
+	base := lower
+	if open := strings.Index(lower, "("); open >= 0 && strings.HasSuffix(lower, ")") {
+		base, result.args = strings.TrimSpace(lower[:open]), lower[open+1:len(lower)-1]
+	}
+	result.base = base
+
+	switch base {
+	case "smallint", "int2", "int16":
+		result.kind = "smallint"
+	case "tinyint":
+		result.kind = "smallint"
+		if result.args == "1" {
+			result.kind = "boolean"
+		}
+	case "int", "integer", "int4", "mediumint":
+		result.kind = "integer"
+	case "bigint", "int8":
+		result.kind = "bigint"
+	case "serial", "serial4", "smallserial", "serial2", "bigserial", "serial8":
+		result.kind = "serial"
+	case "real", "float4", "float", "float32":
+		result.kind = "real"
+	case "double precision", "double", "float8", "float64":
+		result.kind = "double"
+	case "numeric", "decimal", "dec":
+		result.kind = "decimal"
+	case "boolean", "bool":
+		result.kind = "boolean"
+	case "varchar", "character varying", "nvarchar":
+		result.kind = "varchar"
+	case "char", "character", "bpchar", "nchar", "fixedstring":
+		result.kind = "char"
+	case "text", "tinytext", "mediumtext", "longtext", "clob", "citext", "string", "name":
+		result.kind = "text"
+	case "date", "date32":
+		result.kind = "date"
+	case "time", "timetz", "time without time zone", "time with time zone":
+		result.kind = "time"
+	case "timestamp", "datetime", "datetime64", "timestamp without time zone":
+		result.kind = "timestamp"
+	case "timestamptz", "timestamp with time zone":
+		result.kind = "timestamptz"
+	case "json":
+		result.kind = "json"
+	case "jsonb":
+		result.kind = "jsonb"
+	case "uuid":
+		result.kind = "uuid"
+	case "bytea", "blob", "tinyblob", "mediumblob", "longblob", "binary", "varbinary":
+		result.kind = "binary"
+	case "enum", "set", "enum8", "enum16", "user-defined":
+		result.kind = "enum"
+	case "array":
+		inner := sqlType{kind: "text"}
+		result.kind, result.array = "array", &inner
+	default:
+		result.kind = "other"
+	}
+
+	// Integer display widths such as int(11) mean nothing elsewhere
+	if result.kind == "smallint" || result.kind == "integer" || result.kind == "bigint" {
+		result.args = ""
+	}
+
+	return result
+}
+
+// render writes the type in a dialect. Serial columns are written as the
+// auto-incrementing types of PostgreSQL, or as integers elsewhere.
+func (t sqlType) render(dialect Dialect, serial bool) string {
+	kind := t.kind
+	if kind == "serial" {
+		switch t.base {
+		case "smallserial", "serial2":
+			kind = "smallint"
+		case "bigserial", "serial8":
+			kind = "bigint"
+		default:
+			kind = "integer"
+		}
+	}
+
+	// Unsigned integers need the next larger type outside MySQL
+	if t.unsigned && dialect != DialectMySQL {
+		switch kind {
+		case "smallint":
+			kind = "integer"
+		case "integer":
+			kind = "bigint"
+		case "bigint":
+			kind, t.args = "decimal", "20"
+		}
+	}
+
+	withArgs := func(name string) string {
+		if t.args == "" {
+			return name
+		}
+		return name + "(" + strings.ReplaceAll(t.args, " ", "") + ")"
+	}
+
+	switch dialect {
+	case DialectSQLite:
+		switch kind {
+		case "smallint", "integer", "bigint", "boolean":
+			return "INTEGER"
+		case "real", "double":
+			return "REAL"
+		case "decimal":
+			return "NUMERIC"
+		case "binary":
+			return "BLOB"
+		case "other":
+			if sqlSimpleType.MatchString(t.original) {
+				return t.original
+			}
+		}
+		return "TEXT"
+
+	case DialectMySQL:
+		unsigned := ""
+		if t.unsigned {
+			unsigned = " unsigned"
+		}
+		switch kind {
+		case "smallint", "bigint":
+			return kind + unsigned
+		case "integer":
+			return "int" + unsigned
+		case "real":
+			return "float"
+		case "double":
+			return "double"
+		case "decimal":
+			return withArgs("decimal")
+		case "boolean":
+			return "boolean"
+		case "varchar":
+			if t.args == "" {
+				return "text"
+			}
+			return withArgs("varchar")
+		case "char":
+			if t.args == "" {
+				return "char(1)"
+			}
+			return withArgs("char")
+		case "text":
+			if t.base == "tinytext" || t.base == "mediumtext" || t.base == "longtext" {
+				return t.base
+			}
+			return "text"
+		case "date", "time":
+			return kind
+		case "timestamp", "timestamptz":
+			return "datetime"
+		case "json", "jsonb", "array":
+			return "json"
+		case "uuid":
+			return "char(36)"
+		case "binary":
+			switch t.base {
+			case "blob", "tinyblob", "mediumblob", "longblob":
+				return t.base
+			case "binary", "varbinary":
+				if t.args != "" {
+					return withArgs(t.base)
+				}
+			}
+			return "longblob"
+		case "enum":
+			if t.base == "enum" || t.base == "set" {
+				return t.original
+			}
+			return "text"
+		}
+		return t.original
+
+	default:
+		switch kind {
+		case "smallint", "integer", "bigint", "real", "boolean", "text", "date", "timestamptz", "json", "jsonb", "uuid":
+			if serial {
+				switch kind {
+				case "smallint":
+					return "smallserial"
+				case "integer":
+					return "serial"
+				case "bigint":
+					return "bigserial"
+				}
+			}
+			return kind
+		case "double":
+			return "double precision"
+		case "decimal":
+			return withArgs("numeric")
+		case "varchar", "char":
+			return withArgs(kind)
+		case "time":
+			if t.base == "timetz" || t.base == "time with time zone" {
+				return "timetz"
+			}
+			return "time"
+		case "timestamp":
+			return "timestamp"
+		case "binary":
+			return "bytea"
+		case "enum":
+			return "text"
+		case "array":
+			return t.array.render(dialect, false) + "[]"
+		}
+		return t.original
+	}
+}
@@ -0,0 +1,236 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/viveknathani/dbtree/database"
+	"github.com/viveknathani/dbtree/graph"
+)
+
+// tsBareName matches property names TypeScript accepts without quotes.
+var tsBareName = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// typedColumn is a column with what the typescript and jsonschema formats
+// know about its values.
+type typedColumn struct {
+	database.Column
+	sqlType sqlType
+	// rules combine the column's CHECK constraints with limits implied by
+	// its type, such as the length of a varchar. Enum values the inspector
+	// reports take precedence over IN lists.
+	rules columnRules
+	// refTable and refColumn are the target of the first foreign key the
+	// column takes part in, if any.
+	refTable  graph.TableName
+	refColumn string
+	// references lists the targets of all its foreign keys as table.column.
+	references []string
+}
+
+// typedColumns returns the columns of a table with their types, rules and
+// foreign keys.
+func typedColumns(g *graph.SchemaGraph, tableName graph.TableName, table *database.Table) []typedColumn {
+	rules := checkRules(table)
+	columns := make([]typedColumn, len(table.Columns))
+	for i, col := range table.Columns {
+		c := typedColumn{Column: col, sqlType: parseSQLType(string(col.Type))}
+		if r := rules[col.Name]; r != nil {
+			c.rules = *r
+		}
+
+		if c.sqlType.kind == "varchar" || c.sqlType.kind == "char" {
+			if n, err := strconv.Atoi(c.sqlType.args); err == nil && (c.rules.MaxLength == 0 || n < c.rules.MaxLength) {
+				c.rules.MaxLength = n
+			}
+		}
+		switch c.sqlType.kind {
+		case "smallint", "integer", "bigint", "serial", "real", "double":
+			unsigned := c.sqlType.unsigned || strings.HasPrefix(c.sqlType.original, "UInt")
+			if unsigned && c.rules.Minimum == "" && c.rules.ExclusiveMinimum == "" {
+				c.rules.Minimum = "0"
+			}
+		default:
+			// Bounds only apply to values written as numbers, which
+			// decimals are not
+			c.rules.Minimum, c.rules.ExclusiveMinimum = "", ""
+			c.rules.Maximum, c.rules.ExclusiveMaximum = "", ""
+		}
+		if len(col.EnumValues) > 0 {
+			c.rules.Enum = make([]any, len(col.EnumValues))
+			for j, value := range col.EnumValues {
+				c.rules.Enum[j] = value
+			}
+		}
+
+		for _, edge := range g.Edges {
+			if edge.FromTable != tableName {
+				continue
+			}
+			for j, column := range edge.Columns {
+				if column != col.Name || j >= len(edge.ReferenceColumns) {
+					continue
+				}
+				if c.refTable == "" {
+					c.refTable, c.refColumn = edge.ToTable, edge.ReferenceColumns[j]
+				}
+				c.references = append(c.references, string(edge.ToTable)+"."+edge.ReferenceColumns[j])
+			}
+		}
+		columns[i] = c
+	}
+	return columns
+}
+
+// typeNames returns a unique PascalCase type name for each table.
+func typeNames(g *graph.SchemaGraph) map[graph.TableName]string {
+	names := make(map[graph.TableName]string)
+	taken := make(map[string]bool)
+	for _, tableName := range getSortedTableNames(g) {
+		name := jsonName(string(tableName), NamingPascal)
+		if name == "" || name[0] < 'A' || name[0] > 'Z' {
+			name = "T" + name
+		}
+		names[tableName] = uniqueGoName(name, taken)
+	}
+	return names
+}
+
+// renderTypeScript writes an interface for each table, describing its rows as
+// an API returns them. Foreign keys take the type of the column they
+// reference, enums and IN checks become unions, and other check limits are
+// written as JSDoc tags.
+func renderTypeScript(g *graph.SchemaGraph) string {
+	var sb strings.Builder
+	sb.WriteString("// Code generated by dbtree. DO NOT EDIT.\n")
+
+	names := typeNames(g)
+	for _, tableName := range getSortedTableNames(g) {
+		table := g.Nodes[tableName]
+		if table == nil {
+			continue
+		}
+
+		sb.WriteString("\n")
+		if table.Comment != "" {
+			writeJSDoc(&sb, "", strings.Split(strings.TrimSpace(table.Comment), "\n"))
+		}
+		fmt.Fprintf(&sb, "export interface %s {\n", names[tableName])
+		for _, col := range typedColumns(g, tableName, table) {
+			writeJSDoc(&sb, "  ", tsDocLines(col))
+			typ := tsType(col.sqlType, col.rules.Enum)
+			if col.refTable != "" {
+				typ = names[col.refTable] + "[" + strconv.Quote(col.refColumn) + "]"
+			}
+			if col.IsNullable {
+				typ += " | null"
+			}
+			fmt.Fprintf(&sb, "  %s: %s;\n", tsPropertyName(col.Name), typ)
+		}
+		sb.WriteString("}\n")
+	}
+
+	return sb.String()
+}
+
+// tsDocLines returns the JSDoc of a column: its comment, the columns it
+// references and the limits on its values.
+func tsDocLines(col typedColumn) []string {
+	var lines []string
+	if col.Comment != "" {
+		lines = append(lines, strings.Split(strings.TrimSpace(col.Comment), "\n")...)
+	}
+	for _, ref := range col.references {
+		lines = append(lines, "References "+ref)
+	}
+	if col.refTable != "" {
+		return lines
+	}
+
+	for _, tag := range []struct{ name, value string }{
+		{"minimum", col.rules.Minimum},
+		{"exclusiveMinimum", col.rules.ExclusiveMinimum},
+		{"maximum", col.rules.Maximum},
+		{"exclusiveMaximum", col.rules.ExclusiveMaximum},
+	} {
+		if tag.value != "" {
+			lines = append(lines, "@"+tag.name+" "+tag.value)
+		}
+	}
+	if col.rules.MinLength > 0 {
+		lines = append(lines, "@minLength "+strconv.Itoa(col.rules.MinLength))
+	}
+	if col.rules.MaxLength > 0 {
+		lines = append(lines, "@maxLength "+strconv.Itoa(col.rules.MaxLength))
+	}
+	return lines
+}
+
+// writeJSDoc writes a JSDoc comment, on a single line when it has one line.
+func writeJSDoc(sb *strings.Builder, indent string, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	for i, line := range lines {
+		lines[i] = strings.ReplaceAll(strings.TrimRight(line, " \t\r"), "*/", `*\/`)
+	}
+	if len(lines) == 1 {
+		fmt.Fprintf(sb, "%s/** %s */\n", indent, lines[0])
+		return
+	}
+	fmt.Fprintf(sb, "%s/**\n", indent)
+	for _, line := range lines {
+		if line == "" {
+			fmt.Fprintf(sb, "%s *\n", indent)
+		} else {
+			fmt.Fprintf(sb, "%s * %s\n", indent, line)
+		}
+	}
+	fmt.Fprintf(sb, "%s */\n", indent)
+}
+
+// tsPropertyName quotes a column name unless it is a plain identifier.
+func tsPropertyName(name string) string {
+	if tsBareName.MatchString(name) {
+		return name
+	}
+	return strconv.Quote(name)
+}
+
+// tsType maps a column type to a TypeScript type, as its values appear in
+// JSON. Enum values, when known, become a union of literals.
+func tsType(t sqlType, enum []any) string {
+	if len(enum) > 0 && t.kind != "array" {
+		literals := make([]string, len(enum))
+		for i, value := range enum {
+			literal, _ := json.Marshal(value)
+			literals[i] = string(literal)
+		}
+		return strings.Join(literals, " | ")
+	}
+
+	switch t.kind {
+	case "smallint", "integer", "bigint", "serial", "real", "double":
+		// ClickHouse's widest integers do not fit in a number
+		if m := clickhouseInteger.FindStringSubmatch(t.original); m != nil && (m[1] == "128" || m[1] == "256") {
+			return "string"
+		}
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "decimal", "varchar", "char", "text", "enum", "uuid", "date", "time", "timestamp", "timestamptz", "binary":
+		// Decimals are strings to keep their precision, and binary data is
+		// base64 encoded
+		return "string"
+	case "array":
+		element := tsType(*t.array, nil)
+		if strings.Contains(element, " ") {
+			element = "(" + element + ")"
+		}
+		return element + "[]"
+	}
+	return "unknown"
+}
@@ -0,0 +1,263 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// bundleVersion is the version of the bundle format written by ExportBundle.
+const bundleVersion = 1
+
+// ErrBundlePassword is returned when an encrypted bundle cannot be decrypted.
+var ErrBundlePassword = errors.New("wrong bundle password or corrupted bundle")
+
+// bundleFile is the JSON structure of an exported bundle. A plain bundle lists
+// its connections, an encrypted one holds them encrypted under a key derived
+// from the bundle password, independent of any master password.
+type bundleFile struct {
+	Version     int          `json:"version"`
+	Connections []Connection `json:"connections,omitempty"`
+	KDF         *KDFParams   `json:"kdf,omitempty"`
+	Salt        []byte       `json:"salt,omitempty"`
+	Nonce       []byte       `json:"nonce,omitempty"`
+	Ciphertext  []byte       `json:"ciphertext,omitempty"`
+}
+
+// ConflictMode decides what an import does with a connection whose name is
+// already taken.
+type ConflictMode string
+
+const (
+	ConflictSkip      ConflictMode = "skip"
+	ConflictOverwrite ConflictMode = "overwrite"
+	ConflictRename    ConflictMode = "rename"
+)
+
+// ImportResult reports what happened to each imported connection.
+type ImportResult struct {
+	Added       []string
+	Overwritten []string
+	Skipped     []string
+	Renamed     map[string]string // original name to new name
+}
+
+// ExportBundle encodes connections as a bundle. The bundle is encrypted when
+// a password is given. LastUsed timestamps are not exported.
+func ExportBundle(connections []Connection, password string) ([]byte, error) {
+	exported := make([]Connection, len(connections))
+	for i, conn := range connections {
+		conn.LastUsed = 0
+		exported[i] = conn
+	}
+
+	bundle := bundleFile{Version: bundleVersion}
+
+	if password == "" {
+		bundle.Connections = exported
+	} else {
+		plaintext, err := json.Marshal(exported)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal connections: %w", err)
+		}
+
+		salt, err := newSalt()
+		if err != nil {
+			return nil, err
+		}
+
+		kdf := DefaultKDFParams
+		nonce, ciphertext, err := encrypt(deriveKey(password, salt, kdf), plaintext)
+		if err != nil {
+			return nil, err
+		}
+
+		bundle.KDF = &kdf
+		bundle.Salt, bundle.Nonce, bundle.Ciphertext = salt, nonce, ciphertext
+	}
+
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal bundle: %w", err)
+	}
+
+	return data, nil
+}
+
+// BundleEncrypted reports whether a bundle needs a password to be read.
+func BundleEncrypted(data []byte) (bool, error) {
+	bundle, err := parseBundleFile(data)
+	if err != nil {
+		return false, err
+	}
+	return bundle.Ciphertext != nil, nil
+}
+
+// ReadBundle decodes the connections of a bundle, decrypting it with password
+// if it is encrypted.
+func ReadBundle(data []byte, password string) ([]Connection, error) {
+	bundle, err := parseBundleFile(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if bundle.Ciphertext == nil {
+		return bundle.Connections, nil
+	}
+
+	kdf := legacyKDFParams
+	if bundle.KDF != nil {
+		if err := bundle.KDF.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid bundle: %w", err)
+		}
+		kdf = *bundle.KDF
+	}
+
+	plaintext, err := decrypt(deriveKey(password, bundle.Salt, kdf), bundle.Nonce, bundle.Ciphertext)
+	if err != nil {
+		return nil, ErrBundlePassword
+	}
+
+	var connections []Connection
+	if err := json.Unmarshal(plaintext, &connections); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle connections: %w", err)
+	}
+
+	return connections, nil
+}
+
+func parseBundleFile(data []byte) (bundleFile, error) {
+	var bundle bundleFile
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return bundleFile{}, fmt.Errorf("failed to parse bundle: %w", err)
+	}
+
+	if bundle.Version < 1 || bundle.Version > bundleVersion {
+		return bundleFile{}, fmt.Errorf("unsupported bundle version %d", bundle.Version)
+	}
+
+	return bundle, nil
+}
+
+// Import adds connections to the store, resolving name clashes according to
+// mode, and saves once. Renamed connections get the first free name of the
+// form "name-2", "name-3" and so on.
+func (s *Store) Import(imported []Connection, mode ConflictMode) (ImportResult, error) {
+	result := ImportResult{Renamed: make(map[string]string)}
+
+	switch mode {
+	case ConflictSkip, ConflictOverwrite, ConflictRename:
+	default:
+		return result, fmt.Errorf("unknown conflict mode %q", mode)
+	}
+
+	err := s.modify(func(connections []Connection) ([]Connection, error) {
+		return importConnections(connections, imported, mode, &result)
+	})
+	return result, err
+}
+
+// importConnections adds imported to connections, recording the outcome for
+// each one in result.
+func importConnections(connections, imported []Connection, mode ConflictMode, result *ImportResult) ([]Connection, error) {
+	index := make(map[string]int, len(connections))
+	for i, conn := range connections {
+		index[conn.Name] = i
+	}
+
+	now := time.Now().Unix()
+	for _, conn := range imported {
+		if conn.Name == "" {
+			return nil, fmt.Errorf("bundle contains a connection without a name")
+		}
+		conn.LastUsed = now
+
+		i, exists := index[conn.Name]
+		switch {
+		case !exists:
+			result.Added = append(result.Added, conn.Name)
+		case mode == ConflictSkip:
+			result.Skipped = append(result.Skipped, conn.Name)
+			continue
+		case mode == ConflictOverwrite:
+			connections[i] = conn
+			result.Overwritten = append(result.Overwritten, conn.Name)
+			continue
+		case mode == ConflictRename:
+			name := conn.Name
+			for n := 2; ; n++ {
+				candidate := fmt.Sprintf("%s-%d", name, n)
+				if _, taken := index[candidate]; !taken {
+					conn.Name = candidate
+					break
+				}
+			}
+			result.Renamed[name] = conn.Name
+		}
+
+		index[conn.Name] = len(connections)
+		connections = append(connections, conn)
+	}
+
+	return connections, nil
+}
+
+// userInfo locates the user info ("user" or "user:password") of a connection
+// URL or of a MySQL DSN such as user:pass@tcp(host:3306)/db. It returns the
+// byte offsets of the user info, or ok=false if there is none.
+func userInfo(rawURL string) (start, end int, ok bool) {
+	if i := strings.Index(rawURL, "://"); i >= 0 {
+		start = i + 3
+	}
+
+	authority := rawURL[start:]
+	if i := strings.Index(authority, "?"); i >= 0 {
+		authority = authority[:i]
+	}
+
+	at := strings.LastIndex(authority, "@")
+	if at < 0 {
+		return 0, 0, false
+	}
+	return start, start + at, true
+}
+
+// StripPassword removes the password from a connection URL, keeping the user name.
+func StripPassword(rawURL string) string {
+	start, end, ok := userInfo(rawURL)
+	if !ok {
+		return rawURL
+	}
+
+	user, _, hasPassword := strings.Cut(rawURL[start:end], ":")
+	if !hasPassword {
+		return rawURL
+	}
+	return rawURL[:start] + user + rawURL[end:]
+}
+
+// NeedsPassword reports whether a connection URL names a user but no password,
+// as is the case for URLs exported with their passwords stripped.
+func NeedsPassword(rawURL string) bool {
+	start, end, ok := userInfo(rawURL)
+	return ok && end > start && !strings.Contains(rawURL[start:end], ":")
+}
+
+// SetPassword fills in the password of a connection URL. The password is
+// percent-encoded in URLs, but inserted as is in DSNs, which are not decoded.
+func SetPassword(rawURL, password string) string {
+	start, end, ok := userInfo(rawURL)
+	if !ok {
+		return rawURL
+	}
+
+	user, _, _ := strings.Cut(rawURL[start:end], ":")
+	userinfo := user + ":" + password
+	if u, err := url.Parse(rawURL); err == nil && u.User != nil {
+		userinfo = url.UserPassword(u.User.Username(), password).String()
+	}
+	return rawURL[:start] + userinfo + rawURL[end:]
+}
@@ -0,0 +1,160 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBundle(t *testing.T) {
+	connections := []Connection{
+		{Name: "local", URL: "app.db", Driver: "sqlite3", LastUsed: 42},
+		{Name: "prod", URL: "postgres://u:p@h/db", Driver: "postgres", LastUsed: 42},
+	}
+	expected := []Connection{
+		{Name: "local", URL: "app.db", Driver: "sqlite3"},
+		{Name: "prod", URL: "postgres://u:p@h/db", Driver: "postgres"},
+	}
+
+	plain, err := ExportBundle(connections, "")
+	if err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+	if encrypted, err := BundleEncrypted(plain); err != nil || encrypted {
+		t.Errorf("expected a plain bundle, got encrypted=%v err=%v", encrypted, err)
+	}
+	got, err := ReadBundle(plain, "")
+	if err != nil {
+		t.Fatalf("failed to read plain bundle: %v", err)
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	encrypted, err := ExportBundle(connections, "bundle secret")
+	if err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+	if strings.Contains(string(encrypted), "postgres") {
+		t.Error("encrypted bundle contains plaintext")
+	}
+	if isEncrypted, err := BundleEncrypted(encrypted); err != nil || !isEncrypted {
+		t.Errorf("expected an encrypted bundle, got encrypted=%v err=%v", isEncrypted, err)
+	}
+	if _, err := ReadBundle(encrypted, "wrong"); !errors.Is(err, ErrBundlePassword) {
+		t.Errorf("expected ErrBundlePassword, got %v", err)
+	}
+	got, err = ReadBundle(encrypted, "bundle secret")
+	if err != nil {
+		t.Fatalf("failed to read encrypted bundle: %v", err)
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	if _, err := ReadBundle([]byte(`{"version": 99}`), ""); err == nil {
+		t.Error("expected an error for an unsupported bundle version")
+	}
+
+	// A crafted bundle must not make deriving its key exhaust memory
+	var crafted bundleFile
+	if err := json.Unmarshal(encrypted, &crafted); err != nil {
+		t.Fatal(err)
+	}
+	crafted.KDF.Memory = math.MaxUint32
+	data, err := json.Marshal(crafted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadBundle(data, "bundle secret"); err == nil || !strings.Contains(err.Error(), "memory") {
+		t.Errorf("expected an error about the key derivation memory, got %v", err)
+	}
+}
+
+func TestImport(t *testing.T) {
+	imported := []Connection{
+		{Name: "a", URL: "new-a.db", Driver: "sqlite3"},
+		{Name: "c", URL: "c.db", Driver: "sqlite3"},
+	}
+
+	tests := []struct {
+		mode     ConflictMode
+		expected string
+	}{
+		{ConflictSkip, "map[a:a.db a-2:a2.db c:c.db]"},
+		{ConflictOverwrite, "map[a:new-a.db a-2:a2.db c:c.db]"},
+		{ConflictRename, "map[a:a.db a-2:a2.db a-3:new-a.db c:c.db]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+
+			s := newTestStore(t, "secret")
+			for _, conn := range []Connection{{Name: "a", URL: "a.db"}, {Name: "a-2", URL: "a2.db"}} {
+				if err := s.Add(conn); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			result, err := s.Import(imported, tt.mode)
+			if err != nil {
+				t.Fatalf("failed to import: %v", err)
+			}
+
+			connections, err := s.Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			urls := make(map[string]string)
+			for _, conn := range connections {
+				urls[conn.Name] = conn.URL
+			}
+			if got := fmt.Sprint(urls); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+
+			if fmt.Sprint(result.Added) != "[c]" {
+				t.Errorf("expected c to be added, got %v", result.Added)
+			}
+			if tt.mode == ConflictRename && result.Renamed["a"] != "a-3" {
+				t.Errorf("expected a to be renamed to a-3, got %v", result.Renamed)
+			}
+		})
+	}
+}
+
+func TestPasswordHelpers(t *testing.T) {
+	tests := []struct {
+		url      string
+		stripped string
+		filled   string
+	}{
+		{"postgres://u:p@h:5432/db?sslmode=disable", "postgres://u@h:5432/db?sslmode=disable", "postgres://u:n%40w@h:5432/db?sslmode=disable"},
+		{"mysql://root:p@tcp(localhost:3306)/db", "mysql://root@tcp(localhost:3306)/db", "mysql://root:n@w@tcp(localhost:3306)/db"},
+		{"root:p@tcp(localhost:3306)/db", "root@tcp(localhost:3306)/db", "root:n@w@tcp(localhost:3306)/db"},
+		{"app.db", "app.db", "app.db"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			stripped := StripPassword(tt.url)
+			if stripped != tt.stripped {
+				t.Errorf("expected %s, got %s", tt.stripped, stripped)
+			}
+			if needs := NeedsPassword(stripped); needs != (stripped != tt.url) {
+				t.Errorf("unexpected NeedsPassword(%s) = %v", stripped, needs)
+			}
+			if NeedsPassword(tt.url) {
+				t.Errorf("expected %s to have a password", tt.url)
+			}
+			if filled := SetPassword(stripped, "n@w"); filled != tt.filled {
+				t.Errorf("expected %s, got %s", tt.filled, filled)
+			}
+		})
+	}
+}
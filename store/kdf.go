@@ -0,0 +1,92 @@
+package store
+
+import (
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// KDFParams are the Argon2id parameters a key is derived with. They are stored
+// in the file header, so that they can be raised without locking out files
+// written with older values.
+type KDFParams struct {
+	Algorithm string `json:"algorithm"`
+	Time      uint32 `json:"time"`
+	Memory    uint32 `json:"memory"` // KiB
+	Threads   uint8  `json:"threads"`
+	KeyLen    uint32 `json:"key_len"`
+}
+
+// DefaultKDFParams are used for new files. OWASP recommends time=2,
+// memory=19456 as a baseline. Higher values are more secure but slower to
+// unlock. Files with weaker parameters are upgraded when they are unlocked.
+var DefaultKDFParams = KDFParams{
+	Algorithm: "argon2id",
+	Time:      2,
+	Memory:    19 * 1024, // 19 MiB
+	Threads:   4,
+	KeyLen:    32,
+}
+
+// Upper limits of the parameters, which files such as imported bundles could
+// otherwise set high enough to exhaust memory or hang while deriving the key.
+const (
+	maxKDFTime    = 16
+	maxKDFMemory  = 4 * 1024 * 1024 // 4 GiB
+	maxKDFThreads = 64
+)
+
+// legacyKDFParams are the parameters of files written before the header
+// recorded them.
+var legacyKDFParams = KDFParams{
+	Algorithm: "argon2id",
+	Time:      2,
+	Memory:    19 * 1024,
+	Threads:   4,
+	KeyLen:    32,
+}
+
+// Validate checks that the parameters can be used to derive an AES-256 key
+// within the upper limits.
+func (p KDFParams) Validate() error {
+	if p.Algorithm != "argon2id" {
+		return fmt.Errorf("unsupported key derivation algorithm %q", p.Algorithm)
+	}
+	if p.Time < 1 || p.Time > maxKDFTime {
+		return fmt.Errorf("key derivation time must be between 1 and %d", maxKDFTime)
+	}
+	if p.Threads < 1 || p.Threads > maxKDFThreads {
+		return fmt.Errorf("key derivation threads must be between 1 and %d", maxKDFThreads)
+	}
+	if p.Memory < 8*uint32(p.Threads) {
+		return fmt.Errorf("key derivation memory must be at least %d KiB for %d threads", 8*uint32(p.Threads), p.Threads)
+	}
+	if p.Memory > maxKDFMemory {
+		return fmt.Errorf("key derivation memory must be at most %d MiB", maxKDFMemory/1024)
+	}
+	if p.KeyLen != 32 {
+		return fmt.Errorf("unsupported key length %d", p.KeyLen)
+	}
+	return nil
+}
+
+// atLeast raises each cost parameter of p to at least that of floor.
+func (p KDFParams) atLeast(floor KDFParams) KDFParams {
+	p.Time = max(p.Time, floor.Time)
+	p.Memory = max(p.Memory, floor.Memory)
+	p.Threads = max(p.Threads, floor.Threads)
+	return p
+}
+
+// String describes the parameters for display.
+func (p KDFParams) String() string {
+	memory := fmt.Sprintf("%d KiB", p.Memory)
+	if p.Memory%1024 == 0 {
+		memory = fmt.Sprintf("%d MiB", p.Memory/1024)
+	}
+	return fmt.Sprintf("%s (time %d, memory %s, threads %d)", p.Algorithm, p.Time, memory, p.Threads)
+}
+
+func deriveKey(password string, salt []byte, params KDFParams) []byte {
+	return argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, params.KeyLen)
+}
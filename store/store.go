@@ -4,37 +4,98 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"crypto/subtle"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 	"time"
-
-	"golang.org/x/crypto/argon2"
 )
 
 // Connection represents a saved database connection.
 type Connection struct {
-	Name     string `json:"name"`
-	URL      string `json:"url"`
-	Driver   string `json:"driver"`
-	LastUsed int64  `json:"last_used"`
+	Name        string   `json:"name"`
+	URL         string   `json:"url"`
+	Driver      string   `json:"driver"`
+	Environment string   `json:"environment,omitempty"` // e.g. dev, staging or prod
+	Tags        []string `json:"tags,omitempty"`
+	LastUsed    int64    `json:"last_used"`
+}
+
+// IsProduction reports whether the connection is labelled as production.
+func (c Connection) IsProduction() bool {
+	env := strings.ToLower(c.Environment)
+	return env == "prod" || env == "production"
+}
+
+// HasTag reports whether the connection has the given tag, ignoring case.
+func (c Connection) HasTag(tag string) bool {
+	for _, t := range c.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// equal reports whether two connections are identical.
+func (c Connection) equal(other Connection) bool {
+	return c.Name == other.Name && c.URL == other.URL && c.Driver == other.Driver &&
+		c.Environment == other.Environment && slices.Equal(c.Tags, other.Tags) && c.LastUsed == other.LastUsed
+}
+
+// NormalizeTags trims tags and drops empty and duplicate ones, keeping the
+// order in which they were given.
+func NormalizeTags(tags []string) []string {
+	var normalized []string
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || slices.ContainsFunc(normalized, func(t string) bool { return strings.EqualFold(t, tag) }) {
+			continue
+		}
+		normalized = append(normalized, tag)
+	}
+	return normalized
 }
 
+// currentVersion is the version of the file format written by this package.
+// Version 1 files have no version field and were encrypted with a key derived
+// with legacyKDFParams; version 2 records the KDF parameters in the header.
+const currentVersion = 2
+
 // encryptedFile is the JSON structure stored on disk.
 type encryptedFile struct {
-	Salt       []byte `json:"salt"`
-	Nonce      []byte `json:"nonce"`
-	Ciphertext []byte `json:"ciphertext"`
+	Version    int        `json:"version,omitempty"`
+	KDF        *KDFParams `json:"kdf,omitempty"`
+	Salt       []byte     `json:"salt"`
+	Nonce      []byte     `json:"nonce"`
+	Ciphertext []byte     `json:"ciphertext"`
+}
+
+// kdfParams returns the parameters the key of the file was derived with.
+func (ef encryptedFile) kdfParams() (KDFParams, error) {
+	if ef.KDF == nil {
+		return legacyKDFParams, nil
+	}
+	if err := ef.KDF.Validate(); err != nil {
+		return KDFParams{}, fmt.Errorf("invalid store file: %w", err)
+	}
+	return *ef.KDF, nil
 }
 
-// Store manages encrypted connection persistence.
+// Store manages encrypted connection persistence. Changes are serialized
+// across processes with an advisory lock on connections.json.lock, and the
+// file is only ever replaced atomically.
 type Store struct {
 	mu       sync.Mutex
 	filePath string
 	key      []byte
+	kdf      KDFParams    // parameters key was derived with
+	base     []Connection // connections as last loaded or saved, to merge concurrent changes against
 }
 
 var (
@@ -42,30 +103,36 @@ var (
 )
 
 // NewStore creates a Store with a key derived from the given password.
-// If the store file doesn't exist yet, it creates an empty one.
+// If the store file doesn't exist yet, it creates an empty one. Files written
+// by older versions, or with weaker KDF parameters than DefaultKDFParams, are
+// upgraded once the password is verified.
 func NewStore(password string) (*Store, error) {
 	dir, err := storeDir()
 	if err != nil {
 		return nil, err
 	}
 
-	filePath := filepath.Join(dir, "connections.json")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create config directory: %w", err)
+	}
 
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		// First run: create directory and empty store
-		if err := os.MkdirAll(dir, 0700); err != nil {
-			return nil, fmt.Errorf("failed to create config directory: %w", err)
-		}
+	s := &Store{filePath: filepath.Join(dir, "connections.json")}
 
-		salt := make([]byte, 16)
-		if _, err := rand.Read(salt); err != nil {
-			return nil, fmt.Errorf("failed to generate salt: %w", err)
-		}
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
 
-		key := deriveKey(password, salt)
-		s := &Store{filePath: filePath, key: key}
+	if _, err := os.Stat(s.filePath); os.IsNotExist(err) {
+		// First run: write an empty store
+		salt, err := newSalt()
+		if err != nil {
+			return nil, err
+		}
 
-		// Write empty connections list
+		s.kdf = DefaultKDFParams
+		s.key = deriveKey(password, salt, s.kdf)
 		if err := s.saveWithSalt([]Connection{}, salt); err != nil {
 			return nil, err
 		}
@@ -74,40 +141,95 @@ func NewStore(password string) (*Store, error) {
 	}
 
 	// Existing file: read salt, derive key, verify by decrypting
-	data, err := os.ReadFile(filePath)
+	ef, err := s.readFile()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read store file: %w", err)
+		return nil, err
 	}
 
-	var ef encryptedFile
-	if err := json.Unmarshal(data, &ef); err != nil {
-		return nil, fmt.Errorf("failed to parse store file: %w", err)
+	if ef.Version > currentVersion {
+		return nil, fmt.Errorf("store file version %d is newer than this version of dbtree supports, please update dbtree", ef.Version)
 	}
 
-	key := deriveKey(password, ef.Salt)
-	s := &Store{filePath: filePath, key: key}
+	s.kdf, err = ef.kdfParams()
+	if err != nil {
+		return nil, err
+	}
+	s.key = deriveKey(password, ef.Salt, s.kdf)
 
-	// Verify password by decrypting the already-read data
-	if _, err := s.decryptConnections(ef); err != nil {
+	connections, err := s.decryptConnections(ef)
+	if err != nil {
 		return nil, ErrWrongPassword
 	}
+	s.base = connections
+
+	if upgraded := s.kdf.atLeast(DefaultKDFParams); ef.Version < currentVersion || upgraded != s.kdf {
+		if err := s.rewrite(connections, password, upgraded); err != nil {
+			return nil, fmt.Errorf("failed to upgrade store file: %w", err)
+		}
+	}
 
 	return s, nil
 }
 
+// Exists reports whether a connection store has been created.
+func Exists() (bool, error) {
+	dir, err := storeDir()
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "connections.json")); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check store file: %w", err)
+	}
+
+	return true, nil
+}
+
 // Load decrypts and returns all saved connections.
 func (s *Store) Load() ([]Connection, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	connections, _, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	s.base = connections
+	return slices.Clone(connections), nil
+}
+
+// load reads and decrypts the connections along with the salt of the file.
+func (s *Store) load() ([]Connection, []byte, error) {
+	ef, err := s.readFile()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	connections, err := s.decryptConnections(ef)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return connections, ef.Salt, nil
+}
+
+// readFile reads the encrypted store file.
+func (s *Store) readFile() (encryptedFile, error) {
 	data, err := os.ReadFile(s.filePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read store file: %w", err)
+		return encryptedFile{}, fmt.Errorf("failed to read store file: %w", err)
 	}
 
 	var ef encryptedFile
 	if err := json.Unmarshal(data, &ef); err != nil {
-		return nil, fmt.Errorf("failed to parse store file: %w", err)
+		return encryptedFile{}, fmt.Errorf("failed to parse store file: %w", err)
 	}
 
-	return s.decryptConnections(ef)
+	return ef, nil
 }
 
 // decryptConnections decrypts an encryptedFile and returns the connections.
@@ -125,82 +247,283 @@ func (s *Store) decryptConnections(ef encryptedFile) ([]Connection, error) {
 	return connections, nil
 }
 
-// Save encrypts and writes the connections to disk.
+// Save encrypts and writes the connections to disk. If another process changed
+// the store since it was last loaded, its changes are merged with the ones
+// made to the loaded connections, with the latter winning on conflicts.
 func (s *Store) Save(connections []Connection) error {
-	// Read existing salt
-	data, err := os.ReadFile(s.filePath)
+	unlock, err := s.lock()
 	if err != nil {
-		return fmt.Errorf("failed to read store file: %w", err)
+		return err
 	}
+	defer unlock()
 
-	var ef encryptedFile
-	if err := json.Unmarshal(data, &ef); err != nil {
-		return fmt.Errorf("failed to parse store file: %w", err)
+	current, salt, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	if !slices.EqualFunc(current, s.base, Connection.equal) {
+		connections = merge(s.base, connections, current)
 	}
 
-	return s.saveWithSalt(connections, ef.Salt)
+	return s.save(connections, salt)
 }
 
-// Add appends a new connection and saves. Returns an error if a connection
-// with the same name already exists.
-func (s *Store) Add(conn Connection) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// modify applies fn to the current connections and saves the result, holding
+// the lock throughout so that no concurrent change can be lost.
+func (s *Store) modify(fn func([]Connection) ([]Connection, error)) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
-	connections, err := s.Load()
+	connections, salt, err := s.load()
 	if err != nil {
 		return err
 	}
 
-	for _, existing := range connections {
-		if existing.Name == conn.Name {
-			return fmt.Errorf("connection name %q already exists", conn.Name)
-		}
+	connections, err = fn(connections)
+	if err != nil {
+		return err
 	}
 
-	conn.LastUsed = time.Now().Unix()
-	connections = append(connections, conn)
-	return s.Save(connections)
+	return s.save(connections, salt)
+}
+
+// Add appends a new connection and saves. Returns an error if a connection
+// with the same name already exists.
+func (s *Store) Add(conn Connection) error {
+	return s.modify(func(connections []Connection) ([]Connection, error) {
+		for _, existing := range connections {
+			if existing.Name == conn.Name {
+				return nil, fmt.Errorf("connection name %q already exists", conn.Name)
+			}
+		}
+
+		conn.LastUsed = time.Now().Unix()
+		return append(connections, conn), nil
+	})
 }
 
 // Remove deletes a connection by name and saves.
 func (s *Store) Remove(name string) error {
+	return s.modify(func(connections []Connection) ([]Connection, error) {
+		filtered := make([]Connection, 0, len(connections))
+		for _, c := range connections {
+			if c.Name != name {
+				filtered = append(filtered, c)
+			}
+		}
+		return filtered, nil
+	})
+}
+
+// Update replaces the connection named name with conn and saves. The connection
+// can be renamed as long as the new name is not taken, and its LastUsed
+// timestamp is kept.
+func (s *Store) Update(name string, conn Connection) error {
+	return s.modify(func(connections []Connection) ([]Connection, error) {
+		index := -1
+		for i, existing := range connections {
+			if existing.Name == name {
+				index = i
+			} else if existing.Name == conn.Name {
+				return nil, fmt.Errorf("connection name %q already exists", conn.Name)
+			}
+		}
+
+		if index < 0 {
+			return nil, fmt.Errorf("connection %q not found", name)
+		}
+
+		conn.LastUsed = connections[index].LastUsed
+		connections[index] = conn
+		return connections, nil
+	})
+}
+
+// TouchLastUsed updates the LastUsed timestamp for the named connection.
+func (s *Store) TouchLastUsed(name string) error {
+	return s.modify(func(connections []Connection) ([]Connection, error) {
+		for i := range connections {
+			if connections[i].Name == name {
+				connections[i].LastUsed = time.Now().Unix()
+				break
+			}
+		}
+		return connections, nil
+	})
+}
+
+// ChangePassword re-encrypts the saved connections under a new password with a
+// fresh salt. The old password must be the one the store was opened with. The
+// file is replaced atomically, so it is never left half re-encrypted.
+func (s *Store) ChangePassword(oldPassword, newPassword string) error {
+	if newPassword == "" {
+		return fmt.Errorf("new password cannot be empty")
+	}
+
+	return s.reencrypt(oldPassword, newPassword, nil)
+}
+
+// Rekey re-encrypts the saved connections with a key derived with new KDF
+// parameters, for example to raise their cost. The password must be the one
+// the store was opened with. Parameters below DefaultKDFParams are rejected,
+// as unlocking would raise them again.
+func (s *Store) Rekey(password string, params KDFParams) error {
+	if err := params.Validate(); err != nil {
+		return err
+	}
+	if params.atLeast(DefaultKDFParams) != params {
+		return fmt.Errorf("key derivation parameters must be at least those of %s", DefaultKDFParams)
+	}
+
+	return s.reencrypt(password, password, &params)
+}
+
+// KDFParams returns the parameters the key of the store is derived with.
+func (s *Store) KDFParams() KDFParams {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	connections, err := s.Load()
+	return s.kdf
+}
+
+// reencrypt verifies the old password and rewrites the store under the new
+// password, with new KDF parameters if params is set.
+func (s *Store) reencrypt(oldPassword, newPassword string, params *KDFParams) error {
+	unlock, err := s.lock()
 	if err != nil {
 		return err
 	}
+	defer unlock()
 
-	filtered := make([]Connection, 0, len(connections))
-	for _, c := range connections {
-		if c.Name != name {
-			filtered = append(filtered, c)
-		}
+	ef, err := s.readFile()
+	if err != nil {
+		return err
+	}
+
+	current, err := ef.kdfParams()
+	if err != nil {
+		return err
+	}
+
+	if subtle.ConstantTimeCompare(deriveKey(oldPassword, ef.Salt, current), s.key) != 1 {
+		return ErrWrongPassword
+	}
+
+	connections, err := s.decryptConnections(ef)
+	if err != nil {
+		return err
 	}
 
-	return s.Save(filtered)
+	if params == nil {
+		params = &current
+	}
+	return s.rewrite(connections, newPassword, *params)
 }
 
-// TouchLastUsed updates the LastUsed timestamp for the named connection.
-func (s *Store) TouchLastUsed(name string) error {
+// rewrite saves the connections under a key derived from password with a fresh
+// salt and the given parameters. The caller must hold the lock. On failure the
+// previous key is kept, as the file has not been replaced.
+func (s *Store) rewrite(connections []Connection, password string, params KDFParams) error {
+	salt, err := newSalt()
+	if err != nil {
+		return err
+	}
+
+	oldKey, oldKDF := s.key, s.kdf
+	s.key, s.kdf = deriveKey(password, salt, params), params
+	if err := s.save(connections, salt); err != nil {
+		s.key, s.kdf = oldKey, oldKDF
+		return err
+	}
+
+	return nil
+}
+
+// lock takes the store lock, both within this process and across processes.
+// The returned function releases it.
+func (s *Store) lock() (func(), error) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
 
-	connections, err := s.Load()
+	f, err := os.OpenFile(s.filePath+".lock", os.O_RDWR|os.O_CREATE, 0600)
 	if err != nil {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	if err := lockFile(f); err != nil {
+		f.Close()
+		s.mu.Unlock()
+		return nil, fmt.Errorf("failed to lock store: %w", err)
+	}
+
+	return func() {
+		unlockFile(f)
+		f.Close()
+		s.mu.Unlock()
+	}, nil
+}
+
+// save writes the connections and remembers them as the base of later merges.
+// The caller must hold the lock.
+func (s *Store) save(connections []Connection, salt []byte) error {
+	if err := s.saveWithSalt(connections, salt); err != nil {
 		return err
 	}
+	s.base = slices.Clone(connections)
+	return nil
+}
+
+// merge combines the changes made to base in ours with the changes made to it
+// concurrently in theirs. Where both changed the same connection, ours wins,
+// keeping the later LastUsed timestamp.
+func merge(base, ours, theirs []Connection) []Connection {
+	byName := func(connections []Connection) map[string]Connection {
+		m := make(map[string]Connection, len(connections))
+		for _, c := range connections {
+			m[c.Name] = c
+		}
+		return m
+	}
+	baseByName, oursByName, theirsByName := byName(base), byName(ours), byName(theirs)
+
+	var merged []Connection
+
+	for _, t := range theirs {
+		b, inBase := baseByName[t.Name]
+		o, inOurs := oursByName[t.Name]
+		switch {
+		case inOurs && (!inBase || !o.equal(b)):
+			// added or changed by us
+			o.LastUsed = max(o.LastUsed, t.LastUsed)
+			merged = append(merged, o)
+		case inOurs:
+			// unchanged by us
+			merged = append(merged, t)
+		case !inBase:
+			// added by them
+			merged = append(merged, t)
+		}
+		// otherwise removed by us
+	}
 
-	for i := range connections {
-		if connections[i].Name == name {
-			connections[i].LastUsed = time.Now().Unix()
-			break
+	for _, o := range ours {
+		if _, inTheirs := theirsByName[o.Name]; inTheirs {
+			continue
+		}
+		// Added by us, or removed by them; a removal loses to our own changes
+		if b, inBase := baseByName[o.Name]; !inBase || !o.equal(b) {
+			merged = append(merged, o)
 		}
 	}
 
-	return s.Save(connections)
+	if merged == nil {
+		merged = []Connection{}
+	}
+	return merged
 }
 
 func (s *Store) saveWithSalt(connections []Connection, salt []byte) error {
@@ -214,7 +537,10 @@ func (s *Store) saveWithSalt(connections []Connection, salt []byte) error {
 		return err
 	}
 
+	kdf := s.kdf
 	ef := encryptedFile{
+		Version:    currentVersion,
+		KDF:        &kdf,
 		Salt:       salt,
 		Nonce:      nonce,
 		Ciphertext: ciphertext,
@@ -225,12 +551,12 @@ func (s *Store) saveWithSalt(connections []Connection, salt []byte) error {
 		return fmt.Errorf("failed to marshal encrypted file: %w", err)
 	}
 
-	// Atomic write: write to temp file, fsync, then rename into place.
-	tmpPath := s.filePath + ".tmp"
-	f, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	// Atomic write: write to a temp file, fsync, then rename into place.
+	f, err := os.CreateTemp(filepath.Dir(s.filePath), "connections-*.tmp")
 	if err != nil {
 		return fmt.Errorf("failed to create temp file: %w", err)
 	}
+	tmpPath := f.Name()
 
 	if _, err := f.Write(data); err != nil {
 		f.Close()
@@ -257,17 +583,12 @@ func (s *Store) saveWithSalt(connections []Connection, salt []byte) error {
 	return nil
 }
 
-// Argon2id parameters. OWASP recommends time=2, memory=19456 as a baseline.
-// Higher values are more secure but slower to unlock.
-const (
-	argon2Time    = 2
-	argon2Memory  = 19 * 1024 // 19 MiB
-	argon2Threads = 4
-	argon2KeyLen  = 32
-)
-
-func deriveKey(password string, salt []byte) []byte {
-	return argon2.IDKey([]byte(password), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+func newSalt() ([]byte, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	return salt, nil
 }
 
 func encrypt(key, plaintext []byte) (nonce, ciphertext []byte, err error) {
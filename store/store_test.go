@@ -0,0 +1,516 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// newTestStore opens a store under a temporary home directory.
+func newTestStore(t *testing.T, password string) *Store {
+	t.Helper()
+
+	s, err := NewStore(password)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	return s
+}
+
+func connectionNames(t *testing.T, s *Store) []string {
+	t.Helper()
+
+	connections, err := s.Load()
+	if err != nil {
+		t.Fatalf("failed to load connections: %v", err)
+	}
+
+	names := make([]string, len(connections))
+	for i, conn := range connections {
+		names[i] = conn.Name
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestStore(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	s := newTestStore(t, "secret")
+
+	if err := s.Add(Connection{Name: "local", URL: "app.db", Driver: "sqlite3"}); err != nil {
+		t.Fatalf("failed to add connection: %v", err)
+	}
+	if err := s.Add(Connection{Name: "local", URL: "other.db", Driver: "sqlite3"}); err == nil {
+		t.Error("expected an error adding a duplicate name")
+	}
+	if err := s.Add(Connection{Name: "prod", URL: "postgres://u:p@h/db", Driver: "postgres"}); err != nil {
+		t.Fatalf("failed to add connection: %v", err)
+	}
+
+	if err := s.Update("prod", Connection{Name: "production", URL: "postgres://u:p@h/db", Driver: "postgres"}); err != nil {
+		t.Fatalf("failed to update connection: %v", err)
+	}
+	if err := s.Update("production", Connection{Name: "local"}); err == nil {
+		t.Error("expected an error renaming onto a taken name")
+	}
+
+	if err := s.Remove("local"); err != nil {
+		t.Fatalf("failed to remove connection: %v", err)
+	}
+
+	reopened := newTestStore(t, "secret")
+	if got := connectionNames(t, reopened); fmt.Sprint(got) != "[production]" {
+		t.Errorf("expected [production], got %v", got)
+	}
+
+	if _, err := NewStore("wrong"); !errors.Is(err, ErrWrongPassword) {
+		t.Errorf("expected ErrWrongPassword, got %v", err)
+	}
+
+	if err := s.ChangePassword("wrong", "new secret"); !errors.Is(err, ErrWrongPassword) {
+		t.Errorf("expected ErrWrongPassword, got %v", err)
+	}
+	if err := s.ChangePassword("secret", "new secret"); err != nil {
+		t.Fatalf("failed to change password: %v", err)
+	}
+	if _, err := NewStore("secret"); !errors.Is(err, ErrWrongPassword) {
+		t.Errorf("expected the old password to be rejected, got %v", err)
+	}
+	if got := connectionNames(t, newTestStore(t, "new secret")); fmt.Sprint(got) != "[production]" {
+		t.Errorf("expected [production] after changing the password, got %v", got)
+	}
+
+	// Temp files of the atomic writes must not be left behind
+	entries, err := os.ReadDir(filepath.Join(os.Getenv("HOME"), ".dbtree"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if filepath.Ext(entry.Name()) == ".tmp" {
+			t.Errorf("temp file %s left behind", entry.Name())
+		}
+	}
+}
+
+func TestStoreSaveMergesConcurrentChanges(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	ours := newTestStore(t, "secret")
+	for _, name := range []string{"a", "b", "c"} {
+		if err := ours.Add(Connection{Name: name, URL: name + ".db", Driver: "sqlite3"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	connections, err := ours.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Another process adds d, edits b and removes c in the meantime
+	theirs := newTestStore(t, "secret")
+	if err := theirs.Add(Connection{Name: "d", URL: "d.db", Driver: "sqlite3"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := theirs.Update("b", Connection{Name: "b", URL: "theirs.db", Driver: "sqlite3"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := theirs.Remove("c"); err != nil {
+		t.Fatal(err)
+	}
+
+	// We remove a and edit c, working from the stale list
+	var edited []Connection
+	for _, conn := range connections {
+		switch conn.Name {
+		case "a":
+			continue
+		case "c":
+			conn.URL = "ours.db"
+		}
+		edited = append(edited, conn)
+	}
+	edited = append(edited, Connection{Name: "e", URL: "e.db", Driver: "sqlite3"})
+
+	if err := ours.Save(edited); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+
+	saved, err := newTestStore(t, "secret").Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	urls := make(map[string]string)
+	for _, conn := range saved {
+		urls[conn.Name] = conn.URL
+	}
+
+	expected := map[string]string{
+		"b": "theirs.db", // changed by them only
+		"c": "ours.db",   // our edit wins over their removal
+		"d": "d.db",      // added by them
+		"e": "e.db",      // added by us
+	}
+	if fmt.Sprint(urls) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, urls)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	conn := func(name, url string, lastUsed int64) Connection {
+		return Connection{Name: name, URL: url, Driver: "sqlite3", LastUsed: lastUsed}
+	}
+
+	tests := []struct {
+		name     string
+		base     []Connection
+		ours     []Connection
+		theirs   []Connection
+		expected []Connection
+	}{
+		{
+			name:     "no changes",
+			base:     []Connection{conn("a", "a.db", 1)},
+			ours:     []Connection{conn("a", "a.db", 1)},
+			theirs:   []Connection{conn("a", "a.db", 1)},
+			expected: []Connection{conn("a", "a.db", 1)},
+		},
+		{
+			name:     "both changed, ours wins with the later timestamp",
+			base:     []Connection{conn("a", "a.db", 1)},
+			ours:     []Connection{conn("a", "ours.db", 1)},
+			theirs:   []Connection{conn("a", "theirs.db", 5)},
+			expected: []Connection{conn("a", "ours.db", 5)},
+		},
+		{
+			name:     "removed by us, touched by them",
+			base:     []Connection{conn("a", "a.db", 1), conn("b", "b.db", 1)},
+			ours:     []Connection{conn("b", "b.db", 1)},
+			theirs:   []Connection{conn("a", "a.db", 5), conn("b", "b.db", 1)},
+			expected: []Connection{conn("b", "b.db", 1)},
+		},
+		{
+			name:     "removed by them, unchanged by us",
+			base:     []Connection{conn("a", "a.db", 1)},
+			ours:     []Connection{conn("a", "a.db", 1)},
+			theirs:   []Connection{},
+			expected: []Connection{},
+		},
+		{
+			name:     "tags changed by them",
+			base:     []Connection{conn("a", "a.db", 1)},
+			ours:     []Connection{conn("a", "a.db", 1)},
+			theirs:   []Connection{{Name: "a", URL: "a.db", Driver: "sqlite3", Environment: "prod", Tags: []string{"core"}, LastUsed: 1}},
+			expected: []Connection{{Name: "a", URL: "a.db", Driver: "sqlite3", Environment: "prod", Tags: []string{"core"}, LastUsed: 1}},
+		},
+		{
+			name:     "added by both",
+			base:     []Connection{},
+			ours:     []Connection{conn("a", "ours.db", 1)},
+			theirs:   []Connection{conn("b", "theirs.db", 1)},
+			expected: []Connection{conn("b", "theirs.db", 1), conn("a", "ours.db", 1)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := merge(tt.base, tt.ours, tt.theirs)
+			if fmt.Sprint(got) != fmt.Sprint(tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestConnectionLabels(t *testing.T) {
+	if got := NormalizeTags([]string{" core", "", "Billing", "core ", "billing"}); fmt.Sprint(got) != "[core Billing]" {
+		t.Errorf("expected [core Billing], got %v", got)
+	}
+
+	conn := Connection{Name: "a", Environment: "Production", Tags: []string{"Billing"}}
+	if !conn.IsProduction() {
+		t.Error("expected Production to be a production environment")
+	}
+	if (Connection{Environment: "staging"}).IsProduction() {
+		t.Error("expected staging not to be a production environment")
+	}
+	if !conn.HasTag("billing") || conn.HasTag("core") {
+		t.Errorf("unexpected tags match for %v", conn.Tags)
+	}
+}
+
+func TestStoreConcurrentWriters(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	newTestStore(t, "secret")
+
+	// Separate stores share no mutex, so only the file lock keeps their
+	// read-modify-write cycles from interleaving
+	const writers, perWriter = 4, 5
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*perWriter)
+	for w := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s, err := NewStore("secret")
+			if err != nil {
+				errs <- err
+				return
+			}
+			for i := range perWriter {
+				errs <- s.Add(Connection{Name: fmt.Sprintf("w%d-%d", w, i), URL: "x.db", Driver: "sqlite3"})
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("concurrent add failed: %v", err)
+		}
+	}
+
+	if got := len(connectionNames(t, newTestStore(t, "secret"))); got != writers*perWriter {
+		t.Errorf("expected %d connections, got %d", writers*perWriter, got)
+	}
+}
+
+// TestStoreConcurrentProcesses runs writers in separate processes, by
+// re-running the test binary as TestStoreWriterProcess.
+func TestStoreConcurrentProcesses(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping multi-process test in short mode")
+	}
+
+	t.Setenv("HOME", t.TempDir())
+	newTestStore(t, "secret")
+
+	const processes, perProcess = 3, 5
+	cmds := make([]*exec.Cmd, processes)
+	for p := range processes {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestStoreWriterProcess$")
+		cmd.Env = append(os.Environ(),
+			"DBTREE_STORE_WRITER="+strconv.Itoa(p),
+			"DBTREE_STORE_WRITES="+strconv.Itoa(perProcess))
+		if err := cmd.Start(); err != nil {
+			t.Fatalf("failed to start writer process: %v", err)
+		}
+		cmds[p] = cmd
+	}
+
+	for _, cmd := range cmds {
+		if err := cmd.Wait(); err != nil {
+			t.Fatalf("writer process failed: %v", err)
+		}
+	}
+
+	if got := len(connectionNames(t, newTestStore(t, "secret"))); got != processes*perProcess {
+		t.Errorf("expected %d connections, got %d", processes*perProcess, got)
+	}
+}
+
+// TestStoreWriterProcess is the body of the writer processes started by
+// TestStoreConcurrentProcesses, and does nothing when run directly.
+func TestStoreWriterProcess(t *testing.T) {
+	writer := os.Getenv("DBTREE_STORE_WRITER")
+	if writer == "" {
+		return
+	}
+
+	writes, err := strconv.Atoi(os.Getenv("DBTREE_STORE_WRITES"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := newTestStore(t, "secret")
+	for i := range writes {
+		if err := s.Add(Connection{Name: fmt.Sprintf("p%s-%d", writer, i), URL: "x.db", Driver: "sqlite3"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// writeLegacyFile writes a store file in the format used before the header
+// recorded a version and KDF parameters.
+func writeLegacyFile(t *testing.T, password string, connections []Connection) string {
+	t.Helper()
+
+	salt, err := newSalt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := json.Marshal(connections)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce, ciphertext, err := encrypt(deriveKey(password, salt, legacyKDFParams), plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(map[string][]byte{"salt": salt, "nonce": nonce, "ciphertext": ciphertext})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Join(os.Getenv("HOME"), ".dbtree")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "connections.json")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func readHeader(t *testing.T, path string) encryptedFile {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ef encryptedFile
+	if err := json.Unmarshal(data, &ef); err != nil {
+		t.Fatal(err)
+	}
+	return ef
+}
+
+func TestStoreMigratesLegacyFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	path := writeLegacyFile(t, "secret", []Connection{{Name: "local", URL: "app.db", Driver: "sqlite3"}})
+
+	if _, err := NewStore("wrong"); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("expected ErrWrongPassword, got %v", err)
+	}
+	if ef := readHeader(t, path); ef.Version != 0 {
+		t.Fatalf("expected a failed unlock to leave the file alone, got version %d", ef.Version)
+	}
+
+	s := newTestStore(t, "secret")
+
+	ef := readHeader(t, path)
+	if ef.Version != currentVersion {
+		t.Errorf("expected version %d after unlocking, got %d", currentVersion, ef.Version)
+	}
+	if ef.KDF == nil || *ef.KDF != DefaultKDFParams {
+		t.Errorf("expected KDF parameters %v, got %v", DefaultKDFParams, ef.KDF)
+	}
+
+	if got := connectionNames(t, s); fmt.Sprint(got) != "[local]" {
+		t.Errorf("expected [local], got %v", got)
+	}
+	if got := connectionNames(t, newTestStore(t, "secret")); fmt.Sprint(got) != "[local]" {
+		t.Errorf("expected [local] after reopening, got %v", got)
+	}
+}
+
+func TestStoreRejectsNewerVersion(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	newTestStore(t, "secret")
+
+	path := filepath.Join(os.Getenv("HOME"), ".dbtree", "connections.json")
+	ef := readHeader(t, path)
+	ef.Version = currentVersion + 1
+	data, err := json.Marshal(ef)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewStore("secret"); err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Errorf("expected an error about a newer version, got %v", err)
+	}
+}
+
+func TestStoreRekey(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	s := newTestStore(t, "secret")
+	if err := s.Add(Connection{Name: "local", URL: "app.db", Driver: "sqlite3"}); err != nil {
+		t.Fatal(err)
+	}
+
+	stronger := DefaultKDFParams
+	stronger.Time++
+	stronger.Memory *= 2
+
+	if err := s.Rekey("wrong", stronger); !errors.Is(err, ErrWrongPassword) {
+		t.Errorf("expected ErrWrongPassword, got %v", err)
+	}
+
+	invalid := stronger
+	invalid.Threads = 0
+	if err := s.Rekey("secret", invalid); err == nil {
+		t.Error("expected an error for invalid parameters")
+	}
+
+	weaker := DefaultKDFParams
+	weaker.Memory /= 2
+	if err := s.Rekey("secret", weaker); err == nil {
+		t.Error("expected an error for parameters below the defaults")
+	}
+
+	if err := s.Rekey("secret", stronger); err != nil {
+		t.Fatalf("failed to rekey: %v", err)
+	}
+	if got := s.KDFParams(); got != stronger {
+		t.Errorf("expected %v, got %v", stronger, got)
+	}
+
+	// The store keeps working, and stronger parameters are not downgraded
+	if err := s.Add(Connection{Name: "other", URL: "other.db", Driver: "sqlite3"}); err != nil {
+		t.Fatalf("failed to add after rekeying: %v", err)
+	}
+	reopened := newTestStore(t, "secret")
+	if got := reopened.KDFParams(); got != stronger {
+		t.Errorf("expected %v after reopening, got %v", stronger, got)
+	}
+	if got := connectionNames(t, reopened); fmt.Sprint(got) != "[local other]" {
+		t.Errorf("expected [local other], got %v", got)
+	}
+}
+
+func TestKDFParamsValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		edit  func(*KDFParams)
+		valid bool
+	}{
+		{"defaults", func(p *KDFParams) {}, true},
+		{"unknown algorithm", func(p *KDFParams) { p.Algorithm = "scrypt" }, false},
+		{"no passes", func(p *KDFParams) { p.Time = 0 }, false},
+		{"too many passes", func(p *KDFParams) { p.Time = math.MaxUint32 }, false},
+		{"no threads", func(p *KDFParams) { p.Threads = 0 }, false},
+		{"too many threads", func(p *KDFParams) { p.Threads = math.MaxUint8 }, false},
+		{"too little memory", func(p *KDFParams) { p.Memory = 8 }, false},
+		{"most memory", func(p *KDFParams) { p.Memory = maxKDFMemory }, true},
+		{"too much memory", func(p *KDFParams) { p.Memory = math.MaxUint32 }, false},
+		{"short key", func(p *KDFParams) { p.KeyLen = 16 }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := DefaultKDFParams
+			tt.edit(&params)
+			if err := params.Validate(); (err == nil) != tt.valid {
+				t.Errorf("expected valid=%v, got %v", tt.valid, err)
+			}
+		})
+	}
+}
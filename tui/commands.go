@@ -0,0 +1,349 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/viveknathani/dbtree/graph"
+	"github.com/viveknathani/dbtree/render"
+)
+
+// commandNames lists the commands understood in the schema view, for completion.
+var commandNames = []string{"connect", "depth", "export", "filter", "format", "path", "quit", "shape", "table"}
+
+var (
+	formatNames = []string{string(render.FormatText), string(render.FormatJSON)}
+	shapeNames  = []string{string(render.ShapeTree), string(render.ShapeFlat), string(render.ShapeChart)}
+)
+
+func (m model) handleCommandBuf(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		line := strings.TrimSpace(strings.TrimPrefix(m.commandBuf, ":"))
+		m.commandBuf = ""
+		return m.runCommand(line)
+	case tea.KeyEsc:
+		m.commandBuf = ""
+		m.statusMsg = ""
+		return m, nil
+	case tea.KeyTab:
+		m = m.completeCommand()
+		return m, nil
+	case tea.KeyBackspace:
+		if len(m.commandBuf) > 1 {
+			m.commandBuf = m.commandBuf[:len(m.commandBuf)-1]
+		} else {
+			m.commandBuf = ""
+		}
+		return m, nil
+	case tea.KeySpace:
+		m.commandBuf += " "
+		return m, nil
+	default:
+		if msg.Type == tea.KeyRunes {
+			m.commandBuf += msg.String()
+		}
+		return m, nil
+	}
+}
+
+// exportFormatNames lists the formats the current view can be exported in.
+func exportFormatNames() []string {
+	names := make([]string, len(render.Formats))
+	for i, format := range render.Formats {
+		names[i] = string(format)
+	}
+	return names
+}
+
+// exportView renders the current view in format and saves it to path.
+func (m model) exportView(path string, format render.Format) (tea.Model, tea.Cmd) {
+	if m.graph == nil {
+		return m.commandError("no schema loaded")
+	}
+	if format == render.FormatJSON && m.shape == render.ShapeChart {
+		return m.commandError("chart shape is only supported with text format")
+	}
+	output, err := renderView(m.graph, m.viewOptions(), format)
+	if err != nil {
+		return m.commandError("%v", err)
+	}
+	if !strings.HasSuffix(output, "\n") {
+		output += "\n"
+	}
+	if err := os.WriteFile(path, []byte(output), 0644); err != nil {
+		return m.commandError("failed to export: %v", err)
+	}
+	m.statusMsg, m.statusErr = fmt.Sprintf("exported %s %s to %s", format, m.shape, path), false
+	return m, nil
+}
+
+// exportOutputs saves the current view to each of the configured outputs.
+func (m model) exportOutputs() (tea.Model, tea.Cmd) {
+	for _, output := range m.outputs {
+		format, err := render.FormatForPath(output)
+		if err != nil {
+			return m.commandError("%v", err)
+		}
+		next, _ := m.exportView(output, format)
+		m = next.(model)
+		if m.statusErr {
+			return m, nil
+		}
+	}
+	m.statusMsg, m.statusErr = "exported to "+strings.Join(m.outputs, ", "), false
+	return m, nil
+}
+
+// exportPath is the file the e key saves the current view to, named after
+// the connection and shape, in the working directory.
+func (m model) exportPath() string {
+	name := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' {
+			return '_'
+		}
+		return r
+	}, m.connName())
+	return name + "-" + string(m.shape) + m.format.Extension()
+}
+
+// runCommand executes a command line entered after ":".
+func (m model) runCommand(line string) (tea.Model, tea.Cmd) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return m, nil
+	}
+	name, args := fields[0], fields[1:]
+
+	if name == "q" || name == "quit" {
+		m.quitting = true
+		return m, tea.Quit
+	}
+
+	if m.loading {
+		return m.commandError("schema is still loading")
+	}
+
+	switch name {
+	case "table":
+		if len(args) != 1 {
+			return m.commandError("usage: :table <name>")
+		}
+		if m.graph == nil || m.graph.Nodes[graph.TableName(args[0])] == nil {
+			return m.commandError("table %s not found", args[0])
+		}
+		m.tableFilter.SetValue("")
+		m = m.jumpToTable(args[0])
+		m.detailFocus = false
+		m.statusMsg = ""
+		m.state = stateTables
+		return m, nil
+
+	case "depth":
+		if len(args) != 1 {
+			return m.commandError("usage: :depth <n> (0 shows all levels)")
+		}
+		depth, err := strconv.Atoi(args[0])
+		if err != nil || depth < 0 {
+			return m.commandError("depth must be a non-negative number")
+		}
+		m.maxDepth = depth
+		m.statusMsg, m.statusErr = "", false
+		if m.shape != render.ShapeTree && depth > 0 {
+			m.statusMsg = "depth only applies to the tree shape"
+		}
+		return m.refreshView()
+
+	case "filter":
+		for _, pattern := range args {
+			if _, err := path.Match(strings.TrimPrefix(pattern, "!"), ""); err != nil {
+				return m.commandError("invalid pattern %q", pattern)
+			}
+		}
+		m.filter = args
+		m.statusMsg, m.statusErr = "", false
+		return m.refreshView()
+
+	case "format":
+		if len(args) != 1 || !slices.Contains(formatNames, args[0]) {
+			return m.commandError("usage: :format %s", strings.Join(formatNames, "|"))
+		}
+		if render.Format(args[0]) == render.FormatJSON && m.shape == render.ShapeChart {
+			return m.commandError("chart shape is only supported with text format")
+		}
+		m.format = render.Format(args[0])
+		m.statusMsg, m.statusErr = "", false
+		return m.refreshView()
+
+	case "shape":
+		if len(args) != 1 || !slices.Contains(shapeNames, args[0]) {
+			return m.commandError("usage: :shape %s", strings.Join(shapeNames, "|"))
+		}
+		if render.Shape(args[0]) == render.ShapeChart && m.format == render.FormatJSON {
+			return m.commandError("chart shape is only supported with text format")
+		}
+		m.shape = render.Shape(args[0])
+		m.statusMsg, m.statusErr = "", false
+		return m.refreshView()
+
+	case "export":
+		if len(args) < 1 || len(args) > 2 {
+			return m.commandError("usage: :export <path> [%s]", strings.Join(exportFormatNames(), "|"))
+		}
+		// The format is inferred from the extension, falling back to the
+		// format of the current view
+		format, err := render.FormatForPath(args[0])
+		if err != nil {
+			format = m.format
+		}
+		if len(args) == 2 {
+			if !slices.Contains(render.Formats, render.Format(args[1])) {
+				return m.commandError("unsupported format: %s", args[1])
+			}
+			format = render.Format(args[1])
+		}
+		return m.exportView(args[0], format)
+
+	case "path":
+		if len(args) != 2 {
+			return m.commandError("usage: :path <from> <to>")
+		}
+		if m.graph == nil {
+			return m.commandError("no schema loaded")
+		}
+		steps, err := graph.ShortestPath(m.graph, graph.TableName(args[0]), graph.TableName(args[1]))
+		if err != nil {
+			return m.commandError("%v", err)
+		}
+		m.statusMsg, m.statusErr = "path: "+formatPath(args[0], steps), false
+		return m, nil
+
+	case "connect":
+		if len(args) != 1 {
+			return m.commandError("usage: :connect <name>")
+		}
+		for i := range m.connections {
+			if m.connections[i].Name != args[0] {
+				continue
+			}
+			conn := m.connections[i]
+			m.closeDB()
+			m.searchInput.SetValue("")
+			m.searchMatches = nil
+			m.currentConn = &conn
+			m.statusMsg, m.statusErr = "", false
+			m.loading = true
+			m.schemaReqID++
+			return m, loadSchema(&conn, nil, nil, m.viewOptions(), m.schemaReqID)
+		}
+		return m.commandError("connection %s not found", args[0])
+
+	default:
+		return m.commandError("unknown command: %s", name)
+	}
+}
+
+// commandError shows a command error in the status bar.
+func (m model) commandError(format string, args ...any) (tea.Model, tea.Cmd) {
+	m.statusMsg = fmt.Sprintf(format, args...)
+	m.statusErr = true
+	return m, nil
+}
+
+// completeCommand completes the word being typed in the command buffer. A
+// unique candidate is completed in full; otherwise the common prefix of the
+// candidates is filled in and the candidates are listed in the status bar.
+func (m model) completeCommand() model {
+	line := strings.TrimPrefix(m.commandBuf, ":")
+	fields := strings.Fields(line)
+	if len(fields) == 0 || strings.HasSuffix(line, " ") {
+		fields = append(fields, "")
+	}
+
+	word := fields[len(fields)-1]
+	var candidates []string
+	for _, candidate := range m.completionCandidates(fields[0], len(fields)-1) {
+		if strings.HasPrefix(candidate, word) {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return m
+	}
+
+	completion := candidates[0]
+	if len(candidates) == 1 {
+		completion += " "
+	} else {
+		for _, candidate := range candidates[1:] {
+			for !strings.HasPrefix(candidate, completion) {
+				completion = completion[:len(completion)-1]
+			}
+		}
+		m.statusMsg, m.statusErr = strings.Join(candidates, "  "), false
+	}
+
+	m.commandBuf = ":" + strings.Join(append(fields[:len(fields)-1], completion), " ")
+	return m
+}
+
+// completionCandidates returns the possible values of argument position of a
+// command, where position 0 is the command name itself.
+func (m model) completionCandidates(command string, position int) []string {
+	if position == 0 {
+		return commandNames
+	}
+
+	switch {
+	case command == "table" && position == 1, command == "path" && position <= 2:
+		var names []string
+		if m.schema != nil {
+			for _, table := range m.schema.Tables {
+				names = append(names, table.Name)
+			}
+		}
+		sort.Strings(names)
+		return names
+	case command == "connect" && position == 1:
+		var names []string
+		for _, conn := range m.connections {
+			names = append(names, conn.Name)
+		}
+		return names
+	case command == "format" && position == 1:
+		return formatNames
+	case command == "export" && position == 2:
+		return exportFormatNames()
+	case command == "shape" && position == 1:
+		return shapeNames
+	}
+
+	return nil
+}
+
+// formatPath describes a path between tables, with arrows pointing along the
+// direction of each relationship.
+func formatPath(from string, steps []graph.PathStep) string {
+	if len(steps) == 0 {
+		return from
+	}
+
+	var sb strings.Builder
+	sb.WriteString(from)
+	for _, step := range steps {
+		arrow := " ← "
+		if step.Forward {
+			arrow = " → "
+		}
+		sb.WriteString(arrow)
+		sb.WriteString(string(step.To))
+	}
+	return sb.String()
+}
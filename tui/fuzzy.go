@@ -0,0 +1,46 @@
+package tui
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// fuzzyScore reports whether the characters of pattern appear in s in order,
+// ignoring case. Matches score higher when characters are consecutive or start
+// a word, so that "ord" ranks orders above product_descriptions.
+func fuzzyScore(pattern, s string) (int, bool) {
+	pattern = strings.ToLower(pattern)
+	s = strings.ToLower(s)
+
+	score := 0
+	consecutive := false
+	prev := rune(0)
+	pi := 0
+	for _, r := range s {
+		if pi == len(pattern) {
+			break
+		}
+		p, size := utf8.DecodeRuneInString(pattern[pi:])
+		if r == p {
+			score++
+			if consecutive {
+				score += 2
+			}
+			if prev == 0 || prev == '_' || prev == '.' || prev == '-' {
+				score += 3
+			}
+			pi += size
+			consecutive = true
+		} else {
+			consecutive = false
+		}
+		prev = r
+	}
+
+	if pi < len(pattern) {
+		return 0, false
+	}
+
+	// Prefer shorter names among equally good matches
+	return score*100 - utf8.RuneCountInString(s), true
+}
@@ -1,58 +1,156 @@
 package tui
 
 import (
+	"context"
 	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/viveknathani/dbtree/database"
+	"github.com/viveknathani/dbtree/store"
 )
 
+// menuSort is the order of the connections in the menu.
+type menuSort int
+
+const (
+	sortByName menuSort = iota
+	sortByLastUsed
+)
+
+// menuRow is a line of the connection menu: an environment group header, a
+// connection, or the option to add a new connection.
+type menuRow struct {
+	group  string // environment of the header or connection
+	header bool
+	conn   int // index into m.connections, -1 for headers and the new connection option
+	count  int // connections in the group, for headers
+	indent bool
+}
+
+// openConnection switches to the schema view of a saved connection.
+func (m model) openConnection(conn store.Connection) (tea.Model, tea.Cmd) {
+	m.currentConn = &conn
+	m.loading = true
+	m.state = stateSchema
+	m.schemaReqID++
+	return m, loadSchema(&conn, nil, nil, m.viewOptions(), m.schemaReqID)
+}
+
+// touchConnection records that the current connection was opened, for the
+// last used order of the menu. A failure is only shown in the status bar.
+func (m model) touchConnection() model {
+	if m.connStore == nil || m.currentConn == nil {
+		return m
+	}
+	saved := slices.ContainsFunc(m.connections, func(conn store.Connection) bool {
+		return conn.Name == m.currentConn.Name
+	})
+	if !saved {
+		return m
+	}
+	if err := m.connStore.TouchLastUsed(m.currentConn.Name); err != nil {
+		m.statusMsg, m.statusErr = fmt.Sprintf("failed to record last use: %v", err), true
+		return m
+	}
+	if connections, err := m.connStore.Load(); err == nil {
+		m.connections = connections
+		m = m.selectConnection(m.currentConn.Name)
+	}
+	return m
+}
+
 func (m model) updateMenu(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.confirmDelete != "" {
+			return m.updateDeleteConfirm(msg)
+		}
+		if m.menuFiltering {
+			return m.updateMenuFilter(msg)
+		}
+
+		rows := m.menuRows()
+		row := rows[min(m.menuCursor, len(rows)-1)]
+
 		switch msg.String() {
 		case "up", "k":
 			if m.menuCursor > 0 {
 				m.menuCursor--
 			}
 		case "down", "j":
-			// +1 for the "new connection" option
-			if m.menuCursor < len(m.connections) {
+			if m.menuCursor < len(rows)-1 {
 				m.menuCursor++
 			}
 		case "enter":
-			if m.menuCursor == len(m.connections) {
+			if row.header {
+				m = m.toggleGroup(row.group)
+				return m, nil
+			}
+			if row.conn < 0 {
 				// "New connection" selected
 				m.newConnInputs = initNewConnInputs()
 				m.newConnFocus = 0
 				m.newConnErr = ""
+				m.newConnMsg = ""
+				m.editingConn = ""
 				m.state = stateNewConn
 				return m, nil
 			}
 			// Connect to selected
-			conn := m.connections[m.menuCursor]
-			m.currentConn = &conn
-			m.loading = true
-			m.state = stateSchema
-			m.schemaReqID++
-			return m, loadSchema(&conn, m.format, m.shape, m.schemaReqID)
+			return m.openConnection(m.connections[row.conn])
+		case " ":
+			if row.header || row.conn >= 0 {
+				m = m.toggleGroup(row.group)
+			}
+		case "/":
+			m.menuFiltering = true
+			m.menuFilter.Focus()
+			return m, nil
+		case "esc":
+			if m.menuFilter.Value() != "" {
+				m.menuFilter.SetValue("")
+				m = m.keepMenuSelection(row)
+			}
+		case "o":
+			if m.menuSort == sortByName {
+				m.menuSort = sortByLastUsed
+			} else {
+				m.menuSort = sortByName
+			}
+			m = m.keepMenuSelection(row)
+		case "e":
+			if row.conn >= 0 {
+				conn := m.connections[row.conn]
+				m.newConnInputs = editConnInputs(conn)
+				m.newConnFocus = 0
+				m.newConnErr = ""
+				m.newConnMsg = ""
+				m.editingConn = conn.Name
+				m.state = stateNewConn
+			}
+		case "t":
+			if row.conn >= 0 {
+				conn := m.connections[row.conn]
+				m.menuErr = ""
+				m.menuMsg = "Testing " + conn.Name + "..."
+				m.testReqID++
+				return m, testConnection(conn, m.testReqID)
+			}
 		case "d":
-			if len(m.connections) > 0 && m.menuCursor < len(m.connections) {
-				name := m.connections[m.menuCursor].Name
-				if err := m.connStore.Remove(name); err != nil {
-					m.menuErr = fmt.Sprintf("failed to delete: %v", err)
-					return m, nil
-				}
-				connections, err := m.connStore.Load()
-				if err != nil {
-					m.menuErr = fmt.Sprintf("failed to reload: %v", err)
-					return m, nil
-				}
-				m.connections = connections
-				if m.menuCursor >= len(m.connections) && m.menuCursor > 0 {
-					m.menuCursor--
-				}
+			if row.conn >= 0 {
+				m.confirmDelete = m.connections[row.conn].Name
 				m.menuErr = ""
+				m.menuMsg = ""
 			}
+		case "P":
+			m.passwdInputs = initPasswdInputs()
+			m.passwdFocus = 0
+			m.passwdErr = ""
+			m.state = stateChangePassword
 		case "q":
 			m.quitting = true
 			return m, tea.Quit
@@ -62,36 +160,300 @@ func (m model) updateMenu(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// updateMenuFilter edits the filter of the connection menu.
+func (m model) updateMenuFilter(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.menuFiltering = false
+		m.menuFilter.Blur()
+		m.menuFilter.SetValue("")
+		m.menuCursor = 0
+		return m, nil
+	case tea.KeyEnter:
+		m.menuFiltering = false
+		m.menuFilter.Blur()
+		return m, nil
+	case tea.KeyUp:
+		if m.menuCursor > 0 {
+			m.menuCursor--
+		}
+		return m, nil
+	case tea.KeyDown:
+		if m.menuCursor < len(m.menuRows())-1 {
+			m.menuCursor++
+		}
+		return m, nil
+	}
+
+	previous := m.menuFilter.Value()
+	var cmd tea.Cmd
+	m.menuFilter, cmd = m.menuFilter.Update(msg)
+	if m.menuFilter.Value() != previous {
+		m.menuCursor = 0
+	}
+	return m, cmd
+}
+
+// toggleGroup collapses or expands an environment group, leaving the cursor
+// on its header.
+func (m model) toggleGroup(group string) model {
+	if m.collapsed == nil {
+		m.collapsed = make(map[string]bool)
+	}
+	m.collapsed[group] = !m.collapsed[group]
+
+	for i, row := range m.menuRows() {
+		if row.header && row.group == group {
+			m.menuCursor = i
+		}
+	}
+	return m
+}
+
+// keepMenuSelection moves the cursor back to the row that was selected before
+// the menu was reordered.
+func (m model) keepMenuSelection(selected menuRow) model {
+	name := ""
+	if selected.conn >= 0 {
+		name = m.connections[selected.conn].Name
+	}
+	return m.selectConnection(name)
+}
+
+// selectConnection moves the cursor to the named connection, or to the first
+// row if it is not visible.
+func (m model) selectConnection(name string) model {
+	m.menuCursor = 0
+	for i, row := range m.menuRows() {
+		if row.conn >= 0 && m.connections[row.conn].Name == name {
+			m.menuCursor = i
+		}
+	}
+	return m
+}
+
+// menuRows lists the lines of the menu. Connections matching the filter are
+// sorted, and grouped by environment once any connection has one.
+func (m model) menuRows() []menuRow {
+	query := m.menuFilter.Value()
+
+	var visible []int
+	for i, conn := range m.connections {
+		if matchesConnFilter(conn, query) {
+			visible = append(visible, i)
+		}
+	}
+
+	sort.SliceStable(visible, func(a, b int) bool {
+		ca, cb := m.connections[visible[a]], m.connections[visible[b]]
+		if m.menuSort == sortByLastUsed && ca.LastUsed != cb.LastUsed {
+			return ca.LastUsed > cb.LastUsed
+		}
+		return strings.ToLower(ca.Name) < strings.ToLower(cb.Name)
+	})
+
+	var rows []menuRow
+
+	grouped := slices.ContainsFunc(m.connections, func(c store.Connection) bool { return c.Environment != "" })
+	if !grouped {
+		for _, i := range visible {
+			rows = append(rows, menuRow{conn: i})
+		}
+		return append(rows, menuRow{conn: -1})
+	}
+
+	groups := make(map[string][]int)
+	var names []string
+	for _, i := range visible {
+		env := m.connections[i].Environment
+		if _, ok := groups[env]; !ok {
+			names = append(names, env)
+		}
+		groups[env] = append(groups[env], i)
+	}
+
+	// Connections without an environment come last
+	sort.Slice(names, func(a, b int) bool {
+		if (names[a] == "") != (names[b] == "") {
+			return names[b] == ""
+		}
+		return names[a] < names[b]
+	})
+
+	for _, env := range names {
+		rows = append(rows, menuRow{group: env, header: true, conn: -1, count: len(groups[env])})
+		// A filter shows every match, even in collapsed groups
+		if m.collapsed[env] && query == "" {
+			continue
+		}
+		for _, i := range groups[env] {
+			rows = append(rows, menuRow{group: env, conn: i, indent: true})
+		}
+	}
+
+	return append(rows, menuRow{conn: -1})
+}
+
+// matchesConnFilter reports whether a connection matches every word of the
+// filter. Words starting with "#" must be one of its tags, other words must
+// occur in its name, environment or driver.
+func matchesConnFilter(conn store.Connection, query string) bool {
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		if tag, ok := strings.CutPrefix(word, "#"); ok {
+			if !conn.HasTag(tag) {
+				return false
+			}
+			continue
+		}
+		text := strings.ToLower(conn.Name + " " + conn.Environment + " " + conn.Driver)
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	return true
+}
+
+// updateDeleteConfirm deletes the selected connection on "y" and cancels on any other key.
+func (m model) updateDeleteConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	name := m.confirmDelete
+	m.confirmDelete = ""
+
+	if msg.String() != "y" {
+		return m, nil
+	}
+
+	if err := m.connStore.Remove(name); err != nil {
+		m.menuErr = fmt.Sprintf("failed to delete: %v", err)
+		return m, nil
+	}
+	connections, err := m.connStore.Load()
+	if err != nil {
+		m.menuErr = fmt.Sprintf("failed to reload: %v", err)
+		return m, nil
+	}
+	m.connections = connections
+	m.menuCursor = min(m.menuCursor, len(m.menuRows())-1)
+	m.menuErr = ""
+	m.menuMsg = "Deleted " + name
+	return m, nil
+}
+
+// testConnection opens the connection and reports how long connecting took
+// and the server version.
+func testConnection(conn store.Connection, reqID uint64) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		start := time.Now()
+		db, err := OpenConnection(ctx, &conn)
+		if err != nil {
+			return connTestedMsg{name: conn.Name, err: err, reqID: reqID}
+		}
+		defer db.Close()
+		latency := time.Since(start)
+
+		version, err := database.ServerVersion(ctx, db)
+		if err != nil {
+			return connTestedMsg{name: conn.Name, err: err, reqID: reqID}
+		}
+
+		return connTestedMsg{name: conn.Name, latency: latency, version: version, reqID: reqID}
+	}
+}
+
 func (m model) viewMenu() string {
 	s := titleStyle.Render("dbtree") + "\n"
-	s += subtitleStyle.Render("Saved Connections") + "\n\n"
+	s += subtitleStyle.Render("Saved Connections") + "\n"
+
+	order := "name"
+	if m.menuSort == sortByLastUsed {
+		order = "last used"
+	}
+	if m.menuFiltering || m.menuFilter.Value() != "" {
+		s += "  " + m.menuFilter.View() + "\n\n"
+	} else if len(m.connections) > 0 {
+		s += dimStyle.Render(fmt.Sprintf("  %d connections, sorted by %s", len(m.connections), order)) + "\n\n"
+	} else {
+		s += "\n"
+	}
 
 	if len(m.connections) == 0 {
 		s += dimStyle.Render("  No saved connections yet.") + "\n\n"
 	}
 
-	for i, conn := range m.connections {
-		label := fmt.Sprintf("%s (%s)", conn.Name, conn.Driver)
-		if i == m.menuCursor {
-			s += selectedStyle.Render("> "+label) + "\n"
-		} else {
-			s += unselectedStyle.Render(label) + "\n"
-		}
+	rows := m.menuRows()
+
+	// Keep the cursor in view when the list is taller than the window: title
+	// (3 lines), subtitle, filter, blank line, message (2 lines) and help
+	start, end := 0, len(rows)
+	if height := m.height - 9; m.height > 0 && len(rows) > height {
+		height = max(height, 3)
+		start = max(0, min(m.menuCursor-height/2, len(rows)-height))
+		end = start + height
 	}
 
-	// "New connection" option
-	newLabel := "[+] Connect to new database"
-	if m.menuCursor == len(m.connections) {
-		s += selectedStyle.Render("> "+newLabel) + "\n"
-	} else {
-		s += unselectedStyle.Render(newLabel) + "\n"
+	for i := start; i < end; i++ {
+		s += m.viewMenuRow(rows[i], i == m.menuCursor) + "\n"
 	}
 
 	s += "\n"
+	if m.confirmDelete != "" {
+		s += errorStyle.Render(fmt.Sprintf("Delete %q? (y/n)", m.confirmDelete)) + "\n\n"
+		return s
+	}
+
 	if m.menuErr != "" {
 		s += errorStyle.Render(m.menuErr) + "\n\n"
+	} else if m.menuMsg != "" {
+		s += subtitleStyle.Render(m.menuMsg) + "\n\n"
 	}
 
-	s += helpStyle.Render("↑/↓: Navigate  Enter: Select  d: Delete  q: Quit")
+	helpText := "↑/↓: Navigate  Enter: Select  space: Collapse  /: Filter  o: Sort  e: Edit  t: Test  d: Delete  P: Change password  q: Quit"
+	if m.menuFiltering {
+		helpText = "type to filter, #tag for tags  ↑/↓: Navigate  enter: Done  esc: Clear"
+	}
+	s += helpStyle.Render(helpText)
 	return s
 }
+
+// viewMenuRow renders a line of the connection menu.
+func (m model) viewMenuRow(row menuRow, selected bool) string {
+	var label string
+	style := unselectedStyle
+	switch {
+	case row.header:
+		arrow := "▾"
+		if m.collapsed[row.group] && m.menuFilter.Value() == "" {
+			arrow = "▸"
+		}
+		name := row.group
+		if name == "" {
+			name = "no environment"
+		}
+		label = fmt.Sprintf("%s %s (%d)", arrow, name, row.count)
+		style = groupHeaderStyle
+		if (store.Connection{Environment: row.group}).IsProduction() {
+			style = prodGroupHeaderStyle
+		}
+	case row.conn >= 0:
+		conn := m.connections[row.conn]
+		label = fmt.Sprintf("%s (%s)", conn.Name, conn.Driver)
+		for _, tag := range conn.Tags {
+			label += " #" + tag
+		}
+		if row.indent {
+			label = "  " + label
+		}
+		if conn.IsProduction() {
+			style = prodConnStyle
+		}
+	default:
+		label = "[+] Connect to new database"
+	}
+
+	if selected {
+		return selectedStyle.Render("> " + label)
+	}
+	return style.Render(label)
+}
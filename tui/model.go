@@ -1,9 +1,16 @@
 package tui
 
 import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/table"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/viveknathani/dbtree/database"
+	"github.com/viveknathani/dbtree/graph"
 	"github.com/viveknathani/dbtree/render"
 	"github.com/viveknathani/dbtree/store"
 )
@@ -14,7 +21,10 @@ const (
 	statePassword state = iota
 	stateMenu
 	stateNewConn
+	stateChangePassword
 	stateSchema
+	stateTables
+	statePreview
 )
 
 // model is the main bubbletea model.
@@ -25,26 +35,87 @@ type model struct {
 	passwordInput textinput.Model
 	passwordErr   string
 	unlocking     bool
+	password      string // from Options, used to unlock without prompting
+	startConn     string // saved connection to open once unlocked
 
 	// menu view
-	connections []store.Connection
-	menuCursor  int
-	menuErr     string
+	connections   []store.Connection
+	menuCursor    int
+	menuErr       string
+	menuMsg       string
+	confirmDelete string // name of the connection awaiting delete confirmation
+	testReqID     uint64
+	menuFilter    textinput.Model
+	menuFiltering bool
+	menuSort      menuSort
+	collapsed     map[string]bool // environment groups that are collapsed
 
-	// new connection view
-	newConnInputs [2]textinput.Model // 0=name, 1=url
+	// new connection view, also used to edit a saved connection
+	newConnInputs [5]textinput.Model // 0=name, 1=url, 2=driver, 3=environment, 4=tags
 	newConnFocus  int
 	newConnErr    string
+	newConnMsg    string
+	editingConn   string // name of the connection being edited, empty for a new one
+
+	// change password view
+	passwdInputs     [3]textinput.Model // 0=current, 1=new, 2=confirmation
+	passwdFocus      int
+	passwdErr        string
+	changingPassword bool
 
 	// schema view
-	viewport      viewport.Model
-	currentConn   *store.Connection
-	format        render.Format
-	shape         render.Shape
-	schemaErr     string
-	loading       bool
-	schemaReqID   uint64
-	commandBuf    string
+	viewport    viewport.Model
+	currentConn *store.Connection
+	db          *sql.DB // pooled connection to currentConn, reused across refreshes
+	schema      *database.Database
+	graph       *graph.SchemaGraph
+	format      render.Format
+	shape       render.Shape
+	schemaErr   string
+	loading     bool
+	schemaReqID uint64
+	commandBuf  string
+	statusMsg   string // feedback from the last command
+	statusErr   bool
+	filter      []string
+	maxDepth    int
+	stats       bool
+	sortBy      render.SortOrder
+	dialect     render.Dialect
+	goOptions   render.GoOptions
+	charset     render.Charset
+	outputs     []string // files the export key saves to, from Options
+
+	// schema changes, found by auto-refresh or a manual refresh
+	autoRefresh   bool
+	autoRefreshID uint64 // discards ticks scheduled before auto-refresh was last toggled
+	fingerprint   string
+	changed       map[string]bool // changed tables, and columns as "table.column"
+	changedOutput string          // rendered schema with the changes highlighted
+
+	// schema search
+	schemaOutput     string // rendered schema without search highlighting
+	searchInput      textinput.Model
+	searching        bool
+	searchTablesOnly bool
+	searchMatches    []searchMatch
+	searchIndex      int
+
+	// table browser view
+	tableCursor int // position in the filtered table list
+	tableFilter textinput.Model
+	filtering   bool
+	detailFocus bool // relationships in the detail pane are selected with j/k
+	linkCursor  int
+
+	// row preview view
+	previewTable   *database.Table
+	preview        *database.RowPreview
+	previewGrid    table.Model
+	previewColumn  int // first visible column of the grid
+	previewErr     string
+	previewLoading bool
+	previewReqID   uint64
 
 	// shared
 	connStore *store.Store
@@ -62,28 +133,114 @@ type (
 	}
 
 	schemaLoadedMsg struct {
-		output string
-		err    error
-		reqID  uint64
+		db            *sql.DB
+		schema        *database.Database
+		graph         *graph.SchemaGraph
+		output        string
+		fingerprint   string
+		changed       map[string]bool
+		changedOutput string
+		changes       string // summary of the changes since the previous inspection
+		reloaded      bool   // the schema was inspected again for the same connection
+		err           error
+		reqID         uint64
+	}
+
+	autoRefreshTickMsg struct {
+		id uint64
+	}
+
+	fingerprintCheckedMsg struct {
+		fingerprint string
+		err         error
+		id          uint64
+	}
+
+	previewLoadedMsg struct {
+		preview *database.RowPreview
+		err     error
+		reqID   uint64
+	}
+
+	passwordChangedMsg struct {
+		err error
+	}
+
+	connTestedMsg struct {
+		name    string
+		latency time.Duration
+		version string
+		err     error
+		reqID   uint64
 	}
 )
 
-func newModel() model {
+func newModel(opts Options) model {
 	pi := textinput.New()
 	pi.Placeholder = "master password"
 	pi.EchoMode = textinput.EchoPassword
 	pi.EchoCharacter = '*'
 	pi.Focus()
 
-	return model{
+	tf := textinput.New()
+	tf.Prompt = "/"
+	tf.Placeholder = "filter tables"
+
+	si := textinput.New()
+	si.Prompt = "/"
+	si.Placeholder = "search"
+
+	mf := textinput.New()
+	mf.Prompt = "/"
+	mf.Placeholder = "filter connections, #tag"
+
+	m := model{
 		state:         statePassword,
 		passwordInput: pi,
+		tableFilter:   tf,
+		searchInput:   si,
+		menuFilter:    mf,
 		format:        render.FormatText,
 		shape:         render.ShapeTree,
+		password:      opts.Password,
+		unlocking:     opts.Password != "",
+		startConn:     opts.Connection,
+		filter:        opts.Filter,
+		maxDepth:      opts.MaxDepth,
+		stats:         opts.Stats,
+		sortBy:        opts.SortBy,
+		dialect:       opts.Dialect,
+		goOptions:     opts.Go,
+		charset:       opts.Charset,
+		outputs:       opts.Outputs,
 	}
+	if opts.Format != "" {
+		m.format = opts.Format
+	}
+	if opts.Shape != "" {
+		m.shape = opts.Shape
+	}
+	if opts.URL != nil {
+		conn := *opts.URL
+		m.currentConn = &conn
+		m.loading = true
+		m.state = stateSchema
+		m.schemaReqID++
+	}
+	return m
 }
 
 func (m model) Init() tea.Cmd {
+	if m.state == stateSchema {
+		load := loadSchema(m.currentConn, nil, nil, m.viewOptions(), m.schemaReqID)
+		if m.password != "" {
+			return tea.Batch(load, loadConnections(m.password))
+		}
+		return load
+	}
+	if m.password != "" {
+		return tea.Batch(textinput.Blink, loadConnections(m.password))
+	}
 	return textinput.Blink
 }
 
@@ -103,31 +260,131 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.viewport.Width = msg.Width
 			m.viewport.Height = msg.Height - 3 // room for status + help
 		}
+		if m.state == statePreview && m.preview != nil {
+			m.previewGrid = m.buildPreviewGrid()
+		}
 		return m, nil
 
 	case connectionsLoadedMsg:
 		m.unlocking = false
+		m.password = ""
 		if msg.err != nil {
 			m.passwordErr = msg.err.Error()
 			return m, nil
 		}
 		m.connStore = msg.connStore
 		m.connections = msg.connections
+		if m.state != statePassword {
+			// Unlocked in the background while a connection URL is open
+			return m, nil
+		}
 		m.state = stateMenu
+		if name := m.startConn; name != "" {
+			m.startConn = ""
+			for _, conn := range m.connections {
+				if conn.Name == name {
+					return m.openConnection(conn)
+				}
+			}
+			m.menuErr = fmt.Sprintf("saved connection %s not found", name)
+		}
 		return m, nil
 
 	case schemaLoadedMsg:
 		if msg.reqID != m.schemaReqID {
-			return m, nil // stale response, discard
+			// stale response, discard along with any connection it opened
+			if msg.db != nil && msg.db != m.db {
+				msg.db.Close()
+			}
+			return m, nil
 		}
 		m.loading = false
+		if msg.db != nil && msg.db != m.db {
+			// A newly opened connection counts as used, as with --conn @name
+			m = m.touchConnection()
+		}
+		if msg.db != nil {
+			m.db = msg.db
+		}
 		if msg.err != nil {
 			m.schemaErr = msg.err.Error()
 			return m, nil
 		}
 		m.schemaErr = ""
+		m.schema = msg.schema
+		m.graph = msg.graph
+		if msg.fingerprint != "" {
+			m.fingerprint = msg.fingerprint
+		}
+		m.changed = msg.changed
+		m.changedOutput = msg.changedOutput
+		if msg.changes != "" {
+			m.statusMsg, m.statusErr = "schema changed: "+msg.changes, false
+		}
+		offset := m.viewport.YOffset
 		m.viewport = viewport.New(m.width, m.height-3)
-		m.viewport.SetContent(msg.output)
+		m.schemaOutput = msg.output
+		m = m.runSearch(false)
+		if msg.reloaded {
+			m.viewport.SetYOffset(offset)
+		}
+		return m, nil
+
+	case autoRefreshTickMsg:
+		return m.checkFingerprint(msg)
+
+	case fingerprintCheckedMsg:
+		return m.fingerprintChecked(msg)
+
+	case passwordChangedMsg:
+		m.changingPassword = false
+		if msg.err != nil {
+			m.passwdErr = msg.err.Error()
+			return m, nil
+		}
+		m.menuErr = ""
+		m.menuMsg = "Master password changed"
+		m.state = stateMenu
+		return m, nil
+
+	case connTestedMsg:
+		if msg.reqID != m.testReqID {
+			return m, nil // stale response, discard
+		}
+		result := fmt.Sprintf("%s: connected in %s (%s)", msg.name, msg.latency.Round(10*time.Microsecond), msg.version)
+		if msg.err != nil {
+			result = fmt.Sprintf("%s: %v", msg.name, msg.err)
+		}
+		if m.state == stateNewConn {
+			m.newConnMsg, m.newConnErr = "", ""
+			if msg.err != nil {
+				m.newConnErr = result
+			} else {
+				m.newConnMsg = result
+			}
+		} else {
+			m.menuMsg, m.menuErr = "", ""
+			if msg.err != nil {
+				m.menuErr = result
+			} else {
+				m.menuMsg = result
+			}
+		}
+		return m, nil
+
+	case previewLoadedMsg:
+		if msg.reqID != m.previewReqID {
+			return m, nil // stale response, discard
+		}
+		m.previewLoading = false
+		if msg.err != nil {
+			m.previewErr = msg.err.Error()
+			return m, nil
+		}
+		m.previewErr = ""
+		m.preview = msg.preview
+		m.previewColumn = 0
+		m.previewGrid = m.buildPreviewGrid()
 		return m, nil
 	}
 
@@ -138,8 +395,14 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.updateMenu(msg)
 	case stateNewConn:
 		return m.updateNewConn(msg)
+	case stateChangePassword:
+		return m.updateChangePassword(msg)
 	case stateSchema:
 		return m.updateSchema(msg)
+	case stateTables:
+		return m.updateTables(msg)
+	case statePreview:
+		return m.updatePreview(msg)
 	}
 
 	return m, nil
@@ -157,10 +420,35 @@ func (m model) View() string {
 		return m.viewMenu()
 	case stateNewConn:
 		return m.viewNewConn()
+	case stateChangePassword:
+		return m.viewChangePassword()
 	case stateSchema:
 		return m.viewSchema()
+	case stateTables:
+		return m.viewTables()
+	case statePreview:
+		return m.viewPreview()
 	}
 
 	return ""
 }
 
+// closeDB closes the pooled connection of the current database, if any.
+// Schemas and previews still loading are discarded when they arrive, along
+// with any connection they opened.
+func (m *model) closeDB() {
+	if m.db != nil {
+		m.db.Close()
+		m.db = nil
+	}
+	m.schemaReqID++
+	m.previewReqID++
+	m.loading = false
+	m.previewLoading = false
+	m.schema = nil
+	m.graph = nil
+	m.autoRefresh = false
+	m.fingerprint = ""
+	m.changed = nil
+	m.changedOutput = ""
+}
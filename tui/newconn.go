@@ -2,8 +2,10 @@ package tui
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/viveknathani/dbtree/store"
 )
@@ -13,6 +15,7 @@ func (m model) updateNewConn(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.Type {
 		case tea.KeyEsc:
+			m.testReqID++ // discard a pending connection test
 			m.state = stateMenu
 			return m, nil
 		case tea.KeyTab:
@@ -35,28 +38,29 @@ func (m model) updateNewConn(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 			return m, nil
-		case tea.KeyEnter:
-			name := strings.TrimSpace(m.newConnInputs[0].Value())
-			url := strings.TrimSpace(m.newConnInputs[1].Value())
-
-			if name == "" || url == "" {
-				m.newConnErr = "both name and URL are required"
+		case tea.KeyCtrlT:
+			conn, err := m.newConnInput()
+			if err != nil {
+				m.newConnErr = err.Error()
 				return m, nil
 			}
-
-			driver := detectDriver(url)
-			if driver == "" {
-				m.newConnErr = "unsupported URL format (use postgres://, mysql://, clickhouse://, sqlite://, or .db/.sqlite/.sqlite3)"
+			m.newConnErr = ""
+			m.newConnMsg = "Testing " + conn.Name + "..."
+			m.testReqID++
+			return m, testConnection(conn, m.testReqID)
+		case tea.KeyEnter:
+			conn, err := m.newConnInput()
+			if err != nil {
+				m.newConnErr = err.Error()
 				return m, nil
 			}
 
-			conn := store.Connection{
-				Name:   name,
-				URL:    url,
-				Driver: driver,
+			if m.editingConn != "" {
+				err = m.connStore.Update(m.editingConn, conn)
+			} else {
+				err = m.connStore.Add(conn)
 			}
-
-			if err := m.connStore.Add(conn); err != nil {
+			if err != nil {
 				m.newConnErr = fmt.Sprintf("failed to save: %v", err)
 				return m, nil
 			}
@@ -68,7 +72,10 @@ func (m model) updateNewConn(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 			m.connections = connections
-			m.menuCursor = len(m.connections) - 1
+			m.menuFilter.SetValue("")
+			delete(m.collapsed, conn.Environment)
+			m = m.selectConnection(conn.Name)
+			m.menuErr, m.menuMsg = "", ""
 			m.state = stateMenu
 			return m, nil
 		}
@@ -79,9 +86,64 @@ func (m model) updateNewConn(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// newConnInput validates the form and returns the connection it describes.
+func (m model) newConnInput() (store.Connection, error) {
+	conn, err := NewConnection(m.newConnInputs[0].Value(), m.newConnInputs[1].Value(), m.newConnInputs[2].Value())
+	if err != nil {
+		return store.Connection{}, err
+	}
+
+	conn.Environment = strings.ToLower(strings.TrimSpace(m.newConnInputs[3].Value()))
+	conn.Tags = store.NormalizeTags(strings.Split(m.newConnInputs[4].Value(), ","))
+	return conn, nil
+}
+
+// NewConnection validates a saved connection. The driver is detected from the
+// URL when it is empty.
+func NewConnection(name, url, driver string) (store.Connection, error) {
+	name = strings.TrimSpace(name)
+	url = strings.TrimSpace(url)
+	driver = strings.TrimSpace(driver)
+
+	if name == "" || url == "" {
+		return store.Connection{}, fmt.Errorf("both name and URL are required")
+	}
+
+	if driver == "" {
+		driver = detectDriver(url)
+		if driver == "" {
+			return store.Connection{}, fmt.Errorf("unsupported URL format (use postgres://, mysql://, clickhouse://, sqlite://, or .db/.sqlite/.sqlite3)")
+		}
+	} else if !slices.Contains(supportedDrivers, driver) {
+		return store.Connection{}, fmt.Errorf("unsupported driver %q (use %s)", driver, strings.Join(supportedDrivers, ", "))
+	}
+
+	return store.Connection{
+		Name:   name,
+		URL:    url,
+		Driver: driver,
+	}, nil
+}
+
+// editConnInputs returns the form inputs filled in with a saved connection.
+func editConnInputs(conn store.Connection) [5]textinput.Model {
+	inputs := initNewConnInputs()
+	inputs[0].SetValue(conn.Name)
+	inputs[1].SetValue(conn.URL)
+	inputs[2].SetValue(conn.Driver)
+	inputs[3].SetValue(conn.Environment)
+	inputs[4].SetValue(strings.Join(conn.Tags, ", "))
+	return inputs
+}
+
 func (m model) viewNewConn() string {
+	title := "New Connection"
+	if m.editingConn != "" {
+		title = "Edit Connection: " + m.editingConn
+	}
+
 	s := titleStyle.Render("dbtree") + "\n"
-	s += subtitleStyle.Render("New Connection") + "\n\n"
+	s += subtitleStyle.Render(title) + "\n\n"
 
 	s += inputLabelStyle.Render("Name:") + "\n"
 	s += "  " + m.newConnInputs[0].View() + "\n\n"
@@ -89,14 +151,28 @@ func (m model) viewNewConn() string {
 	s += inputLabelStyle.Render("Connection URL:") + "\n"
 	s += "  " + m.newConnInputs[1].View() + "\n\n"
 
+	s += inputLabelStyle.Render("Driver ("+strings.Join(supportedDrivers, ", ")+"):") + "\n"
+	s += "  " + m.newConnInputs[2].View() + "\n\n"
+
+	s += inputLabelStyle.Render("Environment:") + "\n"
+	s += "  " + m.newConnInputs[3].View() + "\n\n"
+
+	s += inputLabelStyle.Render("Tags:") + "\n"
+	s += "  " + m.newConnInputs[4].View() + "\n\n"
+
 	if m.newConnErr != "" {
 		s += errorStyle.Render(m.newConnErr) + "\n\n"
+	} else if m.newConnMsg != "" {
+		s += subtitleStyle.Render(m.newConnMsg) + "\n\n"
 	}
 
-	s += helpStyle.Render("Tab: Next field  Enter: Save  Esc: Cancel")
+	s += helpStyle.Render("Tab: Next field  Ctrl+T: Test  Enter: Save  Esc: Cancel")
 	return s
 }
 
+// supportedDrivers lists the database/sql driver names a connection can use.
+var supportedDrivers = []string{"postgres", "mysql", "clickhouse", "sqlite3"}
+
 func detectDriver(url string) string {
 	switch {
 	case strings.HasPrefix(url, "postgres://"), strings.HasPrefix(url, "postgresql://"):
@@ -0,0 +1,105 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/viveknathani/dbtree/store"
+)
+
+func (m model) updateChangePassword(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.changingPassword {
+			return m, nil
+		}
+
+		switch msg.Type {
+		case tea.KeyEsc:
+			m.state = stateMenu
+			return m, nil
+		case tea.KeyTab, tea.KeyShiftTab:
+			step := 1
+			if msg.Type == tea.KeyShiftTab {
+				step = len(m.passwdInputs) - 1
+			}
+			m.passwdFocus = (m.passwdFocus + step) % len(m.passwdInputs)
+			for i := range m.passwdInputs {
+				if i == m.passwdFocus {
+					m.passwdInputs[i].Focus()
+				} else {
+					m.passwdInputs[i].Blur()
+				}
+			}
+			return m, nil
+		case tea.KeyEnter:
+			oldPassword := m.passwdInputs[0].Value()
+			newPassword := m.passwdInputs[1].Value()
+
+			if newPassword == "" {
+				m.passwdErr = "new password cannot be empty"
+				return m, nil
+			}
+			if newPassword != m.passwdInputs[2].Value() {
+				m.passwdErr = "new passwords do not match"
+				return m, nil
+			}
+
+			m.passwdErr = ""
+			m.changingPassword = true
+			return m, changePassword(m.connStore, oldPassword, newPassword)
+		}
+	}
+
+	var cmd tea.Cmd
+	m.passwdInputs[m.passwdFocus], cmd = m.passwdInputs[m.passwdFocus].Update(msg)
+	return m, cmd
+}
+
+func (m model) viewChangePassword() string {
+	s := titleStyle.Render("dbtree") + "\n"
+	s += subtitleStyle.Render("Change Master Password") + "\n\n"
+
+	if m.changingPassword {
+		s += subtitleStyle.Render("Re-encrypting connections...") + "\n"
+		return s
+	}
+
+	labels := []string{"Current password:", "New password:", "Confirm new password:"}
+	for i, label := range labels {
+		s += inputLabelStyle.Render(label) + "\n"
+		s += "  " + m.passwdInputs[i].View() + "\n\n"
+	}
+
+	if m.passwdErr != "" {
+		s += errorStyle.Render(m.passwdErr) + "\n\n"
+	}
+
+	s += helpStyle.Render("Tab: Next field  Enter: Change  Esc: Cancel")
+	return s
+}
+
+func initPasswdInputs() [3]textinput.Model {
+	var inputs [3]textinput.Model
+	for i := range inputs {
+		inputs[i] = textinput.New()
+		inputs[i].EchoMode = textinput.EchoPassword
+		inputs[i].EchoCharacter = '*'
+	}
+	inputs[0].Focus()
+	return inputs
+}
+
+func changePassword(s *store.Store, oldPassword, newPassword string) tea.Cmd {
+	return func() tea.Msg {
+		if err := s.ChangePassword(oldPassword, newPassword); err != nil {
+			if errors.Is(err, store.ErrWrongPassword) {
+				return passwordChangedMsg{err: fmt.Errorf("current password is incorrect")}
+			}
+			return passwordChangedMsg{err: err}
+		}
+		return passwordChangedMsg{}
+	}
+}
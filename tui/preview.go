@@ -0,0 +1,182 @@
+package tui
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/viveknathani/dbtree/database"
+)
+
+const (
+	// previewRowLimit is the number of rows fetched for a table preview
+	previewRowLimit = 50
+	// previewCellWidth bounds the width of a single grid column
+	previewCellWidth = 24
+	// previewColumnsWidth is the width of the column list next to the grid
+	previewColumnsWidth = 32
+)
+
+func (m model) updatePreview(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc", "b":
+			m.state = stateTables
+			return m, nil
+		case "q":
+			m.quitting = true
+			return m, tea.Quit
+		case "r":
+			m.previewLoading = true
+			m.previewReqID++
+			return m, loadPreview(m.db, m.previewTable, m.previewReqID)
+		case "left", "h":
+			if m.preview != nil && m.previewColumn > 0 {
+				m.previewColumn--
+				m.previewGrid = m.buildPreviewGrid()
+			}
+			return m, nil
+		case "right", "l":
+			if m.preview != nil && m.previewColumn < len(m.preview.Columns)-1 {
+				m.previewColumn++
+				m.previewGrid = m.buildPreviewGrid()
+			}
+			return m, nil
+		}
+	}
+
+	if m.previewLoading || m.preview == nil {
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.previewGrid, cmd = m.previewGrid.Update(msg)
+	return m, cmd
+}
+
+func (m model) viewPreview() string {
+	s := titleStyle.Render(m.connName()+" › "+m.previewTable.Name) + "\n"
+
+	if m.previewLoading {
+		return s + subtitleStyle.Render("Loading rows...") + "\n"
+	}
+
+	if m.previewErr != "" {
+		s += errorStyle.Render("Error: "+m.previewErr) + "\n\n"
+		s += helpStyle.Render("r: Retry  b: Back  q: Quit")
+		return s
+	}
+
+	var columns strings.Builder
+	columns.WriteString(subtitleStyle.Render("Columns") + "\n")
+	for _, col := range m.previewTable.Columns {
+		line := fmt.Sprintf("%s (%s)", col.Name, col.Type)
+		if !col.IsNullable {
+			line += " NOT NULL"
+		}
+		columns.WriteString(unselectedStyle.Render(truncateCell(line, previewColumnsWidth)) + "\n")
+	}
+
+	var grid string
+	if len(m.preview.Rows) == 0 {
+		grid = dimStyle.Render("No rows.")
+	} else {
+		grid = m.previewGrid.View()
+	}
+
+	body := lipgloss.JoinHorizontal(lipgloss.Top,
+		lipgloss.NewStyle().Width(previewColumnsWidth+6).Render(columns.String()),
+		grid,
+	)
+
+	statusBar := statusBarStyle.Render(
+		fmt.Sprintf(" Rows: %d (first %d)  Columns: %d-%d of %d",
+			len(m.preview.Rows), previewRowLimit,
+			m.previewColumn+1, m.previewColumn+len(m.previewGrid.Columns()), len(m.preview.Columns)),
+	)
+	help := helpStyle.Render("↑/↓: Scroll  ←/→: Columns  r: Refresh  b: Back  q: Quit")
+
+	return s + body + "\n" + statusBar + "\n" + help
+}
+
+// buildPreviewGrid lays out the preview rows starting at previewColumn, with as
+// many columns as fit next to the column list. The table is rebuilt rather than
+// updated because its rows must match the visible columns.
+func (m model) buildPreviewGrid() table.Model {
+	available := max(m.width-previewColumnsWidth-8, previewCellWidth)
+
+	var columns []table.Column
+	used := 0
+	for i := m.previewColumn; i < len(m.preview.Columns); i++ {
+		width := len(m.preview.Columns[i])
+		for _, row := range m.preview.Rows {
+			width = max(width, len([]rune(row[i])))
+		}
+		width = min(max(width, 4), previewCellWidth)
+
+		// Each cell is padded by one space on both sides
+		if len(columns) > 0 && used+width+2 > available {
+			break
+		}
+		columns = append(columns, table.Column{Title: m.preview.Columns[i], Width: width})
+		used += width + 2
+	}
+
+	rows := make([]table.Row, len(m.preview.Rows))
+	for i, row := range m.preview.Rows {
+		cells := make(table.Row, len(columns))
+		for j := range columns {
+			cells[j] = flattenCell(row[m.previewColumn+j])
+		}
+		rows[i] = cells
+	}
+
+	return table.New(
+		table.WithColumns(columns),
+		table.WithRows(rows),
+		table.WithHeight(min(len(rows)+1, max(m.height-8, 3))),
+		table.WithFocused(true),
+	)
+}
+
+// flattenCell keeps multi-line values on a single grid row
+func flattenCell(s string) string {
+	return strings.NewReplacer("\r\n", " ", "\n", " ", "\t", " ").Replace(s)
+}
+
+// truncateCell shortens a line to width characters
+func truncateCell(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	if width < 1 {
+		return ""
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+// loadPreview fetches the first rows of a table over the pooled connection.
+func loadPreview(db *sql.DB, t *database.Table, reqID uint64) tea.Cmd {
+	return func() tea.Msg {
+		if db == nil {
+			return previewLoadedMsg{err: fmt.Errorf("not connected"), reqID: reqID}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		preview, err := database.PreviewRows(ctx, db, t, previewRowLimit)
+		if err != nil {
+			return previewLoadedMsg{err: err, reqID: reqID}
+		}
+
+		return previewLoadedMsg{preview: preview, reqID: reqID}
+	}
+}
@@ -28,37 +28,94 @@ func (m model) updateSchema(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.handleCommandBuf(msg)
 		}
 
+		if m.searching {
+			return m.updateSearchInput(msg)
+		}
+
 		switch msg.String() {
 		case "q":
 			m.quitting = true
 			return m, tea.Quit
 		case ":":
 			m.commandBuf = ":"
+			m.statusMsg = ""
+			return m, nil
+		case "/":
+			if m.loading || m.schemaErr != "" {
+				return m, nil
+			}
+			m.searching = true
+			m.searchInput.SetValue("")
+			m.searchInput.Focus()
+			m = m.runSearch(false)
+			return m, nil
+		case "n":
+			m = m.nextMatch(1)
+			return m, nil
+		case "N":
+			m = m.nextMatch(-1)
+			return m, nil
+		case "esc":
+			if m.searchInput.Value() != "" {
+				m.searchInput.SetValue("")
+				m = m.runSearch(false)
+			}
 			return m, nil
 		case "b":
+			m.closeDB()
+			m.searchInput.SetValue("")
+			m.searchMatches = nil
 			m.state = stateMenu
+			if m.connStore == nil {
+				// Opened from a URL, so the store is still locked
+				m.state = statePassword
+			}
 			m.schemaErr = ""
 			return m, nil
+		case "t":
+			if m.loading || m.schema == nil || len(m.schema.Tables) == 0 {
+				return m, nil
+			}
+			if m.tableCursor >= len(m.visibleTables()) {
+				m.tableCursor = 0
+			}
+			m.detailFocus = false
+			m.state = stateTables
+			return m, nil
 		case "f":
 			m.format = cycleFormat(m.format)
 			if m.shape == render.ShapeChart && m.format == render.FormatJSON {
 				m.format = render.FormatText
 			}
-			m.loading = true
-			m.schemaReqID++
-			return m, loadSchema(m.currentConn, m.format, m.shape, m.schemaReqID)
+			return m.refreshView()
 		case "s":
 			m.shape = cycleShape(m.shape)
 			if m.shape == render.ShapeChart && m.format == render.FormatJSON {
 				m.shape = cycleShape(m.shape)
 			}
-			m.loading = true
-			m.schemaReqID++
-			return m, loadSchema(m.currentConn, m.format, m.shape, m.schemaReqID)
+			return m.refreshView()
 		case "r":
 			m.loading = true
 			m.schemaReqID++
-			return m, loadSchema(m.currentConn, m.format, m.shape, m.schemaReqID)
+			return m, loadSchema(m.currentConn, m.db, m.schema, m.viewOptions(), m.schemaReqID)
+		case "e":
+			if m.loading {
+				return m, nil
+			}
+			if len(m.outputs) > 0 {
+				return m.exportOutputs()
+			}
+			return m.exportView(m.exportPath(), m.format)
+		case "a":
+			if m.db == nil {
+				return m, nil
+			}
+			m.autoRefresh = !m.autoRefresh
+			m.autoRefreshID++
+			if !m.autoRefresh {
+				return m, nil
+			}
+			return m, autoRefreshTick(m.autoRefreshID)
 		}
 	}
 
@@ -71,34 +128,6 @@ func (m model) updateSchema(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
-func (m model) handleCommandBuf(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch msg.Type {
-	case tea.KeyEnter:
-		cmd := strings.TrimSpace(m.commandBuf)
-		m.commandBuf = ""
-		if cmd == ":q" || cmd == ":quit" {
-			m.quitting = true
-			return m, tea.Quit
-		}
-		return m, nil
-	case tea.KeyEsc:
-		m.commandBuf = ""
-		return m, nil
-	case tea.KeyBackspace:
-		if len(m.commandBuf) > 1 {
-			m.commandBuf = m.commandBuf[:len(m.commandBuf)-1]
-		} else {
-			m.commandBuf = ""
-		}
-		return m, nil
-	default:
-		if msg.Type == tea.KeyRunes {
-			m.commandBuf += msg.String()
-		}
-		return m, nil
-	}
-}
-
 func (m model) connName() string {
 	if m.currentConn != nil {
 		return m.currentConn.Name
@@ -106,6 +135,18 @@ func (m model) connName() string {
 	return "dbtree"
 }
 
+// envBadge labels the status bar with the environment of the current
+// connection, in red for production.
+func (m model) envBadge() string {
+	if m.currentConn == nil || m.currentConn.Environment == "" {
+		return ""
+	}
+	if m.currentConn.IsProduction() {
+		return prodBadgeStyle.Render(strings.ToUpper(m.currentConn.Environment))
+	}
+	return envBadgeStyle.Render(strings.ToUpper(m.currentConn.Environment))
+}
+
 func (m model) viewSchema() string {
 	if m.loading {
 		s := titleStyle.Render(m.connName()) + "\n"
@@ -122,62 +163,287 @@ func (m model) viewSchema() string {
 
 	header := titleStyle.Render(m.connName())
 	content := m.viewport.View()
-	statusBar := statusBarStyle.Render(
-		fmt.Sprintf(" Format: %s  Shape: %s  %d%%",
-			m.format, m.shape, int(m.viewport.ScrollPercent()*100)),
+	barStyle := statusBarStyle
+	if m.currentConn != nil && m.currentConn.IsProduction() {
+		barStyle = prodStatusBarStyle
+	}
+	statusBar := m.envBadge() + barStyle.Render(
+		fmt.Sprintf(" Format: %s  Shape: %s%s  %d%%%s",
+			m.format, m.shape, m.viewStatus(), int(m.viewport.ScrollPercent()*100), m.searchStatus()),
 	)
+	if m.statusMsg != "" {
+		style := dimStyle.PaddingLeft(1)
+		if m.statusErr {
+			style = errorStyle.PaddingLeft(1)
+		}
+		statusBar += style.Render(m.statusMsg)
+	}
 
-	helpText := "↑/↓: Scroll  f: Format  s: Shape  r: Refresh  b: Back  q: Quit"
+	helpText := "↑/↓: Scroll  /: Search  n/N: Next/Prev  t: Tables  :: Command  f: Format  s: Shape  r: Refresh  a: Auto-refresh  e: Export  b: Back  q: Quit"
 	if m.commandBuf != "" {
 		helpText = m.commandBuf
 	}
 	help := helpStyle.Render(helpText)
+	if m.searching {
+		scope := "all"
+		if m.searchTablesOnly {
+			scope = "tables only"
+		}
+		help = "  " + m.searchInput.View() + "  " + dimStyle.Render("(tab: "+scope+")")
+	}
 
 	return header + "\n" + content + "\n" + statusBar + "\n" + help
 }
 
-func loadSchema(conn *store.Connection, format render.Format, shape render.Shape, reqID uint64) tea.Cmd {
-	return func() tea.Msg {
-		connURL := conn.URL
-
-		// Format driver-specific connection strings
-		switch conn.Driver {
-		case "mysql":
-			connURL = formatMySQLDSN(connURL)
-		case "sqlite3":
-			connURL = strings.TrimPrefix(connURL, "sqlite://")
-		}
+// viewOptions holds the settings the schema view is rendered with.
+type viewOptions struct {
+	format    render.Format
+	shape     render.Shape
+	filter    []string // table name globs, those prefixed with "!" exclude tables
+	maxDepth  int
+	stats     bool
+	sortBy    render.SortOrder
+	dialect   render.Dialect
+	goOptions render.GoOptions
+	charset   render.Charset
+}
 
-		db, err := sql.Open(conn.Driver, connURL)
-		if err != nil {
-			return schemaLoadedMsg{err: fmt.Errorf("failed to open database: %w", err), reqID: reqID}
-		}
-		defer db.Close()
+func (m model) viewOptions() viewOptions {
+	return viewOptions{
+		format:    m.format,
+		shape:     m.shape,
+		filter:    m.filter,
+		maxDepth:  m.maxDepth,
+		stats:     m.stats,
+		sortBy:    m.sortBy,
+		dialect:   m.dialect,
+		goOptions: m.goOptions,
+		charset:   m.charset,
+	}
+}
+
+// viewStatus describes the filter and depth limit for the status bar.
+func (m model) viewStatus() string {
+	var s string
+	if len(m.filter) > 0 {
+		s += "  Filter: " + strings.Join(m.filter, " ")
+	}
+	if m.maxDepth > 0 {
+		s += fmt.Sprintf("  Depth: %d", m.maxDepth)
+	}
+	if m.autoRefresh {
+		s += "  Auto-refresh: " + autoRefreshInterval.String()
+	}
+	return s
+}
 
+// refreshView renders the loaded schema again with the current view options,
+// only inspecting the database when no schema has been loaded yet.
+func (m model) refreshView() (tea.Model, tea.Cmd) {
+	m.loading = true
+	m.schemaReqID++
+	if m.schema == nil {
+		return m, loadSchema(m.currentConn, m.db, nil, m.viewOptions(), m.schemaReqID)
+	}
+	return m, rerenderSchema(m.schema, m.graph, m.viewOptions(), m.changed, m.schemaReqID)
+}
+
+// loadSchema inspects and renders the schema of conn. The pooled connection db
+// is reused when set, otherwise a new one is opened and handed back in the
+// message so that later refreshes and previews can share it. When previous is
+// set, the tables and columns that changed since it are highlighted.
+func loadSchema(conn *store.Connection, db *sql.DB, previous *database.Database, opts viewOptions, reqID uint64) tea.Cmd {
+	return func() tea.Msg {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
-		if err := db.PingContext(ctx); err != nil {
-			return schemaLoadedMsg{err: fmt.Errorf("failed to connect: %w", err), reqID: reqID}
+		if db == nil {
+			var err error
+			db, err = OpenConnection(ctx, conn)
+			if err != nil {
+				return schemaLoadedMsg{err: err, reqID: reqID}
+			}
 		}
 
+		// Taken before inspecting, so that a change made in between is
+		// picked up by the next auto-refresh
+		fingerprint, _ := database.SchemaFingerprint(ctx, db)
+
 		schema, err := database.InspectSchema(ctx, db)
 		if err != nil {
-			return schemaLoadedMsg{err: fmt.Errorf("failed to inspect schema: %w", err), reqID: reqID}
+			return schemaLoadedMsg{db: db, err: fmt.Errorf("failed to inspect schema: %w", err), reqID: reqID}
+		}
+		if opts.stats {
+			if err := database.InspectStats(ctx, db, schema); err != nil {
+				return schemaLoadedMsg{db: db, err: fmt.Errorf("failed to inspect stats: %w", err), reqID: reqID}
+			}
 		}
 
 		g, err := graph.Build(schema)
 		if err != nil {
-			return schemaLoadedMsg{err: fmt.Errorf("failed to build graph: %w", err), reqID: reqID}
+			return schemaLoadedMsg{db: db, err: fmt.Errorf("failed to build graph: %w", err), reqID: reqID}
 		}
 
-		output, err := render.Render(g, format, shape)
+		output, err := renderView(g, opts, opts.format)
 		if err != nil {
-			return schemaLoadedMsg{err: fmt.Errorf("failed to render: %w", err), reqID: reqID}
+			return schemaLoadedMsg{db: db, err: err, reqID: reqID}
 		}
 
-		return schemaLoadedMsg{output: output, reqID: reqID}
+		msg := schemaLoadedMsg{db: db, schema: schema, graph: g, output: output, fingerprint: fingerprint, reqID: reqID}
+		if previous != nil {
+			changes := database.DiffSchemas(previous, schema)
+			msg.reloaded = true
+			msg.changes = changes.String()
+			msg.changed = changes.Changed()
+			msg.changedOutput, err = renderChanges(g, opts, msg.changed)
+			if err != nil {
+				return schemaLoadedMsg{db: db, err: err, reqID: reqID}
+			}
+		}
+
+		return msg
+	}
+}
+
+// rerenderSchema renders an already inspected schema with new view options,
+// keeping the changes found by the last inspection highlighted.
+func rerenderSchema(schema *database.Database, g *graph.SchemaGraph, opts viewOptions, changed map[string]bool, reqID uint64) tea.Cmd {
+	return func() tea.Msg {
+		output, err := renderView(g, opts, opts.format)
+		if err != nil {
+			return schemaLoadedMsg{err: err, reqID: reqID}
+		}
+
+		changedOutput, err := renderChanges(g, opts, changed)
+		if err != nil {
+			return schemaLoadedMsg{err: err, reqID: reqID}
+		}
+
+		return schemaLoadedMsg{schema: schema, graph: g, output: output, changed: changed, changedOutput: changedOutput, reqID: reqID}
+	}
+}
+
+// autoRefreshInterval is how often auto-refresh checks the schema fingerprint.
+const autoRefreshInterval = 2 * time.Second
+
+// autoRefreshTick schedules the next auto-refresh check.
+func autoRefreshTick(id uint64) tea.Cmd {
+	return tea.Tick(autoRefreshInterval, func(time.Time) tea.Msg {
+		return autoRefreshTickMsg{id: id}
+	})
+}
+
+// checkFingerprint computes the schema fingerprint on an auto-refresh tick,
+// skipping the check while a schema is being loaded.
+func (m model) checkFingerprint(msg autoRefreshTickMsg) (tea.Model, tea.Cmd) {
+	if !m.autoRefresh || msg.id != m.autoRefreshID {
+		return m, nil
+	}
+	if m.loading || m.db == nil {
+		return m, autoRefreshTick(m.autoRefreshID)
+	}
+
+	db, id := m.db, m.autoRefreshID
+	return m, func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		fingerprint, err := database.SchemaFingerprint(ctx, db)
+		return fingerprintCheckedMsg{fingerprint: fingerprint, err: err, id: id}
+	}
+}
+
+// fingerprintChecked reloads the schema when its fingerprint changed and
+// schedules the next check.
+func (m model) fingerprintChecked(msg fingerprintCheckedMsg) (tea.Model, tea.Cmd) {
+	if !m.autoRefresh || msg.id != m.autoRefreshID {
+		return m, nil
+	}
+	next := autoRefreshTick(m.autoRefreshID)
+
+	if msg.err != nil {
+		m.statusMsg, m.statusErr = fmt.Sprintf("auto-refresh: %v", msg.err), true
+		return m, next
 	}
+	if msg.fingerprint == m.fingerprint || m.loading || m.schema == nil {
+		return m, next
+	}
+
+	// The current view stays visible while the schema is reloaded
+	m.schemaReqID++
+	return m, tea.Batch(loadSchema(m.currentConn, m.db, m.schema, m.viewOptions(), m.schemaReqID), next)
+}
+
+// renderView applies the table filter and depth limit and renders the schema
+// graph in the given format.
+func renderView(g *graph.SchemaGraph, opts viewOptions, format render.Format) (string, error) {
+	return renderFiltered(g, opts, format, nil)
+}
+
+// renderFiltered renders like renderView, highlighting the tables and
+// columns in changed.
+func renderFiltered(g *graph.SchemaGraph, opts viewOptions, format render.Format, changed map[string]bool) (string, error) {
+	var include, exclude []string
+	for _, pattern := range opts.filter {
+		if strings.HasPrefix(pattern, "!") {
+			exclude = append(exclude, strings.TrimPrefix(pattern, "!"))
+		} else {
+			include = append(include, pattern)
+		}
+	}
+
+	filtered, err := graph.Filter(g, include, exclude)
+	if err != nil {
+		return "", fmt.Errorf("failed to filter tables: %w", err)
+	}
+
+	renderOpts := render.Options{MaxDepth: opts.maxDepth, SortBy: opts.sortBy, Dialect: opts.dialect, Go: opts.goOptions, Charset: opts.charset}
+	if len(changed) > 0 {
+		renderOpts.Changed = changed
+		renderOpts.Highlight = func(s string) string { return changedStyle.Render(s) }
+	}
+
+	output, err := render.RenderWithOptions(filtered, format, opts.shape, renderOpts)
+	if err != nil {
+		return "", fmt.Errorf("failed to render: %w", err)
+	}
+
+	return output, nil
+}
+
+// renderChanges renders the schema like renderView, with the tables and
+// columns in changed highlighted. It returns an empty string when nothing is
+// highlighted, which is the case for JSON and chart output.
+func renderChanges(g *graph.SchemaGraph, opts viewOptions, changed map[string]bool) (string, error) {
+	if len(changed) == 0 || opts.format != render.FormatText || opts.shape == render.ShapeChart {
+		return "", nil
+	}
+	return renderFiltered(g, opts, opts.format, changed)
+}
+
+// OpenConnection opens and pings a database for a saved connection.
+func OpenConnection(ctx context.Context, conn *store.Connection) (*sql.DB, error) {
+	connURL := conn.URL
+
+	// Format driver-specific connection strings
+	switch conn.Driver {
+	case "mysql":
+		connURL = formatMySQLDSN(connURL)
+	case "sqlite3":
+		connURL = strings.TrimPrefix(connURL, "sqlite://")
+	}
+
+	db, err := sql.Open(conn.Driver, connURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+
+	return db, nil
 }
 
 // formatMySQLDSN converts a mysql:// URL into the go-sql-driver/mysql DSN format
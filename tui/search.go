@@ -0,0 +1,195 @@
+package tui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// searchMatch is the position of a search match in the rendered schema.
+type searchMatch struct {
+	line       int
+	start, end int // byte offsets within the line
+}
+
+// updateSearchInput edits the search query, searching as the user types.
+func (m model) updateSearchInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.searching = false
+		m.searchInput.Blur()
+		m.searchInput.SetValue("")
+		m = m.runSearch(false)
+		return m, nil
+	case tea.KeyEnter:
+		m.searching = false
+		m.searchInput.Blur()
+		return m, nil
+	case tea.KeyTab:
+		m.searchTablesOnly = !m.searchTablesOnly
+		m = m.runSearch(true)
+		return m, nil
+	}
+
+	previous := m.searchInput.Value()
+	var cmd tea.Cmd
+	m.searchInput, cmd = m.searchInput.Update(msg)
+	if m.searchInput.Value() != previous {
+		m = m.runSearch(true)
+	}
+	return m, cmd
+}
+
+// runSearch finds all matches of the query in the rendered schema and
+// highlights them. When jump is set, the first match at or below the top of
+// the viewport becomes the current one and is scrolled into view.
+func (m model) runSearch(jump bool) model {
+	m.searchMatches = findMatches(m.schemaOutput, m.searchInput.Value(), m.tableNameSet())
+
+	if len(m.searchMatches) == 0 {
+		m.searchIndex = 0
+	} else if jump {
+		m.searchIndex = 0
+		for i, match := range m.searchMatches {
+			if match.line >= m.viewport.YOffset {
+				m.searchIndex = i
+				break
+			}
+		}
+	} else if m.searchIndex >= len(m.searchMatches) {
+		m.searchIndex = 0
+	}
+
+	if len(m.searchMatches) == 0 && m.changedOutput != "" {
+		// Search highlighting takes over from change highlighting while
+		// there are matches
+		m.viewport.SetContent(m.changedOutput)
+	} else {
+		m.viewport.SetContent(highlightMatches(m.schemaOutput, m.searchMatches, m.searchIndex))
+	}
+	if jump && len(m.searchMatches) > 0 {
+		m.scrollToMatch()
+	}
+	return m
+}
+
+// nextMatch moves to the next (or previous, for a negative step) match, wrapping around.
+func (m model) nextMatch(step int) model {
+	if len(m.searchMatches) == 0 {
+		return m
+	}
+	m.searchIndex = (m.searchIndex + step + len(m.searchMatches)) % len(m.searchMatches)
+	m.viewport.SetContent(highlightMatches(m.schemaOutput, m.searchMatches, m.searchIndex))
+	m.scrollToMatch()
+	return m
+}
+
+// scrollToMatch centers the current match in the viewport unless it is already visible.
+func (m *model) scrollToMatch() {
+	line := m.searchMatches[m.searchIndex].line
+	if line >= m.viewport.YOffset && line < m.viewport.YOffset+m.viewport.Height {
+		return
+	}
+	m.viewport.SetYOffset(line - m.viewport.Height/2)
+}
+
+// tableNameSet returns the table names to restrict matches to, or nil when
+// searching everything.
+func (m model) tableNameSet() map[string]bool {
+	if !m.searchTablesOnly || m.schema == nil {
+		return nil
+	}
+
+	names := make(map[string]bool, len(m.schema.Tables))
+	for _, table := range m.schema.Tables {
+		names[table.Name] = true
+	}
+	return names
+}
+
+// searchStatus describes the search state for the status bar.
+func (m model) searchStatus() string {
+	if m.searchInput.Value() == "" {
+		return ""
+	}
+
+	scope := ""
+	if m.searchTablesOnly {
+		scope = " (tables)"
+	}
+	if len(m.searchMatches) == 0 {
+		return "  No matches" + scope
+	}
+	return fmt.Sprintf("  Match %d/%d%s", m.searchIndex+1, len(m.searchMatches), scope)
+}
+
+// findMatches returns the case-insensitive matches of query in output. When
+// tableNames is set, only matches within an identifier naming a table count.
+func findMatches(output, query string, tableNames map[string]bool) []searchMatch {
+	if query == "" {
+		return nil
+	}
+
+	re := regexp.MustCompile("(?i)" + regexp.QuoteMeta(query))
+
+	var matches []searchMatch
+	for i, line := range strings.Split(output, "\n") {
+		for _, loc := range re.FindAllStringIndex(line, -1) {
+			if tableNames != nil && !tableNames[identifierAt(line, loc[0], loc[1])] {
+				continue
+			}
+			matches = append(matches, searchMatch{line: i, start: loc[0], end: loc[1]})
+		}
+	}
+
+	return matches
+}
+
+// identifierAt expands the span [start, end) of line to the surrounding identifier.
+func identifierAt(line string, start, end int) string {
+	isIdentifier := func(c byte) bool {
+		return c == '_' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+	}
+	for start > 0 && isIdentifier(line[start-1]) {
+		start--
+	}
+	for end < len(line) && isIdentifier(line[end]) {
+		end++
+	}
+	return line[start:end]
+}
+
+// highlightMatches wraps every match of output in a highlight, using a
+// distinct style for the current match.
+func highlightMatches(output string, matches []searchMatch, current int) string {
+	if len(matches) == 0 {
+		return output
+	}
+
+	lines := strings.Split(output, "\n")
+
+	// Matches are ordered by line and offset, so each line is rebuilt once
+	for i := 0; i < len(matches); {
+		lineIndex := matches[i].line
+		line := lines[lineIndex]
+
+		var sb strings.Builder
+		last := 0
+		for ; i < len(matches) && matches[i].line == lineIndex; i++ {
+			match := matches[i]
+			style := searchMatchStyle
+			if i == current {
+				style = currentMatchStyle
+			}
+			sb.WriteString(line[last:match.start])
+			sb.WriteString(style.Render(line[match.start:match.end]))
+			last = match.end
+		}
+		sb.WriteString(line[last:])
+		lines[lineIndex] = sb.String()
+	}
+
+	return strings.Join(lines, "\n")
+}
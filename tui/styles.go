@@ -41,4 +41,52 @@ var (
 
 	dimStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("241"))
+
+	detailHeaderStyle = lipgloss.NewStyle().
+				Bold(true).
+				Foreground(lipgloss.Color("62"))
+
+	linkStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("170"))
+
+	searchMatchStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("0")).
+				Background(lipgloss.Color("186"))
+
+	groupHeaderStyle = lipgloss.NewStyle().
+				Bold(true).
+				Foreground(lipgloss.Color("62")).
+				PaddingLeft(4)
+
+	// Production connections are drawn in red to prevent mistakes
+	prodGroupHeaderStyle = groupHeaderStyle.
+				Foreground(lipgloss.Color("196"))
+
+	prodConnStyle = unselectedStyle.
+			Foreground(lipgloss.Color("203"))
+
+	envBadgeStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("0")).
+			Background(lipgloss.Color("114")).
+			Padding(0, 1)
+
+	prodBadgeStyle = envBadgeStyle.
+			Foreground(lipgloss.Color("231")).
+			Background(lipgloss.Color("160"))
+
+	prodStatusBarStyle = statusBarStyle.
+				Foreground(lipgloss.Color("231")).
+				Background(lipgloss.Color("88"))
+
+	// Tables and columns that changed since the previous refresh
+	changedStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("220"))
+
+	currentMatchStyle = lipgloss.NewStyle().
+				Bold(true).
+				Foreground(lipgloss.Color("0")).
+				Background(lipgloss.Color("214"))
 )
@@ -0,0 +1,416 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/viveknathani/dbtree/database"
+	"github.com/viveknathani/dbtree/graph"
+)
+
+// tableLink is a relationship shown in the detail pane that can be followed
+// to the table on its other end.
+type tableLink struct {
+	section string
+	label   string
+	table   string
+}
+
+func (m model) updateTables(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	if m.filtering {
+		return m.updateTableFilter(keyMsg)
+	}
+
+	visible := m.visibleTables()
+
+	if m.detailFocus {
+		links := m.tableLinks(m.selectedTable(visible))
+		switch keyMsg.String() {
+		case "up", "k":
+			if m.linkCursor > 0 {
+				m.linkCursor--
+			}
+		case "down", "j":
+			if m.linkCursor < len(links)-1 {
+				m.linkCursor++
+			}
+		case "enter":
+			if m.linkCursor < len(links) {
+				m = m.jumpToTable(links[m.linkCursor].table)
+			}
+		case "esc", "left", "h", "tab":
+			m.detailFocus = false
+		case "p":
+			return m.startPreview(visible)
+		case "q":
+			m.quitting = true
+			return m, tea.Quit
+		}
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "up", "k":
+		if m.tableCursor > 0 {
+			m.tableCursor--
+		}
+	case "down", "j":
+		if m.tableCursor < len(visible)-1 {
+			m.tableCursor++
+		}
+	case "/":
+		m.filtering = true
+		m.tableFilter.Focus()
+		return m, nil
+	case "enter", "right", "l", "tab":
+		if len(m.tableLinks(m.selectedTable(visible))) > 0 {
+			m.detailFocus = true
+			m.linkCursor = 0
+		}
+	case "p":
+		return m.startPreview(visible)
+	case "esc":
+		if m.tableFilter.Value() != "" {
+			m.tableFilter.SetValue("")
+			m.tableCursor = 0
+			return m, nil
+		}
+		m.state = stateSchema
+	case "b":
+		m.state = stateSchema
+	case "q":
+		m.quitting = true
+		return m, tea.Quit
+	}
+
+	return m, nil
+}
+
+// updateTableFilter edits the fuzzy filter of the table list.
+func (m model) updateTableFilter(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.filtering = false
+		m.tableFilter.Blur()
+		m.tableFilter.SetValue("")
+		m.tableCursor = 0
+		return m, nil
+	case tea.KeyEnter:
+		m.filtering = false
+		m.tableFilter.Blur()
+		return m, nil
+	case tea.KeyUp:
+		if m.tableCursor > 0 {
+			m.tableCursor--
+		}
+		return m, nil
+	case tea.KeyDown:
+		if m.tableCursor < len(m.visibleTables())-1 {
+			m.tableCursor++
+		}
+		return m, nil
+	}
+
+	previous := m.tableFilter.Value()
+	var cmd tea.Cmd
+	m.tableFilter, cmd = m.tableFilter.Update(msg)
+	if m.tableFilter.Value() != previous {
+		m.tableCursor = 0
+	}
+	return m, cmd
+}
+
+// startPreview loads the first rows of the selected table.
+func (m model) startPreview(visible []int) (tea.Model, tea.Cmd) {
+	selected := m.selectedTable(visible)
+	if selected == nil {
+		return m, nil
+	}
+
+	table := *selected
+	m.previewTable = &table
+	m.preview = nil
+	m.previewErr = ""
+	m.previewLoading = true
+	m.state = statePreview
+	m.previewReqID++
+	return m, loadPreview(m.db, &table, m.previewReqID)
+}
+
+// jumpToTable selects the named table, clearing the filter if it hides it.
+func (m model) jumpToTable(name string) model {
+	position := func() int {
+		for i, index := range m.visibleTables() {
+			if m.schema.Tables[index].Name == name {
+				return i
+			}
+		}
+		return -1
+	}
+
+	pos := position()
+	if pos < 0 {
+		m.tableFilter.SetValue("")
+		pos = position()
+	}
+	if pos < 0 {
+		return m
+	}
+
+	m.tableCursor = pos
+	m.linkCursor = 0
+	m.detailFocus = len(m.tableLinks(m.selectedTable(m.visibleTables()))) > 0
+	return m
+}
+
+// visibleTables returns the indexes of the tables matching the filter, best
+// matches first. Without a filter all tables are returned in schema order.
+func (m model) visibleTables() []int {
+	if m.schema == nil {
+		return nil
+	}
+
+	pattern := strings.TrimSpace(m.tableFilter.Value())
+	if pattern == "" {
+		all := make([]int, len(m.schema.Tables))
+		for i := range all {
+			all[i] = i
+		}
+		return all
+	}
+
+	scores := make(map[int]int)
+	var matches []int
+	for i, table := range m.schema.Tables {
+		if score, ok := fuzzyScore(pattern, table.Name); ok {
+			scores[i] = score
+			matches = append(matches, i)
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return scores[matches[i]] > scores[matches[j]]
+	})
+
+	return matches
+}
+
+// selectedTable returns the table under the cursor, or nil if none is visible.
+func (m model) selectedTable(visible []int) *database.Table {
+	if m.tableCursor < 0 || m.tableCursor >= len(visible) {
+		return nil
+	}
+	return &m.schema.Tables[visible[m.tableCursor]]
+}
+
+// tableLinks lists the foreign keys and data flows of a table in the order
+// they are shown in the detail pane.
+func (m model) tableLinks(table *database.Table) []tableLink {
+	if table == nil || m.graph == nil {
+		return nil
+	}
+
+	name := graph.TableName(table.Name)
+	var outgoing, incoming, flows []tableLink
+
+	for _, edge := range m.graph.Edges {
+		if edge.FromTable == name {
+			outgoing = append(outgoing, tableLink{
+				section: "References",
+				label: fmt.Sprintf("(%s) → %s(%s)", strings.Join(edge.Columns, ", "),
+					edge.ToTable, strings.Join(edge.ReferenceColumns, ", ")),
+				table: string(edge.ToTable),
+			})
+		}
+		if edge.ToTable == name {
+			incoming = append(incoming, tableLink{
+				section: "Referenced by",
+				label: fmt.Sprintf("%s(%s) → (%s)", edge.FromTable,
+					strings.Join(edge.Columns, ", "), strings.Join(edge.ReferenceColumns, ", ")),
+				table: string(edge.FromTable),
+			})
+		}
+	}
+
+	for _, flow := range m.graph.DataFlows {
+		kind := strings.ToLower(strings.ReplaceAll(string(flow.Kind), "_", " "))
+		if flow.ToTable == name {
+			flows = append(flows, tableLink{
+				section: "Data flow",
+				label:   fmt.Sprintf("← %s (%s)", flow.FromTable, kind),
+				table:   string(flow.FromTable),
+			})
+		}
+		if flow.FromTable == name {
+			flows = append(flows, tableLink{
+				section: "Data flow",
+				label:   fmt.Sprintf("→ %s (%s)", flow.ToTable, kind),
+				table:   string(flow.ToTable),
+			})
+		}
+	}
+
+	return append(append(outgoing, incoming...), flows...)
+}
+
+func (m model) viewTables() string {
+	visible := m.visibleTables()
+	selected := m.selectedTable(visible)
+
+	header := titleStyle.Render(m.connName()) + "\n"
+
+	filter := dimStyle.Render(fmt.Sprintf("  %d of %d tables", len(visible), len(m.schema.Tables)))
+	if m.filtering || m.tableFilter.Value() != "" {
+		filter = "  " + m.tableFilter.View()
+	}
+
+	// title (3 lines), filter, blank line and help
+	bodyHeight := max(m.height-6, 3)
+	listWidth := max(m.width/3, 24)
+	detailWidth := max(m.width-listWidth-2, 20)
+
+	list := lipgloss.NewStyle().Width(listWidth).Render(m.viewTableList(visible, bodyHeight, listWidth))
+	detail := m.viewTableDetail(selected, bodyHeight, detailWidth)
+	body := lipgloss.JoinHorizontal(lipgloss.Top, list, "  ", detail)
+
+	helpText := "↑/↓: Navigate  /: Filter  enter: Relationships  p: Preview rows  b: Back  q: Quit"
+	if m.filtering {
+		helpText = "type to filter  ↑/↓: Navigate  enter: Done  esc: Clear"
+	} else if m.detailFocus {
+		helpText = "↑/↓: Select relationship  enter: Go to table  esc: Back to list  p: Preview rows  q: Quit"
+	}
+
+	return header + filter + "\n" + body + "\n\n" + helpStyle.Render(helpText)
+}
+
+// viewTableList renders the filtered table list, scrolled to keep the cursor visible.
+func (m model) viewTableList(visible []int, height, width int) string {
+	if len(visible) == 0 {
+		return dimStyle.Render("    No matching tables.")
+	}
+
+	start := 0
+	if m.tableCursor >= height {
+		start = m.tableCursor - height + 1
+	}
+	end := min(start+height, len(visible))
+
+	var sb strings.Builder
+	for i := start; i < end; i++ {
+		label := truncateCell(m.schema.Tables[visible[i]].Name, width-4)
+		if i == m.tableCursor {
+			style := selectedStyle
+			if m.detailFocus {
+				style = unselectedStyle.Bold(true).PaddingLeft(2)
+			}
+			sb.WriteString(style.Render("> "+label) + "\n")
+		} else {
+			sb.WriteString(unselectedStyle.Render(label) + "\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// viewTableDetail renders the columns, keys, indexes and relationships of a table.
+func (m model) viewTableDetail(table *database.Table, height, width int) string {
+	if table == nil {
+		return ""
+	}
+
+	var lines []string
+	section := func(title string) {
+		lines = append(lines, "", detailHeaderStyle.Render(title))
+	}
+	line := func(s string) {
+		lines = append(lines, "  "+truncateCell(s, width-2))
+	}
+
+	lines = append(lines, detailHeaderStyle.Render(truncateCell(table.Name, width)))
+
+	section("Columns")
+	for _, col := range table.Columns {
+		s := fmt.Sprintf("%s %s", col.Name, col.Type)
+		if !col.IsNullable {
+			s += " NOT NULL"
+		}
+		if col.DefaultValue != "" {
+			s += " DEFAULT " + col.DefaultValue
+		}
+		line(s)
+	}
+
+	var primaryKeys, uniques, checks []string
+	for _, constraint := range table.Constraints {
+		switch constraint.Kind {
+		case database.PrimaryKey:
+			primaryKeys = append(primaryKeys, "("+strings.Join(constraint.Columns, ", ")+")")
+		case database.Unique:
+			uniques = append(uniques, "("+strings.Join(constraint.Columns, ", ")+")")
+		case database.Check:
+			checks = append(checks, constraint.CheckExpression)
+		}
+	}
+
+	for _, keys := range []struct {
+		title  string
+		values []string
+	}{
+		{"Primary key", primaryKeys},
+		{"Unique", uniques},
+		{"Checks", checks},
+	} {
+		if len(keys.values) == 0 {
+			continue
+		}
+		section(keys.title)
+		for _, v := range keys.values {
+			line(v)
+		}
+	}
+
+	if len(table.Indexes) > 0 {
+		section("Indexes")
+		for _, index := range table.Indexes {
+			s := fmt.Sprintf("%s (%s)", index.Name, strings.Join(index.Columns, ", "))
+			if index.Unique {
+				s += " UNIQUE"
+			}
+			line(s)
+		}
+	}
+
+	// Relationships can be selected, so remember the line each one is on to
+	// keep the selected one on screen
+	selectedLine := 0
+	currentSection := ""
+	for i, link := range m.tableLinks(table) {
+		if link.section != currentSection {
+			section(link.section)
+			currentSection = link.section
+		}
+		label := truncateCell(link.label, width-4)
+		if m.detailFocus && i == m.linkCursor {
+			selectedLine = len(lines)
+			lines = append(lines, linkStyle.Render("> "+label))
+		} else {
+			lines = append(lines, "  "+label)
+		}
+	}
+
+	start := 0
+	if selectedLine >= height {
+		start = selectedLine - height + 1
+	}
+	end := min(start+height, len(lines))
+
+	return strings.Join(lines[start:end], "\n")
+}
@@ -1,11 +1,44 @@
 package tui
 
-import tea "github.com/charmbracelet/bubbletea"
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/viveknathani/dbtree/render"
+	"github.com/viveknathani/dbtree/store"
+)
+
+// Options are the settings the TUI starts with. The zero value starts at the
+// password prompt with the default view.
+type Options struct {
+	// Password unlocks the connection store without prompting when set.
+	Password string
+	// Connection names a saved connection to open once the store is unlocked.
+	Connection string
+	// URL is a connection to open right away, without unlocking the store.
+	URL    *store.Connection
+	Format render.Format
+	Shape  render.Shape
+	// Filter holds table name globs, those prefixed with "!" exclude tables.
+	Filter   []string
+	MaxDepth int
+	// Stats gathers row counts and table sizes along with the schema, and
+	// SortBy orders the tables of the flat shape by them.
+	Stats  bool
+	SortBy render.SortOrder
+	// Dialect is the SQL dialect of exports in the sql format.
+	Dialect render.Dialect
+	// Go holds the settings of exports in the go format.
+	Go render.GoOptions
+	// Charset is the characters the chart shape draws with.
+	Charset render.Charset
+	// Outputs are the files the export key saves the current view to, in the
+	// format of their extension.
+	Outputs []string
+}
 
 // Run launches the interactive TUI.
-func Run() error {
+func Run(opts Options) error {
 	p := tea.NewProgram(
-		newModel(),
+		newModel(opts),
 		tea.WithAltScreen(),
 	)
 